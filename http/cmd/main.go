@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net/http"
@@ -11,22 +13,39 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 
+	"github.com/jumaroar-globant/go-bootcamp/http/config"
 	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	usergraphql "github.com/jumaroar-globant/go-bootcamp/http/graphql/user"
 	userrepository "github.com/jumaroar-globant/go-bootcamp/http/repository/user"
 	userservice "github.com/jumaroar-globant/go-bootcamp/http/service/user"
 	"github.com/jumaroar-globant/go-bootcamp/http/transport"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
-	var (
-		grpcUserServiceAddr = flag.String("addr", "localhost:50051", "The gprcUserServer address in the format of host:port")
-		httpAddr            = flag.String("http", ":8080", "http listen address")
-	)
+	cfg, err := config.Load(os.Args[1:])
+	switch {
+	case err == shared.ErrPrintConfig:
+		if err := shared.PrintConfig(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case err == flag.ErrHelp:
+		return
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	var logger log.Logger
 	{
 		logger = log.NewLogfmtLogger(os.Stderr)
 		logger = log.NewSyncLogger(logger)
+		logger = shared.NewRedactingLogger(logger, cfg.Logging.PIIFields)
 		logger = log.With(logger,
 			"service", "httpService",
 			"time:", log.DefaultTimestampUTC,
@@ -37,14 +56,35 @@ func main() {
 	level.Info(logger).Log("msg", "http service started")
 	defer level.Info(logger).Log("msg", "http service ended")
 
-	flag.Parse()
+	shutdownTracing, err := shared.SetupTracing(context.Background(), cfg.Tracing.Options())
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+	defer shutdownTracing(context.Background())
 
-	var err error
 	var grpcUserServiceConn *grpc.ClientConn
 	{
 		var opts []grpc.DialOption
-		opts = append(opts, grpc.WithInsecure())
-		grpcUserServiceConn, err = grpc.Dial(*grpcUserServiceAddr, opts...)
+		if cfg.UserService.TLSEnabled() {
+			tlsConfig, err := shared.NewClientTLSConfig(cfg.UserService.TLSOptions())
+			if err != nil {
+				level.Error(logger).Log("exit", err)
+				os.Exit(-1)
+			}
+
+			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			level.Warn(logger).Log("msg", "dialing the gprcUserServer without TLS, passwords cross the network in plaintext")
+			opts = append(opts, grpc.WithInsecure())
+		}
+		if cfg.UserService.Token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(userrepository.NewTokenCredentials(cfg.UserService.Token, cfg.UserService.TLSEnabled())))
+		}
+		clientMetrics := shared.NewRequestMetrics("gateway", "grpc_client")
+		opts = append(opts, grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor(), userrepository.ContextClientInterceptor, userrepository.MetricsClientInterceptor(clientMetrics)))
+		opts = append(opts, grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), userrepository.ContextStreamClientInterceptor, userrepository.MetricsStreamClientInterceptor(clientMetrics)))
+		grpcUserServiceConn, err = grpc.Dial(cfg.UserService.Address, opts...)
 		if err != nil {
 			level.Error(logger).Log("exit", err)
 			os.Exit(-1)
@@ -57,18 +97,99 @@ func main() {
 		srv = userservice.NewService(repository, logger)
 	}
 
-	errChan := make(chan error)
+	// errChan is buffered for every sender
+	errChan := make(chan error, 3)
 	go func() {
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 		errChan <- fmt.Errorf("%s", <-c)
 	}()
 
-	endpoints := userendpoints.MakeEndpoints(srv)
+	var tlsConfig *tls.Config
+	if cfg.TLS.CertFile != "" {
+		tlsConfig, err = shared.NewServerTLSConfig(cfg.TLS.Options())
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+	}
+
+	var adminServer *http.Server
+	if cfg.AdminAddress != "" {
+		adminServer = &http.Server{Addr: cfg.AdminAddress, Handler: shared.NewAdminHandler()}
+
+		go func() {
+			level.Info(logger).Log("msg", "admin server started", "address", cfg.AdminAddress)
+			errChan <- adminServer.ListenAndServe()
+		}()
+	}
+
+	var drain shared.Drain
+
+	var authentication transport.Authentication
+	{
+		authentication.Credentials, err = shared.ParseCredentials(cfg.Auth.Tokens)
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+
+		authentication.TrustedProxies, err = shared.ParseTrustedProxies(cfg.TrustedProxies)
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+
+		authentication.Admins = cfg.Auth.Admins
+	}
+
+	actorUserIDs, err := cfg.Auth.ActorUserIDs()
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	var streams shared.Streams
+
+	endpoints := userendpoints.Instrument(userendpoints.MakeEndpoints(srv), shared.NewRequestMetrics("gateway", "endpoint"))
+	endpoints.WatchUsers = shared.StreamsMiddleware(&streams)(endpoints.WatchUsers)
+	httpHandler := transport.FailReadinessWhile(drain.Draining, transport.NewHTTPServer(endpoints, usergraphql.NewHandler(srv, actorUserIDs), authentication, logger))
+	server := &http.Server{Addr: cfg.HTTPAddress, Handler: httpHandler, TLSConfig: tlsConfig}
+
 	go func() {
-		httpHandler := transport.NewHTTPServer(endpoints, logger)
-		errChan <- http.ListenAndServe(*httpAddr, httpHandler)
+		if tlsConfig != nil {
+			errChan <- server.ListenAndServeTLS("", "")
+			return
+		}
+
+		errChan <- server.ListenAndServe()
 	}()
 
 	level.Error(logger).Log("exit", <-errChan)
+
+	// the open event streams are canceled before the server stops
+	shared.Shutdown(logger, cfg.Shutdown.Timeout,
+		shared.ShutdownStep{Name: "readiness", Run: func(context.Context) error {
+			drain.Start()
+			return nil
+		}},
+		shared.DelayStep(cfg.Shutdown.Delay),
+		shared.ShutdownStep{Name: "streams", Run: func(context.Context) error {
+			streams.Cancel()
+			return nil
+		}},
+		shared.ShutdownStep{Name: "http", Run: func(ctx context.Context) error {
+			return shared.StopHTTPServer(ctx, server)
+		}},
+		shared.ShutdownStep{Name: "grpc_client", Run: func(context.Context) error {
+			return grpcUserServiceConn.Close()
+		}},
+		shared.ShutdownStep{Name: "admin", Run: func(ctx context.Context) error {
+			if adminServer == nil {
+				return nil
+			}
+
+			return shared.StopHTTPServer(ctx, adminServer)
+		}},
+	)
 }
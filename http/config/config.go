@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// FileEnv names the YAML or TOML configuration file of the gateway when the -config flag is not set
+const FileEnv = "GATEWAY_CONFIG_FILE"
+
+// Config is the configuration of the http gateway
+type Config struct {
+	// HTTPAddress is the address the REST and GraphQL API listens on
+	HTTPAddress string `yaml:"http_address" toml:"http_address" env:"GATEWAY_HTTP_ADDRESS" flag:"http" usage:"http listen address"`
+	// AdminAddress is the address the Prometheus metrics are served on, it is disabled when empty
+	AdminAddress string `yaml:"admin_address" toml:"admin_address" env:"GATEWAY_ADMIN_ADDRESS" flag:"admin" usage:"admin listen address of the Prometheus metrics, disabled when empty"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies whose X-Forwarded-For header is trusted
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"GATEWAY_TRUSTED_PROXIES" flag:"trusted-proxies" usage:"comma separated IPs or CIDRs of the proxies whose X-Forwarded-For is trusted"`
+
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	UserService UserServiceConfig `yaml:"user_service" toml:"user_service"`
+	TLS         TLSConfig         `yaml:"tls" toml:"tls"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown" toml:"shutdown"`
+}
+
+// AuthConfig is the authentication of the clients of the gateway
+type AuthConfig struct {
+	// Tokens are the bearer tokens the clients can present, written as actor:token
+	Tokens []string `yaml:"tokens" toml:"tokens" env:"GATEWAY_AUTH_TOKENS" secret:"true"`
+	// Admins are the actors that are administrators, only they can manage the webhook subscriptions
+	Admins []string `yaml:"admins" toml:"admins" env:"GATEWAY_AUTH_ADMINS" flag:"admins" usage:"comma separated actors that are administrators"`
+	// ActorUsers are the users the actors act as, written as actor:user_id, they are returned by the GraphQL me query
+	ActorUsers []string `yaml:"actor_users" toml:"actor_users" env:"GATEWAY_AUTH_ACTOR_USERS" flag:"actor-users" usage:"comma separated actor:user_id pairs of the actors that are users"`
+}
+
+// ActorUserIDs returns the ID of the user of every actor in ActorUsers
+func (c AuthConfig) ActorUserIDs() (map[string]string, error) {
+	userIDs := make(map[string]string, len(c.ActorUsers))
+	for i, entry := range c.ActorUsers {
+		separator := strings.Index(entry, ":")
+		if separator < 1 || separator == len(entry)-1 {
+			return nil, fmt.Errorf("the actor user %d is not written as actor:user_id", i+1)
+		}
+
+		userIDs[entry[:separator]] = entry[separator+1:]
+	}
+
+	return userIDs, nil
+}
+
+// UserServiceConfig is the connection to the gprcUserServer
+type UserServiceConfig struct {
+	Address string `yaml:"address" toml:"address" env:"GATEWAY_USER_SERVICE_ADDRESS" flag:"addr" usage:"The gprcUserServer address in the format of host:port"`
+	// TLS is used when CAFile or CertFile is set
+	CAFile      string   `yaml:"ca_file" toml:"ca_file" env:"GATEWAY_USER_CA_FILE" flag:"user-ca" usage:"CA bundle verifying the gprcUserServer certificate, TLS is used when it or -user-cert is set"`
+	CertFile    string   `yaml:"cert_file" toml:"cert_file" env:"GATEWAY_USER_CERT_FILE" flag:"user-cert" usage:"client certificate presented to the gprcUserServer for mutual TLS"`
+	KeyFile     string   `yaml:"key_file" toml:"key_file" env:"GATEWAY_USER_KEY_FILE" flag:"user-key" usage:"key of the client certificate presented to the gprcUserServer"`
+	ServerName  string   `yaml:"server_name" toml:"server_name" env:"GATEWAY_USER_SERVER_NAME" flag:"user-server-name" usage:"name the gprcUserServer certificate is verified against, defaults to the host of -addr"`
+	AllowedSANs []string `yaml:"allowed_sans" toml:"allowed_sans" env:"GATEWAY_USER_ALLOWED_SANS" flag:"user-allowed-sans" usage:"comma separated SANs the gprcUserServer certificate must have one of"`
+	// Token keeps its flag for the existing deployments, GATEWAY_USER_TOKEN_FILE keeps it out of the process list
+	Token string `yaml:"token" toml:"token" env:"GATEWAY_USER_TOKEN" flag:"user-token" usage:"bearer token the calls to the gprcUserServer authenticate with, prefer GATEWAY_USER_TOKEN_FILE" secret:"true"`
+}
+
+// TLSEnabled reports whether the gprcUserServer is dialed over TLS
+func (c UserServiceConfig) TLSEnabled() bool {
+	return c.CAFile != "" || c.CertFile != ""
+}
+
+// TLSOptions returns the TLS options of the connection to the gprcUserServer
+func (c UserServiceConfig) TLSOptions() shared.TLSOptions {
+	return shared.TLSOptions{
+		CertFile:    c.CertFile,
+		KeyFile:     c.KeyFile,
+		CAFile:      c.CAFile,
+		ServerName:  c.ServerName,
+		AllowedSANs: c.AllowedSANs,
+	}
+}
+
+// TLSConfig is the certificate of the http listener, it serves plaintext when CertFile is empty
+type TLSConfig struct {
+	CertFile     string   `yaml:"cert_file" toml:"cert_file" env:"GATEWAY_TLS_CERT_FILE" flag:"tls-cert" usage:"certificate of the http listener, it serves plaintext when empty"`
+	KeyFile      string   `yaml:"key_file" toml:"key_file" env:"GATEWAY_TLS_KEY_FILE" flag:"tls-key" usage:"key of the http listener certificate"`
+	ClientCAFile string   `yaml:"client_ca_file" toml:"client_ca_file" env:"GATEWAY_TLS_CLIENT_CA_FILE" flag:"tls-client-ca" usage:"CA bundle of the client certificates the http listener requires"`
+	AllowedSANs  []string `yaml:"allowed_sans" toml:"allowed_sans" env:"GATEWAY_TLS_ALLOWED_SANS" flag:"tls-allowed-sans" usage:"comma separated SANs the client certificates must have one of"`
+}
+
+// Options returns the TLS options of the http listener
+func (c TLSConfig) Options() shared.TLSOptions {
+	return shared.TLSOptions{
+		CertFile:    c.CertFile,
+		KeyFile:     c.KeyFile,
+		CAFile:      c.ClientCAFile,
+		AllowedSANs: c.AllowedSANs,
+	}
+}
+
+// TracingConfig is the export of the spans of the gateway
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"GATEWAY_TRACING_EXPORTER" flag:"tracing-exporter" usage:"exporter of the spans: none, otlp, stdout or file"`
+	Endpoint    string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"GATEWAY_OTLP_ENDPOINT" flag:"otlp-endpoint" usage:"host:port of the OTLP collector"`
+	Insecure    bool    `yaml:"otlp_insecure" toml:"otlp_insecure" env:"GATEWAY_OTLP_INSECURE" flag:"otlp-insecure" usage:"send the spans to the OTLP collector without TLS"`
+	FilePath    string  `yaml:"file" toml:"file" env:"GATEWAY_TRACING_FILE" flag:"tracing-file" usage:"file the spans are appended to by the file exporter"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"GATEWAY_TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"fraction of the traces started by the gateway that are sampled"`
+}
+
+// Options returns the tracing options of the gateway
+func (c TracingConfig) Options() shared.TracingOptions {
+	return shared.TracingOptions{
+		ServiceName: "gateway",
+		Exporter:    c.Exporter,
+		Endpoint:    c.Endpoint,
+		Insecure:    c.Insecure,
+		FilePath:    c.FilePath,
+		SampleRatio: c.SampleRatio,
+	}
+}
+
+// LoggingConfig is the redaction of the logs
+type LoggingConfig struct {
+	PIIFields []string `yaml:"pii_fields" toml:"pii_fields" env:"GATEWAY_LOG_PII_FIELDS" flag:"log-pii-fields" usage:"comma separated keys whose values are redacted from the logs, on top of the secrets"`
+}
+
+// ShutdownConfig is the graceful shutdown of the gateway
+type ShutdownConfig struct {
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"GATEWAY_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"deadline of the graceful shutdown, the requests still running when it passes are canceled"`
+	Delay   time.Duration `yaml:"delay" toml:"delay" env:"GATEWAY_SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"how long the gateway keeps accepting requests after its readiness probe starts failing"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() Config {
+	return Config{
+		HTTPAddress:  ":8080",
+		AdminAddress: ":9090",
+		UserService: UserServiceConfig{
+			Address: "localhost:50051",
+		},
+		Tracing: TracingConfig{
+			Exporter:    shared.NoTraceExporter,
+			Endpoint:    "localhost:4317",
+			FilePath:    "gateway-traces.json",
+			SampleRatio: 1,
+		},
+		Logging: LoggingConfig{
+			PIIFields: shared.DefaultPIIFields,
+		},
+		Shutdown: ShutdownConfig{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Load reads the configuration from the command line arguments, without the name of the binary
+func Load(args []string) (Config, error) {
+	config := Default()
+	err := shared.LoadConfig(&config, shared.ConfigOptions{Name: "gateway", Args: args, FileEnv: FileEnv})
+
+	return config, err
+}
+
+// Validate reports every invalid value of the configuration
+func (c Config) Validate() error {
+	var problems shared.ConfigErrors
+
+	if c.HTTPAddress == "" {
+		problems.Addf("the http address is required")
+	}
+
+	if c.UserService.Address == "" {
+		problems.Addf("the gprcUserServer address is required")
+	}
+
+	if (c.UserService.CertFile == "") != (c.UserService.KeyFile == "") {
+		problems.Addf("the gprcUserServer client certificate and key must be set together")
+	}
+
+	if !c.UserService.TLSEnabled() && (c.UserService.ServerName != "" || len(c.UserService.AllowedSANs) > 0) {
+		problems.Addf("the gprcUserServer server name and allowed SANs require TLS, set its CA or client certificate")
+	}
+
+	if credentials, err := shared.ParseCredentials(c.Auth.Tokens); err != nil {
+		problems.Addf("the auth tokens are invalid: %v", err)
+	} else {
+		actors := map[string]bool{}
+		for _, actor := range credentials.Actors() {
+			actors[actor] = true
+		}
+
+		for _, admin := range c.Auth.Admins {
+			if !actors[admin] {
+				problems.Addf("the admin %q has no auth token", admin)
+			}
+		}
+
+		if _, err := c.Auth.ActorUserIDs(); err != nil {
+			problems.Addf("the actor users are invalid: %v", err)
+		} else {
+			for _, entry := range c.Auth.ActorUsers {
+				if actor := entry[:strings.Index(entry, ":")]; !actors[actor] {
+					problems.Addf("the actor user %q has no auth token", actor)
+				}
+			}
+		}
+	}
+
+	if _, err := shared.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		problems.Addf("the trusted proxies are invalid: %v", err)
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problems.Addf("the TLS certificate and key must be set together")
+	}
+
+	if c.TLS.CertFile == "" && (c.TLS.ClientCAFile != "" || len(c.TLS.AllowedSANs) > 0) {
+		problems.Addf("the TLS client CA and allowed SANs require a TLS certificate")
+	}
+
+	if len(c.TLS.AllowedSANs) > 0 && c.TLS.ClientCAFile == "" {
+		problems.Addf("the TLS allowed SANs require a TLS client CA")
+	}
+
+	switch c.Tracing.Exporter {
+	case shared.NoTraceExporter, shared.OTLPTraceExporter, shared.StdoutTraceExporter, shared.FileTraceExporter:
+	default:
+		problems.Addf("unknown trace exporter %q", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems.Addf("the tracing sample ratio must be between 0 and 1")
+	}
+
+	if c.Shutdown.Timeout <= 0 {
+		problems.Addf("the shutdown timeout must be positive")
+	}
+
+	if c.Shutdown.Delay < 0 || c.Shutdown.Delay >= c.Shutdown.Timeout {
+		problems.Addf("the shutdown delay must be shorter than the shutdown timeout")
+	}
+
+	return problems.Err()
+}
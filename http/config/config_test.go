@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(Default().Validate())
+}
+
+func TestLoadKeepsTheFlags(t *testing.T) {
+	c := require.New(t)
+
+	token := filepath.Join(t.TempDir(), "token")
+	c.NoError(os.WriteFile(token, []byte("s3cret\n"), 0600))
+
+	t.Setenv("GATEWAY_HTTP_ADDRESS", ":8000")
+	t.Setenv("GATEWAY_USER_TOKEN_FILE", token)
+
+	config, err := Load([]string{"-addr", "user:50051", "-http", ":8443", "-user-allowed-sans", "user.internal", "-user-ca", "ca.pem", "-shutdown-timeout", "1m"})
+	c.NoError(err)
+	c.Equal("user:50051", config.UserService.Address)
+	c.Equal(":8443", config.HTTPAddress, "the flags override the environment")
+	c.Equal([]string{"user.internal"}, config.UserService.AllowedSANs)
+	c.Equal("s3cret", config.UserService.Token)
+	c.True(config.UserService.TLSEnabled())
+	c.Equal(time.Minute, config.Shutdown.Timeout)
+}
+
+func TestValidate(t *testing.T) {
+	c := require.New(t)
+
+	config := Default()
+	config.UserService.ServerName = "user.internal"
+	config.Auth.Tokens = []string{"secret"}
+	config.TrustedProxies = []string{"10.0.0.0/33"}
+	config.TLS.KeyFile = "server-key.pem"
+	config.Tracing.Exporter = "jaeger"
+
+	c.EqualError(config.Validate(), "invalid configuration: "+
+		"the gprcUserServer server name and allowed SANs require TLS, set its CA or client certificate; "+
+		"the auth tokens are invalid: the credential 1 is not written as actor:token; "+
+		"the trusted proxies are invalid: the trusted proxy \"10.0.0.0/33\" is not an IP address or a CIDR; "+
+		"the TLS certificate and key must be set together; "+
+		"unknown trace exporter \"jaeger\"")
+
+	config = Default()
+	config.Auth.Tokens = []string{"ops:secret"}
+	config.Auth.Admins = []string{"ops", "root"}
+	config.Auth.ActorUsers = []string{"ops:USR001", "batch:USR002"}
+
+	c.EqualError(config.Validate(), "invalid configuration: "+
+		"the admin \"root\" has no auth token; "+
+		"the actor user \"batch\" has no auth token")
+
+	config = Default()
+	config.Auth.ActorUsers = []string{"ops"}
+
+	c.EqualError(config.Validate(), "invalid configuration: the actor users are invalid: the actor user 1 is not written as actor:user_id")
+}
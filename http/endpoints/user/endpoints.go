@@ -15,11 +15,23 @@ var (
 
 //UserEndpoints are the user endpoints
 type UserEndpoints struct {
-	Authenticate endpoint.Endpoint
-	CreateUser   endpoint.Endpoint
-	GetUser      endpoint.Endpoint
-	UpdateUser   endpoint.Endpoint
-	DeleteUser   endpoint.Endpoint
+	Authenticate    endpoint.Endpoint
+	CreateUser      endpoint.Endpoint
+	GetUser         endpoint.Endpoint
+	UpdateUser      endpoint.Endpoint
+	PatchUser       endpoint.Endpoint
+	DeleteUser      endpoint.Endpoint
+	RestoreUser     endpoint.Endpoint
+	ListUsers       endpoint.Endpoint
+	ListAuditEvents endpoint.Endpoint
+	WatchUsers      endpoint.Endpoint
+
+	CreateWebhookSubscription endpoint.Endpoint
+	ListWebhookSubscriptions  endpoint.Endpoint
+	DeleteWebhookSubscription endpoint.Endpoint
+	ListWebhookDeliveries     endpoint.Endpoint
+
+	Health endpoint.Endpoint
 }
 
 //AuthenticationRequest is the authentication request
@@ -28,6 +40,11 @@ type AuthenticationRequest struct {
 	Password string
 }
 
+//LogValue logs the authentication request without the password
+func (r AuthenticationRequest) LogValue() interface{} {
+	return shared.LogFields{"username": r.Username, "password": shared.Redacted}
+}
+
 //AuthenticationResponse is the authentication response
 type AuthenticationResponse struct {
 	Message string
@@ -38,6 +55,17 @@ type GetUserRequest struct {
 	UserID string
 }
 
+//PatchUserRequest is the partial update request, only the fields named in Paths are updated
+type PatchUserRequest struct {
+	User  shared.User
+	Paths []string
+}
+
+//LogValue logs the partial update request without the password of the user
+func (r PatchUserRequest) LogValue() interface{} {
+	return shared.LogFields{"user": r.User, "paths": r.Paths}
+}
+
 //DeleteUserRequest is the delete user request
 type DeleteUserRequest struct {
 	UserID string
@@ -48,14 +76,75 @@ type DeleteUserResponse struct {
 	Message string
 }
 
+//RestoreUserRequest is the restore user request
+type RestoreUserRequest struct {
+	UserID string
+}
+
+//WatchUsersRequest is the watch users request, Send is called for every event
+type WatchUsersRequest struct {
+	UserID string
+	Cursor string
+	Send   func(shared.Event) error
+}
+
+//ListWebhookSubscriptionsRequest is the list webhook subscriptions request
+type ListWebhookSubscriptionsRequest struct{}
+
+//ListWebhookSubscriptionsResponse is the list webhook subscriptions response
+type ListWebhookSubscriptionsResponse struct {
+	Subscriptions []shared.WebhookSubscription `json:"subscriptions"`
+}
+
+//DeleteWebhookSubscriptionRequest is the delete webhook subscription request
+type DeleteWebhookSubscriptionRequest struct {
+	SubscriptionID string
+}
+
+//DeleteWebhookSubscriptionResponse is the delete webhook subscription response
+type DeleteWebhookSubscriptionResponse struct {
+	Message string
+}
+
+//UpstreamUnreachable is the upstream status reported when the user service health can not be checked
+const UpstreamUnreachable = "UNREACHABLE"
+
+//HealthRequest is the health request
+type HealthRequest struct{}
+
+//HealthResponse is the health response, Upstream is the gRPC serving status of the user service
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Upstream string `json:"upstream"`
+}
+
+//Ready reports whether the user service is serving requests
+func (r HealthResponse) Ready() bool {
+	return r.Upstream == "SERVING"
+}
+
 //MakeEndpoints creates the user endpoints
 func MakeEndpoints(s userservice.Service) *UserEndpoints {
+	validate := shared.ValidationMiddleware(requestRules)
+
 	return &UserEndpoints{
-		Authenticate: makeAuthenticationEndpoint(s),
-		CreateUser:   makeCreateUserEndpoint(s),
-		GetUser:      makeGetUserEndpoint(s),
-		UpdateUser:   makeUpdateUserEndpoint(s),
-		DeleteUser:   makeDeleteUserEndpoint(s),
+		Authenticate:    validate(makeAuthenticationEndpoint(s)),
+		CreateUser:      shared.ValidationMiddleware(createUserRules)(makeCreateUserEndpoint(s)),
+		GetUser:         validate(makeGetUserEndpoint(s)),
+		UpdateUser:      shared.ValidationMiddleware(updateUserRules)(makeUpdateUserEndpoint(s)),
+		PatchUser:       validate(makePatchUserEndpoint(s)),
+		DeleteUser:      validate(makeDeleteUserEndpoint(s)),
+		RestoreUser:     validate(makeRestoreUserEndpoint(s)),
+		ListUsers:       validate(makeListUsersEndpoint(s)),
+		ListAuditEvents: validate(makeListAuditEventsEndpoint(s)),
+		WatchUsers:      validate(makeWatchUsersEndpoint(s)),
+
+		CreateWebhookSubscription: validate(makeCreateWebhookSubscriptionEndpoint(s)),
+		ListWebhookSubscriptions:  validate(makeListWebhookSubscriptionsEndpoint(s)),
+		DeleteWebhookSubscription: validate(makeDeleteWebhookSubscriptionEndpoint(s)),
+		ListWebhookDeliveries:     validate(makeListWebhookDeliveriesEndpoint(s)),
+
+		Health: makeHealthEndpoint(s),
 	}
 }
 
@@ -107,6 +196,17 @@ func makeUpdateUserEndpoint(s userservice.Service) endpoint.Endpoint {
 	}
 }
 
+func makePatchUserEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(PatchUserRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.PatchUser(ctx, req.User, req.Paths)
+	}
+}
+
 func makeDeleteUserEndpoint(s userservice.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req, ok := request.(DeleteUserRequest)
@@ -121,3 +221,110 @@ func makeDeleteUserEndpoint(s userservice.Service) endpoint.Endpoint {
 		}, err
 	}
 }
+
+func makeRestoreUserEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(RestoreUserRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.RestoreUser(ctx, req.UserID)
+	}
+}
+
+func makeListUsersEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(shared.UserFilter)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListUsers(ctx, req)
+	}
+}
+
+func makeListAuditEventsEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(shared.AuditEventFilter)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListAuditEvents(ctx, req)
+	}
+}
+
+func makeWatchUsersEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(WatchUsersRequest)
+		if !ok || req.Send == nil {
+			return nil, errBadRequest
+		}
+
+		return nil, s.WatchUsers(ctx, req.UserID, req.Cursor, req.Send)
+	}
+}
+
+func makeCreateWebhookSubscriptionEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(shared.WebhookSubscription)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.CreateWebhookSubscription(ctx, req)
+	}
+}
+
+func makeListWebhookSubscriptionsEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_, ok := request.(ListWebhookSubscriptionsRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		subscriptions, err := s.ListWebhookSubscriptions(ctx)
+
+		return ListWebhookSubscriptionsResponse{
+			Subscriptions: subscriptions,
+		}, err
+	}
+}
+
+func makeDeleteWebhookSubscriptionEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(DeleteWebhookSubscriptionRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		message, err := s.DeleteWebhookSubscription(ctx, req.SubscriptionID)
+
+		return DeleteWebhookSubscriptionResponse{
+			Message: message,
+		}, err
+	}
+}
+
+func makeListWebhookDeliveriesEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(shared.WebhookDeliveryFilter)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListWebhookDeliveries(ctx, req)
+	}
+}
+
+func makeHealthEndpoint(s userservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		upstream, err := s.CheckHealth(ctx)
+		if err != nil {
+			upstream = UpstreamUnreachable
+		}
+
+		return HealthResponse{Status: "ok", Upstream: upstream}, nil
+	}
+}
@@ -1,9 +1,11 @@
 package userendpoints
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/jumaroar-globant/go-bootcamp/shared"
 	"github.com/stretchr/testify/require"
 )
@@ -114,6 +116,30 @@ func TestMakeUpdateUserEndpoint(t *testing.T) {
 	c.Equal(errForcedFailure, err)
 }
 
+func TestMakePatchUserEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makePatchUserEndpoint(service)
+
+	result, err := endpoint(context.Background(), PatchUserRequest{User: shared.User{ID: "USR123", Name: "test"}, Paths: []string{shared.UserPathName}})
+	c.NoError(err)
+	c.Equal("test", result.(shared.User).Name)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), PatchUserRequest{User: shared.User{ID: "USR123"}, Paths: []string{shared.UserPathName}})
+	c.Equal(errForcedFailure, err)
+}
+
 func TestMakeDeleteUserEndpoint(t *testing.T) {
 	c := require.New(t)
 
@@ -137,3 +163,246 @@ func TestMakeDeleteUserEndpoint(t *testing.T) {
 	_, err = endpoint(context.Background(), DeleteUserRequest{UserID: "USR123"})
 	c.Equal(errForcedFailure, err)
 }
+
+func TestMakeRestoreUserEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeRestoreUserEndpoint(service)
+
+	result, err := endpoint(context.Background(), RestoreUserRequest{UserID: "USR123"})
+	c.NoError(err)
+	c.Equal("USR123", result.(shared.User).ID)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), RestoreUserRequest{UserID: "USR123"})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeListUsersEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeListUsersEndpoint(service)
+
+	result, err := endpoint(context.Background(), shared.UserFilter{PageToken: "USR123"})
+	c.NoError(err)
+	c.Equal("USR123", result.(shared.UserPage).NextPageToken)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), shared.UserFilter{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeListAuditEventsEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeListAuditEventsEndpoint(service)
+
+	result, err := endpoint(context.Background(), shared.AuditEventFilter{Target: "USR123", PageToken: "10"})
+	c.NoError(err)
+	c.Equal("10", result.(shared.AuditEventPage).NextPageToken)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), shared.AuditEventFilter{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeWatchUsersEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeWatchUsersEndpoint(service)
+
+	var received []shared.Event
+	_, err := endpoint(context.Background(), WatchUsersRequest{
+		UserID: "USR123",
+		Send: func(event shared.Event) error {
+			received = append(received, event)
+			return nil
+		},
+	})
+	c.NoError(err)
+	c.Equal([]shared.Event{{ID: 2, Type: shared.EventUserCreated, AggregateID: "USR123"}}, received)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	_, err = endpoint(context.Background(), WatchUsersRequest{UserID: "USR123"})
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), WatchUsersRequest{Send: func(shared.Event) error { return nil }})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeCreateWebhookSubscriptionEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeCreateWebhookSubscriptionEndpoint(service)
+
+	result, err := endpoint(context.Background(), shared.WebhookSubscription{URL: "https://example.com/hook"})
+	c.NoError(err)
+	c.Equal("WHK123", result.(shared.WebhookSubscription).ID)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), shared.WebhookSubscription{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeListWebhookSubscriptionsEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeListWebhookSubscriptionsEndpoint(service)
+
+	result, err := endpoint(context.Background(), ListWebhookSubscriptionsRequest{})
+	c.NoError(err)
+	c.Equal("WHK123", result.(ListWebhookSubscriptionsResponse).Subscriptions[0].ID)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), ListWebhookSubscriptionsRequest{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeDeleteWebhookSubscriptionEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeDeleteWebhookSubscriptionEndpoint(service)
+
+	result, err := endpoint(context.Background(), DeleteWebhookSubscriptionRequest{SubscriptionID: "WHK123"})
+	c.NoError(err)
+	c.Equal("webhook subscription deleted successfully", result.(DeleteWebhookSubscriptionResponse).Message)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), DeleteWebhookSubscriptionRequest{SubscriptionID: "WHK123"})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeListWebhookDeliveriesEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeListWebhookDeliveriesEndpoint(service)
+
+	result, err := endpoint(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.NoError(err)
+	c.Equal("WHK123", result.(shared.WebhookDeliveryPage).Deliveries[0].SubscriptionID)
+
+	_, err = endpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = endpoint(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestMakeHealthEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	service := &serviceMock{}
+
+	endpoint := makeHealthEndpoint(service)
+
+	result, err := endpoint(context.Background(), HealthRequest{})
+	c.NoError(err)
+	c.Equal(HealthResponse{Status: "ok", Upstream: "SERVING"}, result)
+	c.True(result.(HealthResponse).Ready())
+
+	forceMockFail = true
+
+	defer func() {
+		forceMockFail = false
+	}()
+
+	result, err = endpoint(context.Background(), HealthRequest{})
+	c.NoError(err)
+	c.Equal(UpstreamUnreachable, result.(HealthResponse).Upstream)
+	c.False(result.(HealthResponse).Ready())
+}
+
+func TestRequestsAreRedacted(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := shared.NewRedactingLogger(log.NewJSONLogger(&buffer), []string{"additional_information"})
+
+	logger.Log(
+		"auth", AuthenticationRequest{Username: "admin", Password: "hunter2"},
+		"patch", PatchUserRequest{User: shared.User{ID: "USR123", Password: "hunter2", AdditionalInformation: "likes long walks"}, Paths: []string{"additional_information"}},
+	)
+
+	output := buffer.String()
+	c.NotContains(output, "hunter2")
+	c.NotContains(output, "likes long walks")
+	c.Contains(output, "USR123")
+}
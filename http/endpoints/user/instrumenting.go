@@ -0,0 +1,33 @@
+package userendpoints
+
+import (
+	"github.com/go-kit/kit/endpoint"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// Instrument returns the endpoints tracing their calls and recording them in m, named and labelled by the name of the endpoint
+func Instrument(e *UserEndpoints, m shared.RequestMetrics) *UserEndpoints {
+	instrument := func(method string, e endpoint.Endpoint) endpoint.Endpoint {
+		return endpoint.Chain(shared.TracingMiddleware(method), shared.InstrumentingMiddleware(m, method))(e)
+	}
+
+	return &UserEndpoints{
+		Authenticate:    instrument("Authenticate", e.Authenticate),
+		CreateUser:      instrument("CreateUser", e.CreateUser),
+		GetUser:         instrument("GetUser", e.GetUser),
+		UpdateUser:      instrument("UpdateUser", e.UpdateUser),
+		PatchUser:       instrument("PatchUser", e.PatchUser),
+		DeleteUser:      instrument("DeleteUser", e.DeleteUser),
+		RestoreUser:     instrument("RestoreUser", e.RestoreUser),
+		ListUsers:       instrument("ListUsers", e.ListUsers),
+		ListAuditEvents: instrument("ListAuditEvents", e.ListAuditEvents),
+		WatchUsers:      instrument("WatchUsers", e.WatchUsers),
+
+		CreateWebhookSubscription: instrument("CreateWebhookSubscription", e.CreateWebhookSubscription),
+		ListWebhookSubscriptions:  instrument("ListWebhookSubscriptions", e.ListWebhookSubscriptions),
+		DeleteWebhookSubscription: instrument("DeleteWebhookSubscription", e.DeleteWebhookSubscription),
+		ListWebhookDeliveries:     instrument("ListWebhookDeliveries", e.ListWebhookDeliveries),
+
+		Health: instrument("Health", e.Health),
+	}
+}
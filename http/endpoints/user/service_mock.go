@@ -49,6 +49,14 @@ func (m *serviceMock) UpdateUser(ctx context.Context, user shared.User) (shared.
 	return user, nil
 }
 
+func (m *serviceMock) PatchUser(ctx context.Context, user shared.User, paths []string) (shared.User, error) {
+	if forceMockFail {
+		return shared.User{}, errForcedFailure
+	}
+
+	return user, nil
+}
+
 func (m *serviceMock) DeleteUser(ctx context.Context, userID string) (string, error) {
 	if forceMockFail {
 		return "", errForcedFailure
@@ -56,3 +64,79 @@ func (m *serviceMock) DeleteUser(ctx context.Context, userID string) (string, er
 
 	return "user deleted successfully", nil
 }
+
+func (m *serviceMock) RestoreUser(ctx context.Context, userID string) (shared.User, error) {
+	if forceMockFail {
+		return shared.User{}, errForcedFailure
+	}
+
+	return shared.User{ID: userID}, nil
+}
+
+func (m *serviceMock) ListUsers(ctx context.Context, filter shared.UserFilter) (shared.UserPage, error) {
+	if forceMockFail {
+		return shared.UserPage{}, errForcedFailure
+	}
+
+	return shared.UserPage{NextPageToken: filter.PageToken}, nil
+}
+
+func (m *serviceMock) ListAuditEvents(ctx context.Context, filter shared.AuditEventFilter) (shared.AuditEventPage, error) {
+	if forceMockFail {
+		return shared.AuditEventPage{}, errForcedFailure
+	}
+
+	return shared.AuditEventPage{NextPageToken: filter.PageToken}, nil
+}
+
+func (m *serviceMock) WatchUsers(ctx context.Context, userID string, cursor string, send func(shared.Event) error) error {
+	if forceMockFail {
+		return errForcedFailure
+	}
+
+	return send(shared.Event{ID: 2, Type: shared.EventUserCreated, AggregateID: userID})
+}
+
+func (m *serviceMock) CreateWebhookSubscription(ctx context.Context, subscription shared.WebhookSubscription) (shared.WebhookSubscription, error) {
+	if forceMockFail {
+		return shared.WebhookSubscription{}, errForcedFailure
+	}
+
+	subscription.ID = "WHK123"
+
+	return subscription, nil
+}
+
+func (m *serviceMock) ListWebhookSubscriptions(ctx context.Context) ([]shared.WebhookSubscription, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return []shared.WebhookSubscription{{ID: "WHK123"}}, nil
+}
+
+func (m *serviceMock) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error) {
+	if forceMockFail {
+		return "", errForcedFailure
+	}
+
+	return "webhook subscription deleted successfully", nil
+}
+
+func (m *serviceMock) ListWebhookDeliveries(ctx context.Context, filter shared.WebhookDeliveryFilter) (shared.WebhookDeliveryPage, error) {
+	if forceMockFail {
+		return shared.WebhookDeliveryPage{}, errForcedFailure
+	}
+
+	return shared.WebhookDeliveryPage{
+		Deliveries: []shared.WebhookDelivery{{ID: 1, SubscriptionID: filter.SubscriptionID}},
+	}, nil
+}
+
+func (m *serviceMock) CheckHealth(ctx context.Context) (string, error) {
+	if forceMockFail {
+		return "", errForcedFailure
+	}
+
+	return "SERVING", nil
+}
@@ -0,0 +1,103 @@
+package userendpoints
+
+import (
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+//createUserRules are the validation rules of the create user request
+func createUserRules(request interface{}) error {
+	req, ok := request.(shared.User)
+	if !ok {
+		return nil
+	}
+
+	return shared.Validate(
+		shared.Field("name", req.Name, shared.Required()),
+		shared.Field("password", req.Password, shared.Required()),
+		birthRules(req),
+	)
+}
+
+//updateUserRules are the validation rules of the update user request
+func updateUserRules(request interface{}) error {
+	req, ok := request.(shared.User)
+	if !ok {
+		return nil
+	}
+
+	return shared.Validate(
+		shared.Field("id", req.ID, shared.Required()),
+		shared.Field("name", req.Name, shared.Required()),
+		birthRules(req),
+	)
+}
+
+//birthRules checks the date of birth of a user, the legacy age is only checked when there is no date of birth
+func birthRules(user shared.User) shared.FieldRules {
+	if user.DateOfBirth == nil {
+		return shared.Field("age", user.Age, shared.Between(shared.MinAge, shared.MaxAge))
+	}
+
+	return shared.Field("date_of_birth", user.DateOfBirth, shared.BornBetween(shared.MinAge, shared.MaxAge))
+}
+
+//patchRules checks the update mask of a partial update and only the fields listed in it
+func patchRules(req PatchUserRequest) []shared.FieldRules {
+	rules := []shared.FieldRules{
+		shared.Field("id", req.User.ID, shared.Required()),
+		shared.Field("update_mask", req.Paths, shared.Required(), shared.OneOf(shared.UserUpdatePaths...)),
+	}
+
+	for _, path := range req.Paths {
+		switch path {
+		case shared.UserPathName:
+			rules = append(rules, shared.Field("name", req.User.Name, shared.Required()))
+		case shared.UserPathDateOfBirth:
+			rules = append(rules, shared.Field("date_of_birth", req.User.DateOfBirth, shared.Required(), shared.BornBetween(shared.MinAge, shared.MaxAge)))
+		}
+	}
+
+	return rules
+}
+
+//requestRules are the validation rules of the requests that have a type of their own
+func requestRules(request interface{}) error {
+	switch req := request.(type) {
+	case AuthenticationRequest:
+		return shared.Validate(
+			shared.Field("username", req.Username, shared.Required()),
+			shared.Field("password", req.Password, shared.Required()),
+		)
+	case PatchUserRequest:
+		return shared.Validate(patchRules(req)...)
+	case GetUserRequest:
+		return shared.Validate(shared.Field("id", req.UserID, shared.Required()))
+	case DeleteUserRequest:
+		return shared.Validate(shared.Field("id", req.UserID, shared.Required()))
+	case RestoreUserRequest:
+		return shared.Validate(shared.Field("id", req.UserID, shared.Required()))
+	case shared.UserFilter:
+		return shared.Validate(shared.Field("page_size", req.PageSize, shared.AtLeast(0)))
+	case shared.AuditEventFilter:
+		return shared.Validate(
+			shared.Field("id", req.Target, shared.Required()),
+			shared.Field("page_size", req.PageSize, shared.AtLeast(0)),
+		)
+	case WatchUsersRequest:
+		return shared.Validate(shared.Field("cursor", req.Cursor, shared.WatchCursorFormat()))
+	case shared.WebhookSubscription:
+		return shared.Validate(
+			shared.Field("url", req.URL, shared.Required(), shared.AbsoluteURL("http", "https")),
+			shared.Field("event_types", req.EventTypes, shared.Required(), shared.OneOf(shared.UserEventTypes...)),
+		)
+	case DeleteWebhookSubscriptionRequest:
+		return shared.Validate(shared.Field("id", req.SubscriptionID, shared.Required()))
+	case shared.WebhookDeliveryFilter:
+		return shared.Validate(
+			shared.Field("id", req.SubscriptionID, shared.Required()),
+			shared.Field("page_size", req.PageSize, shared.AtLeast(0)),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package userendpoints
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateUserRules(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(createUserRules(shared.User{Name: "test", Password: "clave123", Age: 99}))
+	c.NoError(createUserRules("bad request"))
+
+	err := createUserRules(shared.User{Age: 5000})
+	c.True(errors.Is(err, shared.ErrInvalidRequest))
+	c.Equal([]shared.FieldViolation{
+		{Field: "name", Message: "is required"},
+		{Field: "password", Message: "is required"},
+		{Field: "age", Message: "must be between 0 and 150"},
+	}, shared.AsError(err).FieldViolations())
+}
+
+func TestUpdateUserRules(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(updateUserRules(shared.User{ID: "USR123", Name: "test", Age: 99}))
+	c.NoError(updateUserRules(shared.User{ID: "USR123", Name: "test", Age: 5000, DateOfBirth: &shared.Date{Year: 1990, Month: 6, Day: 5}}))
+
+	err := updateUserRules(shared.User{ID: "USR123", Name: "test", DateOfBirth: &shared.Date{Year: 9999, Month: 1, Day: 1}})
+	c.Equal([]shared.FieldViolation{{Field: "date_of_birth", Message: "must not be in the future"}}, shared.AsError(err).FieldViolations())
+
+	err = updateUserRules(shared.User{ID: "USR123", Age: -1})
+	c.True(errors.Is(err, shared.ErrInvalidRequest))
+	c.Equal([]shared.FieldViolation{
+		{Field: "name", Message: "is required"},
+		{Field: "age", Message: "must be between 0 and 150"},
+	}, shared.AsError(err).FieldViolations())
+}
+
+func TestRequestRules(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(requestRules(GetUserRequest{UserID: "USR123"}))
+	c.NoError(requestRules(ListWebhookSubscriptionsRequest{}))
+
+	err := requestRules(shared.UserFilter{PageSize: -1})
+	c.Equal([]shared.FieldViolation{{Field: "page_size", Message: "must be at least 0"}}, shared.AsError(err).FieldViolations())
+
+	err = requestRules(PatchUserRequest{User: shared.User{ID: "USR123"}, Paths: []string{shared.UserPathName, "age"}})
+	c.Equal([]shared.FieldViolation{
+		{Field: "update_mask", Message: `"age" must be one of name, date_of_birth, additional_information, parents`},
+		{Field: "name", Message: "is required"},
+	}, shared.AsError(err).FieldViolations())
+
+	err = requestRules(WatchUsersRequest{Cursor: "bad"})
+	c.Equal([]shared.FieldViolation{{Field: "cursor", Message: "must be a cursor of a previous event"}}, shared.AsError(err).FieldViolations())
+
+	err = requestRules(shared.WebhookSubscription{URL: "/relative"})
+	c.Equal([]shared.FieldViolation{
+		{Field: "url", Message: "must be an absolute http or https url"},
+		{Field: "event_types", Message: "is required"},
+	}, shared.AsError(err).FieldViolations())
+}
+
+func TestMakeEndpointsValidatesRequests(t *testing.T) {
+	c := require.New(t)
+
+	endpoints := MakeEndpoints(&serviceMock{})
+
+	_, err := endpoints.UpdateUser(context.Background(), shared.User{ID: "USR123", Age: 5000})
+	c.True(errors.Is(err, shared.ErrInvalidRequest))
+
+	_, err = endpoints.Authenticate(context.Background(), AuthenticationRequest{})
+	c.True(errors.Is(err, shared.ErrInvalidRequest))
+	c.Len(shared.AsError(err).FieldViolations(), 2)
+}
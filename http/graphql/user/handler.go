@@ -0,0 +1,55 @@
+package usergraphql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	userservice "github.com/jumaroar-globant/go-bootcamp/http/service/user"
+)
+
+// maxDepth is the maximum nesting of the selections of a query
+const maxDepth = 10
+
+//go:embed schema.graphql
+var schema string
+
+type handler struct {
+	schema  *graphql.Schema
+	service userservice.Service
+}
+
+// request is the body of a GraphQL request
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler serves the GraphQL schema resolved through s, actorUserIDs are the users returned by me for each actor
+func NewHandler(s userservice.Service, actorUserIDs map[string]string) http.Handler {
+	return &handler{
+		schema:  graphql.MustParseSchema(schema, &resolver{service: s, actorUserIDs: actorUserIDs}, graphql.MaxDepth(maxDepth)),
+		service: s,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("request body is not valid JSON")}})
+		return
+	}
+
+	// every request gets its own loaders, so the users and audit events of a query are fetched in batches and only once
+	ctx := contextWithUserLoader(r.Context(), newUserLoader(r.Context(), h.service))
+	ctx = contextWithAuditEventLoader(ctx, newAuditEventLoader(r.Context(), h.service))
+
+	json.NewEncoder(w).Encode(h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
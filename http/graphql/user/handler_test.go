@@ -0,0 +1,176 @@
+package usergraphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, handler http.Handler, body string) response {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+
+	return res
+}
+
+func TestUsersQueryBatchesCreators(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(
+		shared.User{ID: "USR001", Name: "admin"},
+		shared.User{ID: "USR002", Name: "two", CreatedBy: "USR001", UpdatedBy: "USR003"},
+		shared.User{ID: "USR003", Name: "three", CreatedBy: "USR001", UpdatedBy: "USR404"},
+		shared.User{ID: "USR004", Name: "four", CreatedBy: "USR005", UpdatedBy: "USR404"},
+	)
+
+	res := execute(t, NewHandler(service, nil), `{"query": "{ users { nodes { id creator { name } updater { name } } } }"}`)
+	c.Empty(res.Errors)
+	c.Len(res.Data["users"].(map[string]interface{})["nodes"], 4)
+
+	// the listed users are already known, only the missing creators and updaters are looked up, all at once
+	c.Len(service.lookups, 1)
+	c.ElementsMatch([]string{"USR005", "USR404"}, service.lookups[0])
+}
+
+func TestUsersQueryBatchesAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(
+		shared.User{ID: "USR001", Name: "admin"},
+		shared.User{ID: "USR002", Name: "two"},
+		shared.User{ID: "USR003", Name: "three"},
+	)
+
+	res := execute(t, NewHandler(service, nil), `{"query": "{ users { nodes { id auditEvents(first: 1) { nodes { id actorUser { name } } pageInfo { endCursor } } } } }"}`)
+	c.Empty(res.Errors)
+
+	for _, node := range res.Data["users"].(map[string]interface{})["nodes"].([]interface{}) {
+		user := node.(map[string]interface{})
+		auditEvents := user["auditEvents"].(map[string]interface{})
+
+		nodes := auditEvents["nodes"].([]interface{})
+		c.Len(nodes, 1, user["id"])
+
+		event := nodes[0].(map[string]interface{})
+		c.Equal(map[string]interface{}{"name": "admin"}, event["actorUser"])
+		c.Equal(event["id"], auditEvents["pageInfo"].(map[string]interface{})["endCursor"])
+	}
+
+	// the first pages of every listed user are fetched at once
+	c.Len(service.auditCalls, 1)
+	c.ElementsMatch([]string{"USR001", "USR002", "USR003"}, service.auditCalls[0].Targets)
+	c.Equal(1, service.auditCalls[0].PageSize)
+}
+
+func TestUserQueryWithAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(shared.User{ID: "USR001", Name: "admin", Parents: []string{"John Doe"}})
+
+	query := `{"query": "query ($id: ID!) { user(id: $id) { name parents auditEvents(first: 1) { nodes { action actorUser { name } changes { field before after } } pageInfo { hasNextPage endCursor } } } missing: user(id: \"USR404\") { name } }", "variables": {"id": "USR001"}}`
+
+	res := execute(t, NewHandler(service, nil), query)
+	c.Empty(res.Errors)
+
+	user := res.Data["user"].(map[string]interface{})
+	c.Equal("admin", user["name"])
+	c.Equal([]interface{}{"John Doe"}, user["parents"])
+
+	auditEvents := user["auditEvents"].(map[string]interface{})
+	c.Equal(map[string]interface{}{"hasNextPage": true, "endCursor": "1"}, auditEvents["pageInfo"])
+
+	event := auditEvents["nodes"].([]interface{})[0].(map[string]interface{})
+	c.Equal(shared.AuditActionUpdateUser, event["action"])
+	c.Equal(map[string]interface{}{"name": "admin"}, event["actorUser"])
+	c.Equal([]interface{}{map[string]interface{}{"field": "name", "before": "old", "after": "new"}}, event["changes"])
+
+	// the user and the missing one are looked up by their own fields, the actor of the event is then known
+	c.Nil(res.Data["missing"])
+	c.ElementsMatch([][]string{{"USR001"}, {"USR404"}}, service.lookups)
+}
+
+func TestUpdateUserMutation(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(shared.User{ID: "USR001", Name: "admin", AdditionalInformation: "not much"})
+	handler := NewHandler(service, nil)
+
+	res := execute(t, handler, `{"query": "mutation { updateUser(id: \"USR001\", input: {additionalInformation: \"a lot\"}) { name additionalInformation } }"}`)
+	c.Empty(res.Errors)
+	c.Equal(map[string]interface{}{"name": "admin", "additionalInformation": "a lot"}, res.Data["updateUser"])
+
+	res = execute(t, handler, `{"query": "mutation { updateUser(id: \"USR001\", input: {}) { name } }"}`)
+	c.Len(res.Errors, 1)
+	c.Equal("invalid request", res.Errors[0].Message)
+	c.Equal("INVALID_REQUEST", res.Errors[0].Extensions["code"])
+	c.Equal([]interface{}{map[string]interface{}{"name": "update_mask", "reason": "is required"}}, res.Errors[0].Extensions["invalid_params"])
+
+	res = execute(t, handler, `{"query": "mutation { updateUser(id: \"USR001\", input: {dateOfBirth: \"yesterday\"}) { name } }"}`)
+	c.Len(res.Errors, 1)
+	c.Equal("INVALID_REQUEST", res.Errors[0].Extensions["code"])
+}
+
+func TestHandlerRejectsMalformedBody(t *testing.T) {
+	c := require.New(t)
+
+	w := httptest.NewRecorder()
+	NewHandler(newServiceMock(), nil).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{")))
+	c.Equal(http.StatusBadRequest, w.Code)
+}
+
+func TestMeQuery(t *testing.T) {
+	c := require.New(t)
+
+	handler := NewHandler(newServiceMock(shared.User{ID: "USR001", Name: "admin"}), map[string]string{"ops": "USR001"})
+
+	me := func(actor string) string {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ me { name } }"}`))
+		r = r.WithContext(shared.ContextWithActor(r.Context(), actor))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Body.String()
+	}
+
+	c.JSONEq(`{"data": {"me": {"name": "admin"}}}`, me("ops"))
+	c.JSONEq(`{"data": {"me": null}}`, me("batch"), "the actor is not configured as a user")
+	c.JSONEq(`{"data": {"me": null}}`, me("USR001"), "the actor is not taken as a user ID")
+
+	res := execute(t, handler, `{"query": "{ me { name } }"}`)
+	c.Nil(res.Data["me"])
+}
+
+func TestErrorsCarryRequestID(t *testing.T) {
+	c := require.New(t)
+
+	handler := NewHandler(newServiceMock(shared.User{ID: "USR001", Name: "admin"}), nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "mutation { updateUser(id: \"USR001\", input: {}) { name } }"}`))
+	r = r.WithContext(shared.ContextWithRequestID(r.Context(), "REQ123"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var res response
+	c.NoError(json.NewDecoder(w.Body).Decode(&res))
+	c.Len(res.Errors, 1)
+	c.Equal("REQ123", res.Errors[0].Extensions["request_id"])
+}
@@ -0,0 +1,261 @@
+package usergraphql
+
+import (
+	"context"
+	"sync"
+
+	userservice "github.com/jumaroar-globant/go-bootcamp/http/service/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// maxBatchSize is the maximum number of keys fetched by a single call
+const maxBatchSize = 100
+
+type loaderContextKey struct{}
+
+type auditEventLoaderContextKey struct{}
+
+// userLoader batches the users loaded while a query is resolved into ListUsers calls and caches them for the query
+type userLoader struct {
+	ctx     context.Context
+	service userservice.Service
+
+	mu     sync.Mutex
+	loads  map[string]*userLoad
+	queued []string
+}
+
+// userLoad is the result of loading a user, done is closed once it is known
+type userLoad struct {
+	done       chan struct{}
+	dispatched bool
+	user       *shared.User
+	err        error
+}
+
+func newUserLoader(ctx context.Context, s userservice.Service) *userLoader {
+	return &userLoader{
+		ctx:     ctx,
+		service: s,
+		loads:   map[string]*userLoad{},
+	}
+}
+
+func contextWithUserLoader(ctx context.Context, loader *userLoader) context.Context {
+	return context.WithValue(ctx, loaderContextKey{}, loader)
+}
+
+func userLoaderFromContext(ctx context.Context) *userLoader {
+	return ctx.Value(loaderContextKey{}).(*userLoader)
+}
+
+// Queue adds the users that are about to be loaded to the next batch, the ones already known are skipped
+func (l *userLoader) Queue(ids ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		l.queue(id)
+	}
+}
+
+// queue must be called with the lock held
+func (l *userLoader) queue(id string) *userLoad {
+	load, ok := l.loads[id]
+	if !ok && id != "" {
+		load = &userLoad{done: make(chan struct{})}
+		l.loads[id] = load
+		l.queued = append(l.queued, id)
+	}
+
+	return load
+}
+
+// Load returns the user with the given ID, or nil when it does not exist, fetching it together with the queued users
+func (l *userLoader) Load(ctx context.Context, id string) (*shared.User, error) {
+	l.mu.Lock()
+
+	load := l.queue(id)
+
+	var ids []string
+	if !load.dispatched {
+		ids = l.queued
+		l.queued = nil
+
+		for _, queued := range ids {
+			l.loads[queued].dispatched = true
+		}
+	}
+
+	l.mu.Unlock()
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+
+		ids = ids[len(batch):]
+		l.fetch(batch)
+	}
+
+	select {
+	case <-load.done:
+		return load.user, load.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Prime caches a user obtained by other means, a later Load of it does not make a call
+func (l *userLoader) Prime(user shared.User) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.loads[user.ID]; ok {
+		return
+	}
+
+	load := &userLoad{done: make(chan struct{}), dispatched: true, user: &user}
+	close(load.done)
+
+	l.loads[user.ID] = load
+}
+
+func (l *userLoader) fetch(ids []string) {
+	page, err := l.service.ListUsers(l.ctx, shared.UserFilter{IDs: ids, PageSize: len(ids)})
+
+	users := map[string]shared.User{}
+	for _, user := range page.Users {
+		users[user.ID] = user
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		load := l.loads[id]
+
+		if user, ok := users[id]; ok {
+			load.user = &user
+		}
+
+		load.err = err
+		close(load.done)
+	}
+}
+
+// auditEventLoader batches the first audit event pages loaded while a query is resolved and caches them for the query
+type auditEventLoader struct {
+	ctx     context.Context
+	service userservice.Service
+
+	mu      sync.Mutex
+	loads   map[auditEventKey]*auditEventLoad
+	targets []string
+}
+
+// auditEventKey is a first page of the audit events of a target
+type auditEventKey struct {
+	target   string
+	pageSize int
+}
+
+// auditEventLoad is the result of loading a first page, done is closed once it is known
+type auditEventLoad struct {
+	done chan struct{}
+	page shared.AuditEventPage
+	err  error
+}
+
+func newAuditEventLoader(ctx context.Context, s userservice.Service) *auditEventLoader {
+	return &auditEventLoader{
+		ctx:     ctx,
+		service: s,
+		loads:   map[auditEventKey]*auditEventLoad{},
+	}
+}
+
+func contextWithAuditEventLoader(ctx context.Context, loader *auditEventLoader) context.Context {
+	return context.WithValue(ctx, auditEventLoaderContextKey{}, loader)
+}
+
+func auditEventLoaderFromContext(ctx context.Context) *auditEventLoader {
+	return ctx.Value(auditEventLoaderContextKey{}).(*auditEventLoader)
+}
+
+// Queue adds the targets whose audit events are about to be loaded, they are fetched with the first page loaded
+func (l *auditEventLoader) Queue(targets ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.targets = append(l.targets, targets...)
+}
+
+// Load returns the first page of the audit events of target, fetching it together with the queued targets
+func (l *auditEventLoader) Load(ctx context.Context, target string, pageSize int) (shared.AuditEventPage, error) {
+	l.mu.Lock()
+
+	key := auditEventKey{target: target, pageSize: pageSize}
+
+	var targets []string
+	load, ok := l.loads[key]
+	if !ok {
+		for _, queued := range append([]string{target}, l.targets...) {
+			queuedKey := auditEventKey{target: queued, pageSize: pageSize}
+			if _, ok := l.loads[queuedKey]; ok || queued == "" {
+				continue
+			}
+
+			l.loads[queuedKey] = &auditEventLoad{done: make(chan struct{})}
+			targets = append(targets, queued)
+		}
+
+		load = l.loads[key]
+	}
+
+	l.mu.Unlock()
+
+	for len(targets) > 0 {
+		batch := targets
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+
+		targets = targets[len(batch):]
+		l.fetch(batch, pageSize)
+	}
+
+	select {
+	case <-load.done:
+		return load.page, load.err
+	case <-ctx.Done():
+		return shared.AuditEventPage{}, ctx.Err()
+	}
+}
+
+func (l *auditEventLoader) fetch(targets []string, pageSize int) {
+	page, err := l.service.ListAuditEvents(l.ctx, shared.AuditEventFilter{Targets: targets, PageSize: pageSize})
+
+	pages := map[string]*shared.AuditEventPage{}
+	for _, target := range targets {
+		pages[target] = &shared.AuditEventPage{NextPageToken: page.NextPageTokens[target]}
+	}
+
+	for _, event := range page.Events {
+		if targetPage, ok := pages[event.Target]; ok {
+			targetPage.Events = append(targetPage.Events, event)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, target := range targets {
+		load := l.loads[auditEventKey{target: target, pageSize: pageSize}]
+
+		load.page = *pages[target]
+		load.err = err
+		close(load.done)
+	}
+}
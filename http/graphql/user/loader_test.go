@@ -0,0 +1,123 @@
+package usergraphql
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLoaderBatchesAndCaches(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(shared.User{ID: "USR001", Name: "one"}, shared.User{ID: "USR002", Name: "two"})
+	loader := newUserLoader(context.Background(), service)
+	loader.Queue("USR001", "USR002", "USR404")
+
+	ids := []string{"USR001", "USR002", "USR001", "USR404"}
+	users := make([]*shared.User, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			user, err := loader.Load(context.Background(), id)
+			c.NoError(err)
+
+			users[i] = user
+		}(i, id)
+	}
+	wg.Wait()
+
+	c.Equal("one", users[0].Name)
+	c.Equal("two", users[1].Name)
+	c.Equal("one", users[2].Name)
+	c.Nil(users[3])
+	c.Len(service.lookups, 1)
+	c.ElementsMatch([]string{"USR001", "USR002", "USR404"}, service.lookups[0])
+
+	user, err := loader.Load(context.Background(), "USR002")
+	c.NoError(err)
+	c.Equal("two", user.Name)
+	c.Len(service.lookups, 1)
+
+	loader.Prime(shared.User{ID: "USR003", Name: "three"})
+	user, err = loader.Load(context.Background(), "USR003")
+	c.NoError(err)
+	c.Equal("three", user.Name)
+	c.Len(service.lookups, 1)
+
+	loader.Queue("USR003", "USR404")
+	_, err = loader.Load(context.Background(), "USR005")
+	c.NoError(err)
+	c.Equal([][]string{{"USR001", "USR002", "USR404"}, {"USR005"}}, service.lookups)
+}
+
+func TestUserLoaderFetchesOnTheRequestContext(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock(shared.User{ID: "USR001", Name: "one"}, shared.User{ID: "USR002", Name: "two"})
+	loader := newUserLoader(context.Background(), service)
+	loader.Queue("USR001", "USR002")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	loader.Load(canceled, "USR001")
+
+	user, err := loader.Load(context.Background(), "USR002")
+	c.NoError(err)
+	c.Equal("two", user.Name)
+	c.Len(service.lookups, 1)
+}
+
+func TestUserLoaderFails(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock()
+	service.failLookups = true
+
+	_, err := newUserLoader(context.Background(), service).Load(context.Background(), "USR001")
+	c.Equal(errForcedFailure, err)
+}
+
+func TestAuditEventLoaderBatchesAndCaches(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock()
+	loader := newAuditEventLoader(context.Background(), service)
+	loader.Queue("USR001", "USR002")
+
+	page, err := loader.Load(context.Background(), "USR002", 1)
+	c.NoError(err)
+	c.Len(page.Events, 1)
+	c.Equal("USR002", page.Events[0].Target)
+	c.Equal(strconv.FormatInt(page.Events[0].ID, 10), page.NextPageToken)
+
+	page, err = loader.Load(context.Background(), "USR001", 1)
+	c.NoError(err)
+	c.Equal("USR001", page.Events[0].Target)
+	c.Len(service.auditCalls, 1)
+	c.Equal([]string{"USR002", "USR001"}, service.auditCalls[0].Targets)
+
+	// another page size is another page, it is fetched for the queued targets too
+	_, err = loader.Load(context.Background(), "USR001", 5)
+	c.NoError(err)
+	c.Len(service.auditCalls, 2)
+	c.Equal(shared.AuditEventFilter{Targets: []string{"USR001", "USR002"}, PageSize: 5}, service.auditCalls[1])
+}
+
+func TestAuditEventLoaderFails(t *testing.T) {
+	c := require.New(t)
+
+	service := newServiceMock()
+	service.failLookups = true
+
+	_, err := newAuditEventLoader(context.Background(), service).Load(context.Background(), "USR001", 1)
+	c.Equal(errForcedFailure, err)
+}
@@ -0,0 +1,461 @@
+package usergraphql
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	userservice "github.com/jumaroar-globant/go-bootcamp/http/service/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// resolver is the root resolver of the queries and mutations
+type resolver struct {
+	service      userservice.Service
+	actorUserIDs map[string]string
+}
+
+// resolverError exposes only the safe message of an error, its code, the rejected fields and the request ID go in the extensions
+type resolverError struct {
+	err       *shared.Error
+	requestID string
+}
+
+func newResolverError(ctx context.Context, err error) error {
+	return resolverError{err: shared.AsError(err), requestID: shared.RequestIDFromContext(ctx)}
+}
+
+func (e resolverError) Error() string {
+	return e.err.Message
+}
+
+func (e resolverError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.err.Code}
+
+	if e.requestID != "" {
+		extensions["request_id"] = e.requestID
+	}
+
+	violations := e.err.FieldViolations()
+	if len(violations) > 0 {
+		invalidParams := make([]map[string]string, 0, len(violations))
+		for _, violation := range violations {
+			invalidParams = append(invalidParams, map[string]string{"name": violation.Field, "reason": violation.Message})
+		}
+
+		extensions["invalid_params"] = invalidParams
+	}
+
+	return extensions
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	return r.loadUser(ctx, string(args.ID))
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	First     *int32
+	After     *string
+	CreatedBy *string
+	UpdatedBy *string
+}) (*userConnectionResolver, error) {
+	filter := shared.UserFilter{
+		CreatedBy: stringValue(args.CreatedBy),
+		UpdatedBy: stringValue(args.UpdatedBy),
+		PageSize:  int(int32Value(args.First)),
+		PageToken: stringValue(args.After),
+	}
+
+	page, err := r.service.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, newResolverError(ctx, err)
+	}
+
+	loader := userLoaderFromContext(ctx)
+
+	connection := &userConnectionResolver{pageInfo: pageInfoResolver{endCursor: page.NextPageToken}}
+	for _, user := range page.Users {
+		loader.Prime(user)
+		connection.nodes = append(connection.nodes, &userResolver{root: r, user: user})
+	}
+
+	auditEventLoader := auditEventLoaderFromContext(ctx)
+	for _, user := range page.Users {
+		loader.Queue(user.CreatedBy, user.UpdatedBy)
+		auditEventLoader.Queue(user.ID)
+	}
+
+	return connection, nil
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	userID, ok := r.actorUserIDs[shared.ActorFromContext(ctx)]
+	if !ok {
+		return nil, nil
+	}
+
+	return r.loadUser(ctx, userID)
+}
+
+func (r *resolver) Authenticate(ctx context.Context, args struct {
+	Username string
+	Password string
+}) (string, error) {
+	message, err := r.service.Authenticate(ctx, args.Username, args.Password)
+	if err != nil {
+		return "", newResolverError(ctx, err)
+	}
+
+	return message, nil
+}
+
+type createUserInput struct {
+	Name                  string
+	Password              string
+	DateOfBirth           string
+	AdditionalInformation *string
+	Parents               *[]string
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	dateOfBirth, err := parseDate(ctx, args.Input.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+
+	user := shared.User{
+		Name:                  args.Input.Name,
+		Password:              args.Input.Password,
+		DateOfBirth:           dateOfBirth,
+		AdditionalInformation: stringValue(args.Input.AdditionalInformation),
+	}
+
+	if args.Input.Parents != nil {
+		user.Parents = *args.Input.Parents
+	}
+
+	created, err := r.service.CreateUser(ctx, user)
+	if err != nil {
+		return nil, newResolverError(ctx, err)
+	}
+
+	return &userResolver{root: r, user: created}, nil
+}
+
+type updateUserInput struct {
+	Name                  *string
+	DateOfBirth           *string
+	AdditionalInformation *string
+	Parents               *[]string
+}
+
+// UpdateUser updates the fields given in the input through PatchUser, the missing and null fields are kept
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateUserInput
+}) (*userResolver, error) {
+	user := shared.User{ID: string(args.ID)}
+	paths := []string{}
+
+	if args.Input.Name != nil {
+		user.Name = *args.Input.Name
+		paths = append(paths, shared.UserPathName)
+	}
+
+	if args.Input.DateOfBirth != nil {
+		dateOfBirth, err := parseDate(ctx, *args.Input.DateOfBirth)
+		if err != nil {
+			return nil, err
+		}
+
+		user.DateOfBirth = dateOfBirth
+		paths = append(paths, shared.UserPathDateOfBirth)
+	}
+
+	if args.Input.AdditionalInformation != nil {
+		user.AdditionalInformation = *args.Input.AdditionalInformation
+		paths = append(paths, shared.UserPathAdditionalInformation)
+	}
+
+	if args.Input.Parents != nil {
+		user.Parents = *args.Input.Parents
+		paths = append(paths, shared.UserPathParents)
+	}
+
+	updated, err := r.service.PatchUser(ctx, user, paths)
+	if err != nil {
+		return nil, newResolverError(ctx, err)
+	}
+
+	return &userResolver{root: r, user: updated}, nil
+}
+
+func (r *resolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (string, error) {
+	message, err := r.service.DeleteUser(ctx, string(args.ID))
+	if err != nil {
+		return "", newResolverError(ctx, err)
+	}
+
+	return message, nil
+}
+
+// loadUser resolves a user through the loader of the query and queues its creator and updater, it is nil when the user does not exist
+func (r *resolver) loadUser(ctx context.Context, id string) (*userResolver, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	loader := userLoaderFromContext(ctx)
+
+	user, err := loader.Load(ctx, id)
+	if err != nil {
+		return nil, newResolverError(ctx, err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	loader.Queue(user.CreatedBy, user.UpdatedBy)
+
+	return &userResolver{root: r, user: *user}, nil
+}
+
+type userResolver struct {
+	root *resolver
+	user shared.User
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.user.ID)
+}
+
+func (u *userResolver) Name() string {
+	return u.user.Name
+}
+
+func (u *userResolver) Age() int32 {
+	return int32(u.user.Age)
+}
+
+func (u *userResolver) DateOfBirth() *string {
+	if u.user.DateOfBirth == nil {
+		return nil
+	}
+
+	dateOfBirth := u.user.DateOfBirth.String()
+
+	return &dateOfBirth
+}
+
+func (u *userResolver) DateOfBirthEstimated() bool {
+	return u.user.DateOfBirthEstimated
+}
+
+func (u *userResolver) AdditionalInformation() string {
+	return u.user.AdditionalInformation
+}
+
+func (u *userResolver) Parents() []string {
+	if u.user.Parents == nil {
+		return []string{}
+	}
+
+	return u.user.Parents
+}
+
+func (u *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: u.user.CreatedAt}
+}
+
+func (u *userResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: u.user.UpdatedAt}
+}
+
+func (u *userResolver) CreatedBy() string {
+	return u.user.CreatedBy
+}
+
+func (u *userResolver) UpdatedBy() string {
+	return u.user.UpdatedBy
+}
+
+func (u *userResolver) Creator(ctx context.Context) (*userResolver, error) {
+	return u.root.loadUser(ctx, u.user.CreatedBy)
+}
+
+func (u *userResolver) Updater(ctx context.Context) (*userResolver, error) {
+	return u.root.loadUser(ctx, u.user.UpdatedBy)
+}
+
+func (u *userResolver) AuditEvents(ctx context.Context, args struct {
+	First *int32
+	After *string
+}) (*auditEventConnectionResolver, error) {
+	var page shared.AuditEventPage
+	var err error
+	if args.After == nil {
+		page, err = auditEventLoaderFromContext(ctx).Load(ctx, u.user.ID, int(int32Value(args.First)))
+	} else {
+		page, err = u.root.service.ListAuditEvents(ctx, shared.AuditEventFilter{
+			Target:    u.user.ID,
+			PageSize:  int(int32Value(args.First)),
+			PageToken: *args.After,
+		})
+	}
+
+	if err != nil {
+		return nil, newResolverError(ctx, err)
+	}
+
+	loader := userLoaderFromContext(ctx)
+
+	connection := &auditEventConnectionResolver{pageInfo: pageInfoResolver{endCursor: page.NextPageToken}}
+	for _, event := range page.Events {
+		loader.Queue(event.Actor)
+		connection.nodes = append(connection.nodes, &auditEventResolver{root: u.root, event: event})
+	}
+
+	return connection, nil
+}
+
+type userConnectionResolver struct {
+	nodes    []*userResolver
+	pageInfo pageInfoResolver
+}
+
+func (c *userConnectionResolver) Nodes() []*userResolver {
+	return c.nodes
+}
+
+func (c *userConnectionResolver) PageInfo() *pageInfoResolver {
+	return &c.pageInfo
+}
+
+type auditEventResolver struct {
+	root  *resolver
+	event shared.AuditEvent
+}
+
+func (e *auditEventResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(e.event.ID, 10))
+}
+
+func (e *auditEventResolver) OccurredAt() graphql.Time {
+	return graphql.Time{Time: e.event.OccurredAt}
+}
+
+func (e *auditEventResolver) Actor() string {
+	return e.event.Actor
+}
+
+func (e *auditEventResolver) ActorUser(ctx context.Context) (*userResolver, error) {
+	return e.root.loadUser(ctx, e.event.Actor)
+}
+
+func (e *auditEventResolver) Action() string {
+	return e.event.Action
+}
+
+func (e *auditEventResolver) Outcome() string {
+	return e.event.Outcome
+}
+
+func (e *auditEventResolver) Reason() *string {
+	return optionalString(e.event.Reason)
+}
+
+func (e *auditEventResolver) RequestID() *string {
+	return optionalString(e.event.RequestID)
+}
+
+func (e *auditEventResolver) Changes() []*auditChangeResolver {
+	changes := make([]*auditChangeResolver, 0, len(e.event.Changes))
+	for field, change := range e.event.Changes {
+		changes = append(changes, &auditChangeResolver{field: field, change: change})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].field < changes[j].field
+	})
+
+	return changes
+}
+
+type auditChangeResolver struct {
+	field  string
+	change shared.AuditChange
+}
+
+func (c *auditChangeResolver) Field() string {
+	return c.field
+}
+
+func (c *auditChangeResolver) Before() *string {
+	return optionalString(c.change.Before)
+}
+
+func (c *auditChangeResolver) After() *string {
+	return optionalString(c.change.After)
+}
+
+type auditEventConnectionResolver struct {
+	nodes    []*auditEventResolver
+	pageInfo pageInfoResolver
+}
+
+func (c *auditEventConnectionResolver) Nodes() []*auditEventResolver {
+	return c.nodes
+}
+
+func (c *auditEventConnectionResolver) PageInfo() *pageInfoResolver {
+	return &c.pageInfo
+}
+
+type pageInfoResolver struct {
+	endCursor string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool {
+	return p.endCursor != ""
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	return optionalString(p.endCursor)
+}
+
+// parseDate parses a YYYY-MM-DD date argument
+func parseDate(ctx context.Context, value string) (*shared.Date, error) {
+	date, err := shared.ParseDate(value)
+	if err != nil {
+		return nil, newResolverError(ctx, shared.ErrInvalidRequest.WithViolations([]shared.FieldViolation{{Field: "date_of_birth", Message: "must be a YYYY-MM-DD date"}}))
+	}
+
+	return &date, nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func int32Value(value *int32) int32 {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,119 @@
+package usergraphql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	userservice "github.com/jumaroar-globant/go-bootcamp/http/service/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var (
+	errForcedFailure = errors.New("forced failure")
+)
+
+// serviceMock serves users, it counts the ListUsers calls that look up users by ID and the ListAuditEvents calls
+type serviceMock struct {
+	userservice.Service
+
+	mu          sync.Mutex
+	users       map[string]shared.User
+	lookups     [][]string
+	failLookups bool
+	auditCalls  []shared.AuditEventFilter
+}
+
+func newServiceMock(users ...shared.User) *serviceMock {
+	m := &serviceMock{users: map[string]shared.User{}}
+	for _, user := range users {
+		m.users[user.ID] = user
+	}
+
+	return m
+}
+
+func (m *serviceMock) ListUsers(ctx context.Context, filter shared.UserFilter) (shared.UserPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := shared.UserPage{}
+
+	if filter.IDs == nil {
+		for _, user := range m.users {
+			page.Users = append(page.Users, user)
+		}
+
+		return page, nil
+	}
+
+	m.lookups = append(m.lookups, filter.IDs)
+	if m.failLookups {
+		return shared.UserPage{}, errForcedFailure
+	}
+
+	if err := ctx.Err(); err != nil {
+		return shared.UserPage{}, err
+	}
+
+	for _, id := range filter.IDs {
+		if user, ok := m.users[id]; ok {
+			page.Users = append(page.Users, user)
+		}
+	}
+
+	return page, nil
+}
+
+// ListAuditEvents returns an update of every target, made by USR001, with a next page
+func (m *serviceMock) ListAuditEvents(ctx context.Context, filter shared.AuditEventFilter) (shared.AuditEventPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.auditCalls = append(m.auditCalls, filter)
+	if m.failLookups {
+		return shared.AuditEventPage{}, errForcedFailure
+	}
+
+	update := func(id int64, target string) shared.AuditEvent {
+		return shared.AuditEvent{ID: id, Actor: "USR001", Action: shared.AuditActionUpdateUser, Target: target, Outcome: shared.AuditOutcomeSuccess, Changes: map[string]shared.AuditChange{"name": {Before: "old", After: "new"}}}
+	}
+
+	if len(filter.Targets) == 0 {
+		return shared.AuditEventPage{Events: []shared.AuditEvent{update(1, filter.Target)}, NextPageToken: "1"}, nil
+	}
+
+	page := shared.AuditEventPage{NextPageTokens: map[string]string{}}
+	for i, target := range filter.Targets {
+		event := update(int64(i+1), target)
+
+		page.Events = append(page.Events, event)
+		page.NextPageTokens[target] = strconv.FormatInt(event.ID, 10)
+	}
+
+	return page, nil
+}
+
+func (m *serviceMock) PatchUser(ctx context.Context, user shared.User, paths []string) (shared.User, error) {
+	if len(paths) == 0 {
+		return shared.User{}, shared.ErrInvalidRequest.WithViolations([]shared.FieldViolation{{Field: "update_mask", Message: "is required"}})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.users[user.ID]
+	for _, path := range paths {
+		switch path {
+		case shared.UserPathName:
+			current.Name = user.Name
+		case shared.UserPathAdditionalInformation:
+			current.AdditionalInformation = user.AdditionalInformation
+		}
+	}
+
+	m.users[user.ID] = current
+
+	return current, nil
+}
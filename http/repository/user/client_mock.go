@@ -10,7 +10,14 @@ import (
 	gokitLog "github.com/go-kit/log"
 
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -18,16 +25,25 @@ var (
 	errForcedFailure = errors.New("forced failure")
 	forceMockFail    = false
 	forceBadAge      = false
+	forceBadCursor   = false
+	lastMetadata     metadata.MD
+	healthMock       = health.NewServer()
 )
 
 type grpcMock struct {
 	pb.UnimplementedUserServiceServer
 }
 
+type grpcMockV2 struct {
+	pbv2.UnimplementedUserServiceServer
+}
+
 func initDialer(m *grpcMock) func(context.Context, string) (net.Conn, error) {
 	listener := bufconn.Listen(1024 * 1024)
 	server := grpc.NewServer()
 	pb.RegisterUserServiceServer(server, m)
+	pbv2.RegisterUserServiceServer(server, &grpcMockV2{})
+	healthpb.RegisterHealthServer(server, healthMock)
 
 	go func() {
 		if err := server.Serve(listener); err != nil {
@@ -54,7 +70,7 @@ func InitGRPCMock() (UserRepository, error) {
 
 	grpcServer := &grpcMock{}
 
-	conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(initDialer(grpcServer)))
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(initDialer(grpcServer)), grpc.WithUnaryInterceptor(ContextClientInterceptor), grpc.WithStreamInterceptor(ContextStreamClientInterceptor))
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +96,12 @@ func (m *grpcMock) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*
 	}
 
 	response := &pb.CreateUserResponse{
-		Id:   "USR123",
-		Name: req.Name,
-		Age:  "99",
+		Id:                   "USR123",
+		Name:                 req.Name,
+		Age:                  "99",
+		AgeYears:             99,
+		DateOfBirth:          req.DateOfBirth,
+		DateOfBirthEstimated: req.DateOfBirth == nil,
 	}
 
 	if forceBadAge {
@@ -97,10 +116,15 @@ func (m *grpcMock) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.Get
 		return nil, errForcedFailure
 	}
 
+	if req.Id != "USR123" {
+		return nil, sharedLib.ToGRPCStatus(sharedLib.ErrUserNotFound).Err()
+	}
+
 	response := &pb.GetUserResponse{
-		Id:   "USR123",
-		Name: "test",
-		Age:  "99",
+		Id:       "USR123",
+		Name:     "test",
+		Age:      "99",
+		AgeYears: 99,
 	}
 
 	if forceBadAge {
@@ -116,9 +140,11 @@ func (m *grpcMock) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*
 	}
 
 	response := &pb.UpdateUserResponse{
-		Id:   "USR123",
-		Name: req.Name,
-		Age:  "99",
+		Id:          "USR123",
+		Name:        req.Name,
+		Age:         "99",
+		AgeYears:    99,
+		DateOfBirth: req.DateOfBirth,
 	}
 
 	if forceBadAge {
@@ -128,12 +154,181 @@ func (m *grpcMock) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*
 	return response, nil
 }
 
+func (m *grpcMockV2) UpdateUser(ctx context.Context, req *pbv2.UpdateUserRequest) (*pbv2.User, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	response := &pbv2.User{
+		Id:                    req.User.Id,
+		Name:                  "test",
+		Age:                   99,
+		AdditionalInformation: "not much",
+	}
+
+	for _, path := range req.UpdateMask.GetPaths() {
+		switch path {
+		case sharedLib.UserPathName:
+			response.Name = req.User.Name
+		case sharedLib.UserPathAdditionalInformation:
+			response.AdditionalInformation = req.User.AdditionalInformation
+		}
+	}
+
+	return response, nil
+}
+
 func (m *grpcMock) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
 	if forceMockFail {
 		return nil, errForcedFailure
 	}
 
+	lastMetadata, _ = metadata.FromIncomingContext(ctx)
+
 	return &pb.DeleteUserResponse{
 		Message: "user deleted successfully",
 	}, nil
 }
+
+func (m *grpcMock) RestoreUser(ctx context.Context, req *pb.RestoreUserRequest) (*pb.RestoreUserResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	response := &pb.RestoreUserResponse{
+		Id:       req.Id,
+		Name:     "test",
+		Age:      "99",
+		AgeYears: 99,
+	}
+
+	if forceBadAge {
+		response.Age = "a"
+	}
+
+	return response, nil
+}
+
+func (m *grpcMock) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	user := &pb.User{
+		Id:        "USR123",
+		Name:      "test",
+		Age:       "99",
+		AgeYears:  99,
+		CreatedBy: req.CreatedBy,
+		CreatedAt: req.CreatedAfter,
+	}
+
+	if forceBadAge {
+		user.Age = "a"
+	}
+
+	return &pb.ListUsersResponse{
+		Users:         []*pb.User{user},
+		NextPageToken: "USR123",
+	}, nil
+}
+
+func (m *grpcMock) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return &pb.ListAuditEventsResponse{
+		Events: []*pb.AuditEvent{
+			{
+				Id:      1,
+				Actor:   "admin",
+				Action:  "delete_user",
+				Target:  req.Target,
+				Changes: map[string]*pb.AuditChange{"name": {Before: "test"}},
+				Outcome: "success",
+			},
+		},
+		NextPageToken: "1",
+	}, nil
+}
+
+func (m *grpcMock) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserService_WatchUsersServer) error {
+	if forceMockFail {
+		return errForcedFailure
+	}
+
+	lastMetadata, _ = metadata.FromIncomingContext(stream.Context())
+
+	cursor := "1"
+	if forceBadCursor {
+		cursor = "bad"
+	}
+
+	for i, eventType := range []string{"user.created", "user.updated"} {
+		err := stream.Send(&pb.UserEvent{
+			Id:      int64(i + 1),
+			Cursor:  cursor,
+			Type:    eventType,
+			UserId:  "USR123",
+			Actor:   "admin",
+			Payload: `{"id":"USR123"}`,
+		})
+		if err != nil {
+			return err
+		}
+
+		cursor = "0:1,2"
+	}
+
+	return nil
+}
+
+func (m *grpcMock) CreateWebhookSubscription(ctx context.Context, req *pb.CreateWebhookSubscriptionRequest) (*pb.WebhookSubscription, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return &pb.WebhookSubscription{
+		Id:         "WHK123",
+		Url:        req.Url,
+		EventTypes: req.EventTypes,
+		Secret:     "generated secret",
+		CreatedBy:  "admin",
+	}, nil
+}
+
+func (m *grpcMock) ListWebhookSubscriptions(ctx context.Context, req *pb.ListWebhookSubscriptionsRequest) (*pb.ListWebhookSubscriptionsResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return &pb.ListWebhookSubscriptionsResponse{
+		Subscriptions: []*pb.WebhookSubscription{
+			{Id: "WHK123", Url: "https://example.com/hook", EventTypes: []string{"user.created"}},
+		},
+	}, nil
+}
+
+func (m *grpcMock) DeleteWebhookSubscription(ctx context.Context, req *pb.DeleteWebhookSubscriptionRequest) (*pb.DeleteWebhookSubscriptionResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return &pb.DeleteWebhookSubscriptionResponse{
+		Message: "webhook subscription deleted successfully",
+	}, nil
+}
+
+func (m *grpcMock) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return &pb.ListWebhookDeliveriesResponse{
+		Deliveries: []*pb.WebhookDelivery{
+			{Id: 1, SubscriptionId: req.SubscriptionId, EventId: 7, EventType: "user.created", Status: "dead_letter", Attempts: 8, ResponseStatus: 500},
+		},
+		NextPageToken: "1",
+	}, nil
+}
@@ -0,0 +1,88 @@
+package userrepository
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// ContextClientInterceptor forwards the actor, request ID and client IP carried by the context to the user service as gRPC metadata
+func ContextClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+// ContextStreamClientInterceptor is the streaming counterpart of ContextClientInterceptor
+func ContextStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	pairs := []string{sharedLib.ActorMetadataKey, sharedLib.ActorFromContext(ctx)}
+
+	if requestID := sharedLib.RequestIDFromContext(ctx); requestID != "" {
+		pairs = append(pairs, sharedLib.RequestIDMetadataKey, requestID)
+	}
+
+	if clientIP := sharedLib.ClientIPFromContext(ctx); clientIP != "" {
+		pairs = append(pairs, sharedLib.ClientIPMetadataKey, clientIP)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// tokenCredentials authenticates the calls to the user service with a bearer token
+type tokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+// NewTokenCredentials returns the credentials sending token on every call
+func NewTokenCredentials(token string, requireTLS bool) credentials.PerRPCCredentials {
+	return tokenCredentials{token: token, requireTLS: requireTLS}
+}
+
+// GetRequestMetadata returns the authorization metadata
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{sharedLib.AuthorizationMetadataKey: sharedLib.BearerPrefix + c.token}, nil
+}
+
+// RequireTransportSecurity reports whether the token may only be sent over TLS
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// MetricsClientInterceptor records the calls to the user service in m, labelled by method and gRPC code
+func MetricsClientInterceptor(m sharedLib.RequestMetrics) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		begin := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observeCall(m, method, err, begin)
+
+		return err
+	}
+}
+
+// MetricsStreamClientInterceptor is the streaming counterpart of MetricsClientInterceptor, only the opening of the streams is recorded
+func MetricsStreamClientInterceptor(m sharedLib.RequestMetrics) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		begin := time.Now()
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		observeCall(m, method, err, begin)
+
+		return stream, err
+	}
+}
+
+func observeCall(m sharedLib.RequestMetrics, method string, err error, begin time.Time) {
+	code := status.Code(err)
+	m.Observe(method, code.String(), code != codes.OK, time.Since(begin))
+}
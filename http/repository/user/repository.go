@@ -2,27 +2,49 @@ package userrepository
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"encoding/json"
+	"io"
 	"strconv"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
 
 	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 
+	"google.golang.org/genproto/googleapis/type/date"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-var (
-	ErrBadAge = errors.New("age is not a number")
-)
+// healthCheckTimeout is how long the user service has to answer a health check
+const healthCheckTimeout = time.Second
 
 type userRepository struct {
-	client pb.UserServiceClient
-	logger log.Logger
+	client   pb.UserServiceClient
+	clientV2 pbv2.UserServiceClient
+	health   healthpb.HealthClient
+	logger   log.Logger
+}
+
+// userReply is implemented by every gRPC reply carrying a user
+type userReply interface {
+	GetId() string
+	GetName() string
+	GetDateOfBirth() *date.Date
+	GetDateOfBirthEstimated() bool
+	GetAgeYears() int32
+	GetAdditionalInformation() string
+	GetParent() []string
+	GetCreatedAt() *timestamppb.Timestamp
+	GetUpdatedAt() *timestamppb.Timestamp
+	GetCreatedBy() string
+	GetUpdatedBy() string
 }
 
 // UserRepository is the user repository
@@ -31,20 +53,32 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error)
 	GetUser(ctx context.Context, userID string) (sharedLib.User, error)
 	UpdateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error)
+	PatchUser(ctx context.Context, user sharedLib.User, paths []string) (sharedLib.User, error)
 	DeleteUser(ctx context.Context, userID string) (string, error)
+	RestoreUser(ctx context.Context, userID string) (sharedLib.User, error)
+	ListUsers(ctx context.Context, filter sharedLib.UserFilter) (sharedLib.UserPage, error)
+	ListAuditEvents(ctx context.Context, filter sharedLib.AuditEventFilter) (sharedLib.AuditEventPage, error)
+	WatchUsers(ctx context.Context, userID string, cursor string, send func(sharedLib.Event) error) error
+	CreateWebhookSubscription(ctx context.Context, subscription sharedLib.WebhookSubscription) (sharedLib.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]sharedLib.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error)
+	ListWebhookDeliveries(ctx context.Context, filter sharedLib.WebhookDeliveryFilter) (sharedLib.WebhookDeliveryPage, error)
+	CheckHealth(ctx context.Context) (string, error)
 }
 
 // NewUserRepository is the UserRepository constructor
 func NewUserRepository(conn *grpc.ClientConn, logger log.Logger) UserRepository {
 	return &userRepository{
-		client: pb.NewUserServiceClient(conn),
-		logger: log.With(logger, "error", "grpc"),
+		client:   pb.NewUserServiceClient(conn),
+		clientV2: pbv2.NewUserServiceClient(conn),
+		health:   healthpb.NewHealthClient(conn),
+		logger:   log.With(logger, "error", "grpc"),
 	}
 }
 
 // Authenticate is the userRepository authentication method
 func (r *userRepository) Authenticate(ctx context.Context, username string, pwdHash string) (string, error) {
-	logger := log.With(r.logger, "method", "Authenticate")
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "Authenticate")
 
 	request := &pb.UserAuthRequest{
 		Username: username,
@@ -54,7 +88,7 @@ func (r *userRepository) Authenticate(ctx context.Context, username string, pwdH
 	reply, err := r.client.Authenticate(ctx, request)
 	if err != nil {
 		level.Error(logger).Log("err", err)
-		return "", err
+		return "", sharedLib.FromGRPCError(err)
 	}
 
 	return reply.Message, nil
@@ -62,12 +96,13 @@ func (r *userRepository) Authenticate(ctx context.Context, username string, pwdH
 
 // CreateUser is the userRepository user creation method
 func (r *userRepository) CreateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error) {
-	logger := log.With(r.logger, "method", "CreateUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "CreateUser")
 
 	request := &pb.CreateUserRequest{
 		Name:                  user.Name,
 		Password:              user.Password,
 		Age:                   strconv.Itoa(user.Age),
+		DateOfBirth:           user.DateOfBirth.Proto(),
 		AdditionalInformation: user.AdditionalInformation,
 		Parent:                user.Parents,
 	}
@@ -75,29 +110,15 @@ func (r *userRepository) CreateUser(ctx context.Context, user sharedLib.User) (s
 	reply, err := r.client.CreateUser(ctx, request)
 	if err != nil {
 		level.Error(logger).Log("err", err)
-		return sharedLib.User{}, err
+		return sharedLib.User{}, sharedLib.FromGRPCError(err)
 	}
 
-	fmt.Println(reply.Age)
-
-	intAge, err := strconv.Atoi(reply.Age)
-	if err != nil {
-		level.Error(logger).Log("err", ErrBadAge)
-		return sharedLib.User{}, ErrBadAge
-	}
-
-	return sharedLib.User{
-		ID:                    reply.Id,
-		Name:                  reply.Name,
-		Age:                   intAge,
-		AdditionalInformation: reply.AdditionalInformation,
-		Parents:               reply.Parent,
-	}, nil
+	return decodeUser(reply), nil
 }
 
 // GetUser is the userRepository method to retrieve an user by id
 func (r *userRepository) GetUser(ctx context.Context, userID string) (sharedLib.User, error) {
-	logger := log.With(r.logger, "method", "GetUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "GetUser")
 
 	request := &pb.GetUserRequest{
 		Id: userID,
@@ -106,32 +127,21 @@ func (r *userRepository) GetUser(ctx context.Context, userID string) (sharedLib.
 	reply, err := r.client.GetUser(ctx, request)
 	if err != nil {
 		level.Error(logger).Log("err", err)
-		return sharedLib.User{}, err
+		return sharedLib.User{}, sharedLib.FromGRPCError(err)
 	}
 
-	intAge, err := strconv.Atoi(reply.Age)
-	if err != nil {
-		level.Error(logger).Log("err", ErrBadAge)
-		return sharedLib.User{}, ErrBadAge
-	}
-
-	return sharedLib.User{
-		ID:                    reply.Id,
-		Name:                  reply.Name,
-		Age:                   intAge,
-		AdditionalInformation: reply.AdditionalInformation,
-		Parents:               reply.Parent,
-	}, nil
+	return decodeUser(reply), nil
 }
 
 // UpdateUser is the userRepository method to update an user
 func (r *userRepository) UpdateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error) {
-	logger := log.With(r.logger, "method", "UpdateUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "UpdateUser")
 
 	request := &pb.UpdateUserRequest{
 		Id:                    user.ID,
 		Name:                  user.Name,
 		Age:                   strconv.Itoa(user.Age),
+		DateOfBirth:           user.DateOfBirth.Proto(),
 		AdditionalInformation: user.AdditionalInformation,
 		Parent:                user.Parents,
 	}
@@ -139,27 +149,39 @@ func (r *userRepository) UpdateUser(ctx context.Context, user sharedLib.User) (s
 	reply, err := r.client.UpdateUser(ctx, request)
 	if err != nil {
 		level.Error(logger).Log("err", err)
-		return sharedLib.User{}, err
+		return sharedLib.User{}, sharedLib.FromGRPCError(err)
+	}
+
+	return decodeUser(reply), nil
+}
+
+// PatchUser is the userRepository method to update only the given fields of an user, it uses the v2 API
+func (r *userRepository) PatchUser(ctx context.Context, user sharedLib.User, paths []string) (sharedLib.User, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "PatchUser")
+
+	request := &pbv2.UpdateUserRequest{
+		User: &pbv2.User{
+			Id:                    user.ID,
+			Name:                  user.Name,
+			DateOfBirth:           user.DateOfBirth.Proto(),
+			AdditionalInformation: user.AdditionalInformation,
+			Parents:               user.Parents,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
 	}
 
-	intAge, err := strconv.Atoi(reply.Age)
+	reply, err := r.clientV2.UpdateUser(ctx, request)
 	if err != nil {
-		level.Error(logger).Log("err", ErrBadAge)
-		return sharedLib.User{}, ErrBadAge
+		level.Error(logger).Log("err", err)
+		return sharedLib.User{}, sharedLib.FromGRPCError(err)
 	}
 
-	return sharedLib.User{
-		ID:                    reply.Id,
-		Name:                  reply.Name,
-		Age:                   intAge,
-		AdditionalInformation: reply.AdditionalInformation,
-		Parents:               reply.Parent,
-	}, nil
+	return decodeV2User(reply), nil
 }
 
 // DeleteUser is the userRepository method to delete an user by id
 func (r *userRepository) DeleteUser(ctx context.Context, userID string) (string, error) {
-	logger := log.With(r.logger, "method", "DeleteUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "DeleteUser")
 
 	request := &pb.DeleteUserRequest{
 		Id: userID,
@@ -168,8 +190,338 @@ func (r *userRepository) DeleteUser(ctx context.Context, userID string) (string,
 	reply, err := r.client.DeleteUser(ctx, request)
 	if err != nil {
 		level.Error(logger).Log("err", err)
-		return "", err
+		return "", sharedLib.FromGRPCError(err)
 	}
 
 	return reply.Message, nil
 }
+
+// RestoreUser is the userRepository method to restore a deleted user by id
+func (r *userRepository) RestoreUser(ctx context.Context, userID string) (sharedLib.User, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "RestoreUser")
+
+	request := &pb.RestoreUserRequest{
+		Id: userID,
+	}
+
+	reply, err := r.client.RestoreUser(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.User{}, sharedLib.FromGRPCError(err)
+	}
+
+	return decodeUser(reply), nil
+}
+
+// ListUsers is the userRepository method to list users matching a filter
+func (r *userRepository) ListUsers(ctx context.Context, filter sharedLib.UserFilter) (sharedLib.UserPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "ListUsers")
+
+	request := &pb.ListUsersRequest{
+		CreatedAfter:  encodeTimestamp(filter.CreatedAfter),
+		CreatedBefore: encodeTimestamp(filter.CreatedBefore),
+		UpdatedAfter:  encodeTimestamp(filter.UpdatedAfter),
+		UpdatedBefore: encodeTimestamp(filter.UpdatedBefore),
+		CreatedBy:     filter.CreatedBy,
+		UpdatedBy:     filter.UpdatedBy,
+		Ids:           filter.IDs,
+		PageSize:      int32(filter.PageSize),
+		PageToken:     filter.PageToken,
+	}
+
+	reply, err := r.client.ListUsers(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.UserPage{}, sharedLib.FromGRPCError(err)
+	}
+
+	page := sharedLib.UserPage{
+		Users:         make([]sharedLib.User, 0, len(reply.Users)),
+		NextPageToken: reply.NextPageToken,
+	}
+
+	for _, userReply := range reply.Users {
+		page.Users = append(page.Users, decodeUser(userReply))
+	}
+
+	return page, nil
+}
+
+// ListAuditEvents is the userRepository method to list the audit events of a user
+func (r *userRepository) ListAuditEvents(ctx context.Context, filter sharedLib.AuditEventFilter) (sharedLib.AuditEventPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "ListAuditEvents")
+
+	request := &pb.ListAuditEventsRequest{
+		Target:    filter.Target,
+		Targets:   filter.Targets,
+		PageSize:  int32(filter.PageSize),
+		PageToken: filter.PageToken,
+	}
+
+	reply, err := r.client.ListAuditEvents(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.AuditEventPage{}, sharedLib.FromGRPCError(err)
+	}
+
+	page := sharedLib.AuditEventPage{
+		Events:         make([]sharedLib.AuditEvent, 0, len(reply.Events)),
+		NextPageToken:  reply.NextPageToken,
+		NextPageTokens: reply.NextPageTokens,
+	}
+
+	for _, event := range reply.Events {
+		var changes map[string]sharedLib.AuditChange
+		if len(event.Changes) > 0 {
+			changes = make(map[string]sharedLib.AuditChange, len(event.Changes))
+		}
+
+		for field, change := range event.Changes {
+			changes[field] = sharedLib.AuditChange{
+				Before: change.GetBefore(),
+				After:  change.GetAfter(),
+			}
+		}
+
+		page.Events = append(page.Events, sharedLib.AuditEvent{
+			ID:         event.Id,
+			OccurredAt: decodeTimestamp(event.OccurredAt),
+			Actor:      event.Actor,
+			Action:     event.Action,
+			Target:     event.Target,
+			Changes:    changes,
+			RequestID:  event.RequestId,
+			ClientIP:   event.ClientIp,
+			Outcome:    event.Outcome,
+			Reason:     event.Reason,
+		})
+	}
+
+	return page, nil
+}
+
+// WatchUsers is the userRepository method to stream the user changes after a cursor, send is called for every event
+func (r *userRepository) WatchUsers(ctx context.Context, userID string, cursor string, send func(sharedLib.Event) error) error {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "WatchUsers")
+
+	request := &pb.WatchUsersRequest{
+		UserId: userID,
+		Cursor: cursor,
+	}
+
+	stream, err := r.client.WatchUsers(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.FromGRPCError(err)
+	}
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			return sharedLib.FromGRPCError(err)
+		}
+
+		event, err := decodeEvent(reply)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			return err
+		}
+
+		err = send(event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CreateWebhookSubscription is the userRepository method to subscribe a URL to user events
+func (r *userRepository) CreateWebhookSubscription(ctx context.Context, subscription sharedLib.WebhookSubscription) (sharedLib.WebhookSubscription, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "CreateWebhookSubscription")
+
+	request := &pb.CreateWebhookSubscriptionRequest{
+		Url:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Secret:     subscription.Secret,
+	}
+
+	reply, err := r.client.CreateWebhookSubscription(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.WebhookSubscription{}, sharedLib.FromGRPCError(err)
+	}
+
+	return decodeWebhookSubscription(reply), nil
+}
+
+// ListWebhookSubscriptions is the userRepository method to list the webhook subscriptions
+func (r *userRepository) ListWebhookSubscriptions(ctx context.Context) ([]sharedLib.WebhookSubscription, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "ListWebhookSubscriptions")
+
+	reply, err := r.client.ListWebhookSubscriptions(ctx, &pb.ListWebhookSubscriptionsRequest{})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return nil, sharedLib.FromGRPCError(err)
+	}
+
+	subscriptions := make([]sharedLib.WebhookSubscription, 0, len(reply.Subscriptions))
+	for _, subscription := range reply.Subscriptions {
+		subscriptions = append(subscriptions, decodeWebhookSubscription(subscription))
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription is the userRepository method to delete a webhook subscription by id
+func (r *userRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "DeleteWebhookSubscription")
+
+	request := &pb.DeleteWebhookSubscriptionRequest{
+		Id: subscriptionID,
+	}
+
+	reply, err := r.client.DeleteWebhookSubscription(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return "", sharedLib.FromGRPCError(err)
+	}
+
+	return reply.Message, nil
+}
+
+// ListWebhookDeliveries is the userRepository method to list the deliveries of a webhook subscription
+func (r *userRepository) ListWebhookDeliveries(ctx context.Context, filter sharedLib.WebhookDeliveryFilter) (sharedLib.WebhookDeliveryPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "ListWebhookDeliveries")
+
+	request := &pb.ListWebhookDeliveriesRequest{
+		SubscriptionId: filter.SubscriptionID,
+		PageSize:       int32(filter.PageSize),
+		PageToken:      filter.PageToken,
+	}
+
+	reply, err := r.client.ListWebhookDeliveries(ctx, request)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return sharedLib.WebhookDeliveryPage{}, sharedLib.FromGRPCError(err)
+	}
+
+	page := sharedLib.WebhookDeliveryPage{
+		Deliveries:    make([]sharedLib.WebhookDelivery, 0, len(reply.Deliveries)),
+		NextPageToken: reply.NextPageToken,
+	}
+
+	for _, delivery := range reply.Deliveries {
+		page.Deliveries = append(page.Deliveries, sharedLib.WebhookDelivery{
+			ID:             delivery.Id,
+			SubscriptionID: delivery.SubscriptionId,
+			EventID:        delivery.EventId,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       int(delivery.Attempts),
+			NextAttemptAt:  decodeTimestamp(delivery.NextAttemptAt),
+			LastAttemptAt:  decodeTimestamp(delivery.LastAttemptAt),
+			ResponseStatus: int(delivery.ResponseStatus),
+			LastError:      delivery.LastError,
+			CreatedAt:      decodeTimestamp(delivery.CreatedAt),
+		})
+	}
+
+	return page, nil
+}
+
+func decodeWebhookSubscription(reply *pb.WebhookSubscription) sharedLib.WebhookSubscription {
+	return sharedLib.WebhookSubscription{
+		ID:         reply.Id,
+		URL:        reply.Url,
+		EventTypes: reply.EventTypes,
+		Secret:     reply.Secret,
+		CreatedAt:  decodeTimestamp(reply.CreatedAt),
+		CreatedBy:  reply.CreatedBy,
+	}
+}
+
+func decodeEvent(reply *pb.UserEvent) (sharedLib.Event, error) {
+	if _, err := sharedLib.ParseWatchCursor(reply.Cursor); err != nil {
+		return sharedLib.Event{}, sharedLib.ErrBadReply.Wrap(err)
+	}
+
+	return sharedLib.Event{
+		ID:          reply.Id,
+		Cursor:      reply.Cursor,
+		Type:        reply.Type,
+		AggregateID: reply.UserId,
+		OccurredAt:  decodeTimestamp(reply.OccurredAt),
+		Actor:       reply.Actor,
+		RequestID:   reply.RequestId,
+		Payload:     json.RawMessage(reply.Payload),
+	}, nil
+}
+
+// CheckHealth is the userRepository method to obtain the gRPC serving status of the user service
+func (r *userRepository) CheckHealth(ctx context.Context) (string, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, r.logger), "method", "CheckHealth")
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	reply, err := r.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return "", sharedLib.FromGRPCError(err)
+	}
+
+	return reply.Status.String(), nil
+}
+
+// decodeUser reads the typed age and date of birth of a reply, the deprecated string age is never parsed
+func decodeUser(reply userReply) sharedLib.User {
+	return sharedLib.User{
+		ID:                    reply.GetId(),
+		Name:                  reply.GetName(),
+		Age:                   int(reply.GetAgeYears()),
+		DateOfBirth:           sharedLib.DateFromProto(reply.GetDateOfBirth()),
+		DateOfBirthEstimated:  reply.GetDateOfBirthEstimated(),
+		AdditionalInformation: reply.GetAdditionalInformation(),
+		Parents:               reply.GetParent(),
+		CreatedAt:             decodeTimestamp(reply.GetCreatedAt()),
+		UpdatedAt:             decodeTimestamp(reply.GetUpdatedAt()),
+		CreatedBy:             reply.GetCreatedBy(),
+		UpdatedBy:             reply.GetUpdatedBy(),
+	}
+}
+
+func decodeV2User(user *pbv2.User) sharedLib.User {
+	return sharedLib.User{
+		ID:                    user.GetId(),
+		Name:                  user.GetName(),
+		Age:                   int(user.GetAge()),
+		DateOfBirth:           sharedLib.DateFromProto(user.GetDateOfBirth()),
+		DateOfBirthEstimated:  user.GetDateOfBirthEstimated(),
+		AdditionalInformation: user.GetAdditionalInformation(),
+		Parents:               user.GetParents(),
+		CreatedAt:             decodeTimestamp(user.GetCreateTime()),
+		UpdatedAt:             decodeTimestamp(user.GetUpdateTime()),
+		CreatedBy:             user.GetCreatedBy(),
+		UpdatedBy:             user.GetUpdatedBy(),
+	}
+}
+
+func encodeTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func decodeTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
@@ -2,10 +2,21 @@ package userrepository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 func TestAuthenticate(t *testing.T) {
@@ -26,6 +37,7 @@ func TestAuthenticate(t *testing.T) {
 	authResponse, err = repo.Authenticate(context.Background(), "test", "testPassWord")
 	c.Empty(authResponse)
 	c.Error(err, "rpc error: code = Unknown desc = forced failure")
+	c.Equal(shared.KindInternal, shared.KindOf(err))
 }
 
 func TestCreateUser(t *testing.T) {
@@ -38,6 +50,15 @@ func TestCreateUser(t *testing.T) {
 	c.NoError(err)
 	c.Equal("USR123", createResponse.ID)
 	c.Equal("test", createResponse.Name)
+	c.Equal(99, createResponse.Age)
+	c.Nil(createResponse.DateOfBirth)
+	c.True(createResponse.DateOfBirthEstimated)
+
+	dateOfBirth := &shared.Date{Year: 1990, Month: time.June, Day: 5}
+	createResponse, err = repo.CreateUser(context.Background(), shared.User{Name: "test", DateOfBirth: dateOfBirth})
+	c.NoError(err)
+	c.Equal(dateOfBirth, createResponse.DateOfBirth)
+	c.False(createResponse.DateOfBirthEstimated)
 
 	forceBadAge = true
 	defer func() {
@@ -45,8 +66,8 @@ func TestCreateUser(t *testing.T) {
 	}()
 
 	createResponse, err = repo.CreateUser(context.Background(), shared.User{Name: "test"})
-	c.Empty(createResponse)
-	c.Error(err, "rpc error: code = Unknown desc = age is not a number")
+	c.NoError(err)
+	c.Equal(99, createResponse.Age)
 
 	forceMockFail = true
 	defer func() {
@@ -69,14 +90,18 @@ func TestGetUser(t *testing.T) {
 	c.Equal("USR123", getResponse.ID)
 	c.Equal("test", getResponse.Name)
 
+	_, err = repo.GetUser(context.Background(), "USR404")
+	c.True(errors.Is(err, shared.ErrUserNotFound))
+	c.Equal(shared.KindNotFound, shared.KindOf(err))
+
 	forceBadAge = true
 	defer func() {
 		forceBadAge = false
 	}()
 
 	getResponse, err = repo.GetUser(context.Background(), "USR123")
-	c.Empty(getResponse)
-	c.Error(err, "rpc error: code = Unknown desc = age is not a number")
+	c.NoError(err)
+	c.Equal(99, getResponse.Age)
 
 	forceMockFail = true
 	defer func() {
@@ -105,8 +130,8 @@ func TestUpdateUser(t *testing.T) {
 	}()
 
 	updateResponse, err = repo.UpdateUser(context.Background(), shared.User{Name: "test"})
-	c.Empty(updateResponse)
-	c.Error(err, "rpc error: code = Unknown desc = age is not a number")
+	c.NoError(err)
+	c.Equal(99, updateResponse.Age)
 
 	forceMockFail = true
 	defer func() {
@@ -118,6 +143,29 @@ func TestUpdateUser(t *testing.T) {
 	c.Error(err, "rpc error: code = Unknown desc = forced failure")
 }
 
+func TestPatchUser(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	patchResponse, err := repo.PatchUser(context.Background(), shared.User{ID: "USR123", Name: "ignored", AdditionalInformation: "a lot"}, []string{shared.UserPathAdditionalInformation})
+	c.NoError(err)
+	c.Equal("USR123", patchResponse.ID)
+	c.Equal("test", patchResponse.Name)
+	c.Equal("a lot", patchResponse.AdditionalInformation)
+	c.Equal(99, patchResponse.Age)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	patchResponse, err = repo.PatchUser(context.Background(), shared.User{ID: "USR123"}, []string{shared.UserPathName})
+	c.Empty(patchResponse)
+	c.Error(err)
+}
+
 func TestDeleteUser(t *testing.T) {
 	c := require.New(t)
 
@@ -137,3 +185,301 @@ func TestDeleteUser(t *testing.T) {
 	c.Empty(deleteResponse)
 	c.Error(err, "rpc error: code = Unknown desc = forced failure")
 }
+
+func TestRestoreUser(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	restoreResponse, err := repo.RestoreUser(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal("USR123", restoreResponse.ID)
+	c.Equal("test", restoreResponse.Name)
+
+	forceBadAge = true
+	defer func() {
+		forceBadAge = false
+	}()
+
+	restoreResponse, err = repo.RestoreUser(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal(99, restoreResponse.Age)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	restoreResponse, err = repo.RestoreUser(context.Background(), "USR123")
+	c.Empty(restoreResponse)
+	c.Error(err, "rpc error: code = Unknown desc = forced failure")
+}
+
+func TestListUsers(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	createdAfter := time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	listResponse, err := repo.ListUsers(context.Background(), shared.UserFilter{CreatedAfter: createdAfter, CreatedBy: "admin"})
+	c.NoError(err)
+	c.Equal("USR123", listResponse.NextPageToken)
+	c.Equal("USR123", listResponse.Users[0].ID)
+	c.Equal("admin", listResponse.Users[0].CreatedBy)
+	c.Equal(createdAfter, listResponse.Users[0].CreatedAt)
+	c.True(listResponse.Users[0].UpdatedAt.IsZero())
+
+	forceBadAge = true
+	defer func() {
+		forceBadAge = false
+	}()
+
+	listResponse, err = repo.ListUsers(context.Background(), shared.UserFilter{})
+	c.NoError(err)
+	c.Equal(99, listResponse.Users[0].Age)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	listResponse, err = repo.ListUsers(context.Background(), shared.UserFilter{})
+	c.Empty(listResponse)
+	c.Error(err, "rpc error: code = Unknown desc = forced failure")
+}
+
+func TestContextClientInterceptor(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	ctx := shared.ContextWithActor(context.Background(), "admin")
+	ctx = shared.ContextWithRequestID(ctx, "REQ123")
+	ctx = shared.ContextWithClientIP(ctx, "10.0.0.1")
+
+	_, err = repo.DeleteUser(ctx, "USR123")
+	c.NoError(err)
+	c.Equal([]string{"admin"}, lastMetadata.Get(shared.ActorMetadataKey))
+	c.Equal([]string{"REQ123"}, lastMetadata.Get(shared.RequestIDMetadataKey))
+	c.Equal([]string{"10.0.0.1"}, lastMetadata.Get(shared.ClientIPMetadataKey))
+
+	_, err = repo.DeleteUser(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal([]string{shared.AnonymousActor}, lastMetadata.Get(shared.ActorMetadataKey))
+	c.Empty(lastMetadata.Get(shared.RequestIDMetadataKey))
+	c.Empty(lastMetadata.Get(shared.ClientIPMetadataKey))
+}
+
+func TestTokenCredentials(t *testing.T) {
+	c := require.New(t)
+
+	credentials := NewTokenCredentials("secret", true)
+	c.True(credentials.RequireTransportSecurity())
+
+	md, err := credentials.GetRequestMetadata(context.Background())
+	c.NoError(err)
+	c.Equal(map[string]string{shared.AuthorizationMetadataKey: "Bearer secret"}, md)
+
+	c.False(NewTokenCredentials("secret", false).RequireTransportSecurity())
+}
+
+func TestMetricsClientInterceptor(t *testing.T) {
+	c := require.New(t)
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"method", "code"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors_total"}, []string{"method", "code"})
+	interceptor := MetricsClientInterceptor(shared.RequestMetrics{
+		Requests: kitprometheus.NewCounter(requests),
+		Errors:   kitprometheus.NewCounter(failures),
+		Duration: discard.NewHistogram(),
+	})
+
+	var fail error
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return fail
+	}
+
+	c.NoError(interceptor(context.Background(), "/user.v2.UserService/GetUser", nil, nil, nil, invoker))
+
+	fail = status.Error(codes.NotFound, "user not found")
+	c.Equal(fail, interceptor(context.Background(), "/user.v2.UserService/GetUser", nil, nil, nil, invoker))
+
+	c.Equal(float64(1), testutil.ToFloat64(requests.WithLabelValues("/user.v2.UserService/GetUser", "OK")))
+	c.Equal(float64(1), testutil.ToFloat64(requests.WithLabelValues("/user.v2.UserService/GetUser", "NotFound")))
+	c.Equal(float64(0), testutil.ToFloat64(failures.WithLabelValues("/user.v2.UserService/GetUser", "OK")))
+	c.Equal(float64(1), testutil.ToFloat64(failures.WithLabelValues("/user.v2.UserService/GetUser", "NotFound")))
+}
+
+func TestListAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	page, err := repo.ListAuditEvents(context.Background(), shared.AuditEventFilter{Target: "USR123"})
+	c.NoError(err)
+	c.Equal(shared.AuditEventPage{
+		Events: []shared.AuditEvent{
+			{
+				ID:      1,
+				Actor:   "admin",
+				Action:  shared.AuditActionDeleteUser,
+				Target:  "USR123",
+				Changes: map[string]shared.AuditChange{"name": {Before: "test"}},
+				Outcome: shared.AuditOutcomeSuccess,
+			},
+		},
+		NextPageToken: "1",
+	}, page)
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	_, err = repo.ListAuditEvents(context.Background(), shared.AuditEventFilter{Target: "USR123"})
+	c.Error(err)
+}
+
+func TestWatchUsers(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	ctx := shared.ContextWithActor(context.Background(), "admin")
+	ctx = shared.ContextWithRequestID(ctx, "REQ123")
+
+	var received []shared.Event
+	err = repo.WatchUsers(ctx, "USR123", "", func(event shared.Event) error {
+		received = append(received, event)
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]shared.Event{
+		{ID: 1, Type: shared.EventUserCreated, AggregateID: "USR123", Actor: "admin", Payload: json.RawMessage(`{"id":"USR123"}`), Cursor: "1"},
+		{ID: 2, Type: shared.EventUserUpdated, AggregateID: "USR123", Actor: "admin", Payload: json.RawMessage(`{"id":"USR123"}`), Cursor: "0:1,2"},
+	}, received)
+	c.Equal([]string{"admin"}, lastMetadata.Get(shared.ActorMetadataKey))
+	c.Equal([]string{"REQ123"}, lastMetadata.Get(shared.RequestIDMetadataKey))
+
+	err = repo.WatchUsers(context.Background(), "", "", func(shared.Event) error {
+		return errForcedFailure
+	})
+	c.Equal(errForcedFailure, err)
+
+	forceBadCursor = true
+	err = repo.WatchUsers(context.Background(), "", "", func(shared.Event) error { return nil })
+	c.True(errors.Is(err, shared.ErrBadReply))
+	forceBadCursor = false
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	err = repo.WatchUsers(context.Background(), "", "", func(shared.Event) error { return nil })
+	c.Error(err)
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	subscription, err := repo.CreateWebhookSubscription(context.Background(), shared.WebhookSubscription{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{shared.EventUserCreated},
+	})
+	c.NoError(err)
+	c.Equal(shared.WebhookSubscription{
+		ID:         "WHK123",
+		URL:        "https://example.com/hook",
+		EventTypes: []string{shared.EventUserCreated},
+		Secret:     "generated secret",
+		CreatedBy:  "admin",
+	}, subscription)
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	_, err = repo.CreateWebhookSubscription(context.Background(), shared.WebhookSubscription{})
+	c.Error(err)
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	subscriptions, err := repo.ListWebhookSubscriptions(context.Background())
+	c.NoError(err)
+	c.Equal([]shared.WebhookSubscription{
+		{ID: "WHK123", URL: "https://example.com/hook", EventTypes: []string{shared.EventUserCreated}},
+	}, subscriptions)
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	_, err = repo.ListWebhookSubscriptions(context.Background())
+	c.Error(err)
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	message, err := repo.DeleteWebhookSubscription(context.Background(), "WHK123")
+	c.NoError(err)
+	c.Equal("webhook subscription deleted successfully", message)
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	_, err = repo.DeleteWebhookSubscription(context.Background(), "WHK123")
+	c.Error(err)
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	page, err := repo.ListWebhookDeliveries(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.NoError(err)
+	c.Equal(shared.WebhookDeliveryPage{
+		Deliveries: []shared.WebhookDelivery{
+			{ID: 1, SubscriptionID: "WHK123", EventID: 7, EventType: shared.EventUserCreated, Status: shared.WebhookDeliveryDeadLetter, Attempts: 8, ResponseStatus: 500},
+		},
+		NextPageToken: "1",
+	}, page)
+
+	forceMockFail = true
+	defer func() { forceMockFail = false }()
+
+	_, err = repo.ListWebhookDeliveries(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.Error(err)
+}
+
+func TestCheckHealth(t *testing.T) {
+	c := require.New(t)
+
+	repo, err := InitGRPCMock()
+	c.Nil(err)
+
+	status, err := repo.CheckHealth(context.Background())
+	c.NoError(err)
+	c.Equal("SERVING", status)
+
+	healthMock.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	defer healthMock.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	status, err = repo.CheckHealth(context.Background())
+	c.NoError(err)
+	c.Equal("NOT_SERVING", status)
+}
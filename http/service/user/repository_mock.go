@@ -58,6 +58,17 @@ func (m *repoMock) UpdateUser(ctx context.Context, user shared.User) (shared.Use
 	}, nil
 }
 
+func (m *repoMock) PatchUser(ctx context.Context, user shared.User, paths []string) (shared.User, error) {
+	if forceMockFail {
+		return shared.User{}, errForcedFailure
+	}
+
+	return shared.User{
+		ID:   user.ID,
+		Name: "test",
+	}, nil
+}
+
 func (m *repoMock) DeleteUser(ctx context.Context, userID string) (string, error) {
 	if forceMockFail {
 		return "", errForcedFailure
@@ -65,3 +76,86 @@ func (m *repoMock) DeleteUser(ctx context.Context, userID string) (string, error
 
 	return "user deleted successfully", nil
 }
+
+func (m *repoMock) RestoreUser(ctx context.Context, userID string) (shared.User, error) {
+	if forceMockFail {
+		return shared.User{}, errForcedFailure
+	}
+
+	return shared.User{
+		ID:   userID,
+		Name: "test",
+	}, nil
+}
+
+func (m *repoMock) ListUsers(ctx context.Context, filter shared.UserFilter) (shared.UserPage, error) {
+	if forceMockFail {
+		return shared.UserPage{}, errForcedFailure
+	}
+
+	return shared.UserPage{
+		Users: []shared.User{{ID: "USR123", Name: "test", CreatedBy: filter.CreatedBy}},
+	}, nil
+}
+
+func (m *repoMock) ListAuditEvents(ctx context.Context, filter shared.AuditEventFilter) (shared.AuditEventPage, error) {
+	if forceMockFail {
+		return shared.AuditEventPage{}, errForcedFailure
+	}
+
+	return shared.AuditEventPage{
+		Events: []shared.AuditEvent{{ID: 1, Target: filter.Target, Action: shared.AuditActionDeleteUser}},
+	}, nil
+}
+
+func (m *repoMock) WatchUsers(ctx context.Context, userID string, cursor string, send func(shared.Event) error) error {
+	if forceMockFail {
+		return errForcedFailure
+	}
+
+	return send(shared.Event{ID: 1, Type: shared.EventUserUpdated, AggregateID: userID})
+}
+
+func (m *repoMock) CreateWebhookSubscription(ctx context.Context, subscription shared.WebhookSubscription) (shared.WebhookSubscription, error) {
+	if forceMockFail {
+		return shared.WebhookSubscription{}, errForcedFailure
+	}
+
+	subscription.ID = "WHK123"
+
+	return subscription, nil
+}
+
+func (m *repoMock) ListWebhookSubscriptions(ctx context.Context) ([]shared.WebhookSubscription, error) {
+	if forceMockFail {
+		return nil, errForcedFailure
+	}
+
+	return []shared.WebhookSubscription{{ID: "WHK123"}}, nil
+}
+
+func (m *repoMock) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error) {
+	if forceMockFail {
+		return "", errForcedFailure
+	}
+
+	return "webhook subscription deleted successfully", nil
+}
+
+func (m *repoMock) ListWebhookDeliveries(ctx context.Context, filter shared.WebhookDeliveryFilter) (shared.WebhookDeliveryPage, error) {
+	if forceMockFail {
+		return shared.WebhookDeliveryPage{}, errForcedFailure
+	}
+
+	return shared.WebhookDeliveryPage{
+		Deliveries: []shared.WebhookDelivery{{ID: 1, SubscriptionID: filter.SubscriptionID}},
+	}, nil
+}
+
+func (m *repoMock) CheckHealth(ctx context.Context) (string, error) {
+	if forceMockFail {
+		return "", errForcedFailure
+	}
+
+	return "SERVING", nil
+}
@@ -16,7 +16,17 @@ type Service interface {
 	CreateUser(ctx context.Context, user shared.User) (shared.User, error)
 	GetUser(ctx context.Context, userID string) (shared.User, error)
 	UpdateUser(ctx context.Context, user shared.User) (shared.User, error)
+	PatchUser(ctx context.Context, user shared.User, paths []string) (shared.User, error)
 	DeleteUser(ctx context.Context, userID string) (string, error)
+	RestoreUser(ctx context.Context, userID string) (shared.User, error)
+	ListUsers(ctx context.Context, filter shared.UserFilter) (shared.UserPage, error)
+	ListAuditEvents(ctx context.Context, filter shared.AuditEventFilter) (shared.AuditEventPage, error)
+	WatchUsers(ctx context.Context, userID string, cursor string, send func(shared.Event) error) error
+	CreateWebhookSubscription(ctx context.Context, subscription shared.WebhookSubscription) (shared.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]shared.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error)
+	ListWebhookDeliveries(ctx context.Context, filter shared.WebhookDeliveryFilter) (shared.WebhookDeliveryPage, error)
+	CheckHealth(ctx context.Context) (string, error)
 }
 
 type userService struct {
@@ -34,7 +44,7 @@ func NewService(repository userrepository.UserRepository, logger log.Logger) Ser
 
 //Authenticate is a method to athenticate a user
 func (s *userService) Authenticate(ctx context.Context, name string, password string) (string, error) {
-	logger := log.With(s.logger, "method", "Authenticate")
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "Authenticate")
 
 	message, err := s.repository.Authenticate(ctx, name, password)
 	if err != nil {
@@ -47,7 +57,7 @@ func (s *userService) Authenticate(ctx context.Context, name string, password st
 
 //CreateUser is a method to create a user
 func (s *userService) CreateUser(ctx context.Context, user shared.User) (shared.User, error) {
-	logger := log.With(s.logger, "method", "CreateUser")
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "CreateUser")
 
 	userCreated, err := s.repository.CreateUser(ctx, user)
 	if err != nil {
@@ -60,7 +70,7 @@ func (s *userService) CreateUser(ctx context.Context, user shared.User) (shared.
 
 //GetUser is a method to get a user by id
 func (s *userService) GetUser(ctx context.Context, userID string) (shared.User, error) {
-	logger := log.With(s.logger, "method", "GetUser")
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "GetUser")
 
 	user, err := s.repository.GetUser(ctx, userID)
 	if err != nil {
@@ -73,7 +83,7 @@ func (s *userService) GetUser(ctx context.Context, userID string) (shared.User,
 
 //UpdateUser is a method to update a user
 func (s *userService) UpdateUser(ctx context.Context, user shared.User) (shared.User, error) {
-	logger := log.With(s.logger, "method", "UpdateUser")
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "UpdateUser")
 
 	userCreated, err := s.repository.UpdateUser(ctx, user)
 	if err != nil {
@@ -84,9 +94,22 @@ func (s *userService) UpdateUser(ctx context.Context, user shared.User) (shared.
 	return userCreated, nil
 }
 
+//PatchUser is a method to update only the given fields of a user
+func (s *userService) PatchUser(ctx context.Context, user shared.User, paths []string) (shared.User, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "PatchUser")
+
+	userPatched, err := s.repository.PatchUser(ctx, user, paths)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.User{}, err
+	}
+
+	return userPatched, nil
+}
+
 //DeleteUser is a method to delete a user
 func (s *userService) DeleteUser(ctx context.Context, userID string) (string, error) {
-	logger := log.With(s.logger, "method", "DeleteUser")
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "DeleteUser")
 
 	message, err := s.repository.DeleteUser(ctx, userID)
 	if err != nil {
@@ -96,3 +119,120 @@ func (s *userService) DeleteUser(ctx context.Context, userID string) (string, er
 
 	return message, nil
 }
+
+//RestoreUser is a method to restore a deleted user
+func (s *userService) RestoreUser(ctx context.Context, userID string) (shared.User, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "RestoreUser")
+
+	user, err := s.repository.RestoreUser(ctx, userID)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.User{}, err
+	}
+
+	return user, nil
+}
+
+//ListUsers is a method to list users matching a filter
+func (s *userService) ListUsers(ctx context.Context, filter shared.UserFilter) (shared.UserPage, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "ListUsers")
+
+	page, err := s.repository.ListUsers(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.UserPage{}, err
+	}
+
+	return page, nil
+}
+
+//ListAuditEvents is a method to list the audit events of a user
+func (s *userService) ListAuditEvents(ctx context.Context, filter shared.AuditEventFilter) (shared.AuditEventPage, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "ListAuditEvents")
+
+	page, err := s.repository.ListAuditEvents(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.AuditEventPage{}, err
+	}
+
+	return page, nil
+}
+
+//WatchUsers is a method to stream the user changes after a cursor
+func (s *userService) WatchUsers(ctx context.Context, userID string, cursor string, send func(shared.Event) error) error {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "WatchUsers")
+
+	err := s.repository.WatchUsers(ctx, userID, cursor, send)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return err
+	}
+
+	return nil
+}
+
+//CreateWebhookSubscription is a method to subscribe a URL to user events
+func (s *userService) CreateWebhookSubscription(ctx context.Context, subscription shared.WebhookSubscription) (shared.WebhookSubscription, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "CreateWebhookSubscription")
+
+	created, err := s.repository.CreateWebhookSubscription(ctx, subscription)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.WebhookSubscription{}, err
+	}
+
+	return created, nil
+}
+
+//ListWebhookSubscriptions is a method to list the webhook subscriptions
+func (s *userService) ListWebhookSubscriptions(ctx context.Context) ([]shared.WebhookSubscription, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "ListWebhookSubscriptions")
+
+	subscriptions, err := s.repository.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+//DeleteWebhookSubscription is a method to delete a webhook subscription
+func (s *userService) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) (string, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "DeleteWebhookSubscription")
+
+	message, err := s.repository.DeleteWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return "", err
+	}
+
+	return message, nil
+}
+
+//ListWebhookDeliveries is a method to list the deliveries of a webhook subscription
+func (s *userService) ListWebhookDeliveries(ctx context.Context, filter shared.WebhookDeliveryFilter) (shared.WebhookDeliveryPage, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "ListWebhookDeliveries")
+
+	page, err := s.repository.ListWebhookDeliveries(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return shared.WebhookDeliveryPage{}, err
+	}
+
+	return page, nil
+}
+
+//CheckHealth is a method to obtain the gRPC serving status of the user service
+func (s *userService) CheckHealth(ctx context.Context) (string, error) {
+	logger := log.With(shared.ContextLogger(ctx, s.logger), "method", "CheckHealth")
+
+	status, err := s.repository.CheckHealth(ctx)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		return "", err
+	}
+
+	return status, nil
+}
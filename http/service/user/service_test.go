@@ -1,7 +1,9 @@
 package userservice
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
 
@@ -82,6 +84,24 @@ func TestUpdateuser(t *testing.T) {
 	c.Equal(errForcedFailure, err)
 }
 
+func TestPatchUser(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.PatchUser(context.Background(), shared.User{ID: "USR123"}, []string{shared.UserPathName})
+	c.NoError(err)
+	c.Equal("USR123", result.ID)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.PatchUser(context.Background(), shared.User{ID: "USR123"}, []string{shared.UserPathName})
+	c.Equal(errForcedFailure, err)
+}
+
 func TestDeleteUser(t *testing.T) {
 	c := require.New(t)
 
@@ -99,3 +119,189 @@ func TestDeleteUser(t *testing.T) {
 	_, err = service.DeleteUser(context.Background(), "USR123")
 	c.Equal(errForcedFailure, err)
 }
+
+func TestRestoreUser(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.RestoreUser(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal("USR123", result.ID)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.RestoreUser(context.Background(), "USR123")
+	c.Equal(errForcedFailure, err)
+}
+
+func TestListUsers(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.ListUsers(context.Background(), shared.UserFilter{CreatedBy: "admin"})
+	c.NoError(err)
+	c.Equal("admin", result.Users[0].CreatedBy)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.ListUsers(context.Background(), shared.UserFilter{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.ListAuditEvents(context.Background(), shared.AuditEventFilter{Target: "USR123"})
+	c.NoError(err)
+	c.Equal("USR123", result.Events[0].Target)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.ListAuditEvents(context.Background(), shared.AuditEventFilter{Target: "USR123"})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestWatchUsers(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	var received []shared.Event
+	err := service.WatchUsers(context.Background(), "USR123", "", func(event shared.Event) error {
+		received = append(received, event)
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]shared.Event{{ID: 1, Type: shared.EventUserUpdated, AggregateID: "USR123"}}, received)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	err = service.WatchUsers(context.Background(), "USR123", "", func(shared.Event) error { return nil })
+	c.Equal(errForcedFailure, err)
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.CreateWebhookSubscription(context.Background(), shared.WebhookSubscription{URL: "https://example.com/hook"})
+	c.NoError(err)
+	c.Equal("WHK123", result.ID)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.CreateWebhookSubscription(context.Background(), shared.WebhookSubscription{})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.ListWebhookSubscriptions(context.Background())
+	c.NoError(err)
+	c.Equal("WHK123", result[0].ID)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.ListWebhookSubscriptions(context.Background())
+	c.Equal(errForcedFailure, err)
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.DeleteWebhookSubscription(context.Background(), "WHK123")
+	c.NoError(err)
+	c.Equal("webhook subscription deleted successfully", result)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.DeleteWebhookSubscription(context.Background(), "WHK123")
+	c.Equal(errForcedFailure, err)
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.ListWebhookDeliveries(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.NoError(err)
+	c.Equal("WHK123", result.Deliveries[0].SubscriptionID)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.ListWebhookDeliveries(context.Background(), shared.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.Equal(errForcedFailure, err)
+}
+
+func TestCheckHealth(t *testing.T) {
+	c := require.New(t)
+
+	service := NewService(&repoMock{}, log.NewJSONLogger(os.Stdout))
+
+	result, err := service.CheckHealth(context.Background())
+	c.NoError(err)
+	c.Equal("SERVING", result)
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err = service.CheckHealth(context.Background())
+	c.Equal(errForcedFailure, err)
+}
+
+func TestErrorsAreLoggedWithRequestID(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	service := NewService(&repoMock{}, log.NewJSONLogger(&buffer))
+
+	forceMockFail = true
+	defer func() {
+		forceMockFail = false
+	}()
+
+	_, err := service.GetUser(shared.ContextWithRequestID(context.Background(), "REQ123"), "USR123")
+	c.Equal(errForcedFailure, err)
+
+	var line map[string]interface{}
+	c.NoError(json.Unmarshal(buffer.Bytes(), &line))
+	c.Equal("REQ123", line["request_id"])
+	c.Equal("GetUser", line["method"])
+}
@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func TestAuthenticationMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	credentials, err := shared.ParseCredentials([]string{"USR123:secret"})
+	c.NoError(err)
+
+	proxies, err := shared.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	c.NoError(err)
+
+	var actor, clientIP string
+	usrEndpoints := &userendpoints.UserEndpoints{
+		GetUser: func(ctx context.Context, request interface{}) (interface{}, error) {
+			actor = shared.ActorFromContext(ctx)
+			clientIP = shared.ClientIPFromContext(ctx)
+			return shared.User{ID: "USR123"}, nil
+		},
+	}
+
+	handler := NewHTTPServer(usrEndpoints, http.NotFoundHandler(), Authentication{Credentials: credentials, TrustedProxies: proxies}, log.NewNopLogger())
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/user/USR123", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set(shared.ActorHeader, "admin")
+	r.Header.Set(shared.ForwardedForHeader, "198.51.100.1")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal(shared.AnonymousActor, actor, "the X-Actor header is ignored")
+	c.Equal("203.0.113.7", clientIP, "X-Forwarded-For is only trusted from the proxies")
+
+	r = httptest.NewRequest(http.MethodGet, "/v2/user/USR123", nil)
+	r.RemoteAddr = "10.0.0.2:51234"
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set(shared.ForwardedForHeader, "198.51.100.1")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal("USR123", actor)
+	c.Equal("198.51.100.1", clientIP)
+
+	r = httptest.NewRequest(http.MethodGet, "/v2/user/USR123", nil)
+	r.Header.Set("Authorization", "Bearer wrong")
+	r.Header.Set(shared.RequestIDHeader, "REQ123")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Equal(http.StatusUnauthorized, w.Code)
+	c.Equal("Bearer", w.Header().Get("WWW-Authenticate"))
+
+	var problem Problem
+	c.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	c.Equal(shared.ErrInvalidToken.Code, problem.Code)
+	c.Equal("REQ123", problem.RequestID)
+	c.Equal("/v2/user/USR123", problem.Instance)
+}
+
+func TestWebhooksRequireAdmin(t *testing.T) {
+	c := require.New(t)
+
+	credentials, err := shared.ParseCredentials([]string{"USR123:secret", "ops:admin-secret"})
+	c.NoError(err)
+
+	called := false
+	usrEndpoints := &userendpoints.UserEndpoints{
+		ListWebhookSubscriptions: func(ctx context.Context, request interface{}) (interface{}, error) {
+			called = true
+			return userendpoints.ListWebhookSubscriptionsResponse{}, nil
+		},
+	}
+
+	handler := NewHTTPServer(usrEndpoints, http.NotFoundHandler(), Authentication{Credentials: credentials, Admins: []string{"ops"}}, log.NewNopLogger())
+
+	for _, authorization := range []string{"", "Bearer secret"} {
+		r := httptest.NewRequest(http.MethodGet, "/v2/webhooks", nil)
+		r.Header.Set("Authorization", authorization)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		c.Equal(http.StatusForbidden, w.Code)
+
+		var problem Problem
+		c.NoError(json.NewDecoder(w.Body).Decode(&problem))
+		c.Equal(shared.ErrAdminRequired.Code, problem.Code)
+	}
+	c.False(called)
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/webhooks", nil)
+	r.Header.Set("Authorization", "Bearer admin-secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Equal(http.StatusOK, w.Code)
+	c.True(called)
+}
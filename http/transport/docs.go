@@ -0,0 +1,58 @@
+package transport
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPISpec is the OpenAPI 3 document of the routes registered by NewHTTPServer
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerUI is the page rendering openAPISpec with the Swagger UI served by the gateway itself
+//
+//go:embed docs.html
+var swaggerUI []byte
+
+// swaggerUIBundle and swaggerUIStyles are Swagger UI 5.18.2, vendored from the swagger-ui-dist package
+//
+//go:embed swagger-ui/swagger-ui-bundle.js
+var swaggerUIBundle []byte
+
+//go:embed swagger-ui/swagger-ui.css
+var swaggerUIStyles []byte
+
+// Paths of the API documentation
+const (
+	OpenAPIPath         = "/openapi.json"
+	DocsPath            = "/docs"
+	SwaggerUIBundlePath = DocsPath + "/swagger-ui-bundle.js"
+	SwaggerUIStylesPath = DocsPath + "/swagger-ui.css"
+)
+
+// registerDocs serves the OpenAPI document and the Swagger UI
+func registerDocs(r *mux.Router) {
+	registerDocsFile(r, SwaggerUIBundlePath, "text/javascript; charset=utf-8", swaggerUIBundle)
+	registerDocsFile(r, SwaggerUIStylesPath, "text/css; charset=utf-8", swaggerUIStyles)
+
+	r.Methods("GET").Path(OpenAPIPath).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(openAPISpec)
+	})
+
+	r.Methods("GET").Path(DocsPath).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(swaggerUI)
+	})
+}
+
+// registerDocsFile serves an embedded file with its content type
+func registerDocsFile(r *mux.Router, path, contentType string, content []byte) {
+	r.Methods("GET").Path(path).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(content)
+	})
+}
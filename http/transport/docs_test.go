@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Servers    []openAPIServer                       `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct {
+	Servers []openAPIServer `json:"servers"`
+}
+
+// openAPIMethods are the keys of a path item that are operations
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPISchemaTypes are the types encoded as the schemas of the document
+var openAPISchemaTypes = map[string]interface{}{
+	"User":                    shared.User{},
+	"UserPage":                shared.UserPage{},
+	"AuthenticationRequest":   userendpoints.AuthenticationRequest{},
+	"Message":                 userendpoints.DeleteUserResponse{},
+	"AuditEvent":              shared.AuditEvent{},
+	"AuditChange":             shared.AuditChange{},
+	"AuditEventPage":          shared.AuditEventPage{},
+	"Event":                   shared.Event{},
+	"WebhookSubscription":     shared.WebhookSubscription{},
+	"WebhookSubscriptionList": userendpoints.ListWebhookSubscriptionsResponse{},
+	"WebhookDelivery":         shared.WebhookDelivery{},
+	"WebhookDeliveryPage":     shared.WebhookDeliveryPage{},
+	"Health":                  userendpoints.HealthResponse{},
+	"Problem":                 Problem{},
+	"InvalidParam":            InvalidParam{},
+}
+
+func loadOpenAPIDocument(t *testing.T) openAPIDocument {
+	var document openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &document))
+
+	return document
+}
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	c := require.New(t)
+
+	document := loadOpenAPIDocument(t)
+	c.Equal("3.0.3", document.OpenAPI)
+
+	documented := []string{}
+	for path, item := range document.Paths {
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+
+			var operation openAPIOperation
+			c.NoError(json.Unmarshal(raw, &operation))
+
+			servers := operation.Servers
+			if len(servers) == 0 {
+				servers = document.Servers
+			}
+
+			for _, server := range servers {
+				documented = append(documented, strings.ToUpper(method)+" "+strings.TrimSuffix(server.URL, "/")+path)
+			}
+		}
+	}
+
+	router := NewHTTPServer(&userendpoints.UserEndpoints{}, http.NotFoundHandler(), Authentication{}, log.NewNopLogger()).(*mux.Router)
+
+	registered := []string{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || path == OpenAPIPath || path == DocsPath || strings.HasPrefix(path, DocsPath+"/") {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			registered = append(registered, method+" "+path)
+		}
+
+		return nil
+	})
+	c.NoError(err)
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+	c.Equal(registered, documented)
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	c := require.New(t)
+
+	document := loadOpenAPIDocument(t)
+
+	for name, value := range openAPISchemaTypes {
+		schema, ok := document.Components.Schemas[name]
+		c.True(ok, name)
+
+		properties := []string{}
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		sort.Strings(properties)
+		c.Equal(jsonFieldNames(reflect.TypeOf(value)), properties, name)
+	}
+
+	c.Len(document.Components.Schemas, len(openAPISchemaTypes))
+}
+
+func TestDocsRoutes(t *testing.T) {
+	c := require.New(t)
+
+	handler := NewHTTPServer(&userendpoints.UserEndpoints{}, http.NotFoundHandler(), Authentication{}, log.NewNopLogger())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal(openAPISpec, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DocsPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal("text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	c.Contains(w.Body.String(), OpenAPIPath)
+	c.Contains(w.Body.String(), SwaggerUIBundlePath)
+	c.Contains(w.Body.String(), SwaggerUIStylesPath)
+	c.NotContains(w.Body.String(), "https://", "the docs page loads nothing from a third party")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SwaggerUIBundlePath, nil))
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal("text/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	c.Equal(swaggerUIBundle, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SwaggerUIStylesPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal("text/css; charset=utf-8", w.Header().Get("Content-Type"))
+	c.Equal(swaggerUIStyles, w.Body.Bytes())
+}
+
+// jsonFieldNames returns the sorted names encoding/json gives to the fields of a struct
+func jsonFieldNames(structType reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
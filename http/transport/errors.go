@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// problemContentType is the media type of RFC 7807 problem details
+const problemContentType = "application/problem+json"
+
+// statusClientClosedRequest is the non standard status of the requests canceled by the client
+const statusClientClosedRequest = 499
+
+// Stable codes of the requests that do not match any route
+const (
+	CodeRouteNotFound    = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+)
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Code          string         `json:"code"`
+	RequestID     string         `json:"request_id,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is a request parameter rejected by validation
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+var httpStatuses = map[shared.ErrorKind]int{
+	shared.KindInternal:         http.StatusInternalServerError,
+	shared.KindInvalidArgument:  http.StatusBadRequest,
+	shared.KindNotFound:         http.StatusNotFound,
+	shared.KindAlreadyExists:    http.StatusConflict,
+	shared.KindUnauthenticated:  http.StatusUnauthorized,
+	shared.KindPermissionDenied: http.StatusForbidden,
+	shared.KindCanceled:         statusClientClosedRequest,
+	shared.KindDeadlineExceeded: http.StatusGatewayTimeout,
+	shared.KindUnavailable:      http.StatusServiceUnavailable,
+	shared.KindUpstream:         http.StatusBadGateway,
+}
+
+// encodeError is the ServerErrorEncoder of every route, it writes the error as an RFC 7807 problem
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	writeProblem(w, newProblem(ctx, err))
+}
+
+// newProblem translates a typed error, or a gRPC status not decoded by the repository, into a problem
+func newProblem(ctx context.Context, err error) Problem {
+	typed := shared.AsError(shared.FromGRPCError(err))
+
+	status, ok := httpStatuses[typed.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     statusText(status),
+		Status:    status,
+		Detail:    typed.Message,
+		Code:      typed.Code,
+		RequestID: shared.RequestIDFromContext(ctx),
+	}
+
+	if path, ok := ctx.Value(httptransport.ContextKeyRequestPath).(string); ok {
+		problem.Instance = path
+	}
+
+	for _, violation := range typed.FieldViolations() {
+		problem.InvalidParams = append(problem.InvalidParams, InvalidParam{Name: violation.Field, Reason: violation.Message})
+	}
+
+	return problem
+}
+
+func statusText(status int) string {
+	if status == statusClientClosedRequest {
+		return "Client Closed Request"
+	}
+
+	return http.StatusText(status)
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// routeErrorHandler answers the requests that do not match any route
+func routeErrorHandler(status int, code string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, Problem{
+			Type:      "about:blank",
+			Title:     statusText(status),
+			Status:    status,
+			Instance:  r.URL.Path,
+			Code:      code,
+			RequestID: r.Header.Get(shared.RequestIDHeader),
+		})
+	})
+}
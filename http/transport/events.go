@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// lastEventIDHeader is sent by EventSource clients when they reconnect
+const lastEventIDHeader = "Last-Event-ID"
+
+// watchUsersHandler streams the user changes as server-sent events, resuming from the cursor parameter or the Last-Event-ID header
+func watchUsersHandler(watchUsers endpoint.Endpoint, logger log.Logger) http.Handler {
+	logger = log.With(logger, "handler", "WatchUsers")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+
+		cursor := query.Get("cursor")
+		if cursor == "" {
+			cursor = r.Header.Get(lastEventIDHeader)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		req := userendpoints.WatchUsersRequest{
+			UserID: query.Get("user_id"),
+			Cursor: cursor,
+			Send: func(event shared.Event) error {
+				err := writeServerSentEvent(w, event)
+				if err != nil {
+					return err
+				}
+
+				flusher.Flush()
+
+				return nil
+			},
+		}
+
+		ctx := contextFromHeader(r.Context(), r)
+
+		_, err := watchUsers(ctx, req)
+		if err != nil && ctx.Err() != context.Canceled {
+			level.Error(shared.ContextLogger(ctx, logger)).Log("err", err)
+
+			problem, _ := json.Marshal(newProblem(ctx, err))
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", problem)
+			flusher.Flush()
+		}
+	})
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event shared.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Cursor, event.Type, data)
+
+	return err
+}
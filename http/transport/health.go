@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+)
+
+// Paths of the probes
+const (
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// registerHealth serves the liveness and the readiness probes
+func registerHealth(r *mux.Router, usrEndpoints *userendpoints.UserEndpoints, options []httptransport.ServerOption) {
+	r.Methods("GET").Path(HealthPath).Handler(
+		httptransport.NewServer(
+			usrEndpoints.Health,
+			decodeHealthRequest,
+			encodeHealthResponse,
+			options...,
+		),
+	)
+
+	r.Methods("GET").Path(ReadinessPath).Handler(
+		httptransport.NewServer(
+			usrEndpoints.Health,
+			decodeHealthRequest,
+			encodeReadinessResponse,
+			options...,
+		),
+	)
+}
+
+func decodeHealthRequest(_ context.Context, _ *http.Request) (request interface{}, err error) {
+	return userendpoints.HealthRequest{}, nil
+}
+
+func encodeHealthResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(userendpoints.HealthResponse)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func encodeReadinessResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(userendpoints.HealthResponse)
+	if !res.Ready() {
+		res.Status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+// FailReadinessWhile answers the readiness probe with 503 Service Unavailable while draining returns true
+func FailReadinessWhile(draining func() bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ReadinessPath || !draining() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(userendpoints.HealthResponse{Status: "draining"})
+	})
+}
@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	c := require.New(t)
+
+	upstream := "SERVING"
+	usrEndpoints := &userendpoints.UserEndpoints{
+		Health: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return userendpoints.HealthResponse{Status: "ok", Upstream: upstream}, nil
+		},
+	}
+
+	handler := NewHTTPServer(usrEndpoints, http.NotFoundHandler(), Authentication{}, log.NewNopLogger())
+
+	for _, path := range []string{HealthPath, ReadinessPath} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		c.Equal(http.StatusOK, w.Code, path)
+
+		var res userendpoints.HealthResponse
+		c.NoError(json.NewDecoder(w.Body).Decode(&res))
+		c.Equal(userendpoints.HealthResponse{Status: "ok", Upstream: "SERVING"}, res)
+	}
+
+	upstream = userendpoints.UpstreamUnreachable
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	c.Equal(http.StatusServiceUnavailable, w.Code)
+
+	var res userendpoints.HealthResponse
+	c.NoError(json.NewDecoder(w.Body).Decode(&res))
+	c.Equal(userendpoints.HealthResponse{Status: "unavailable", Upstream: userendpoints.UpstreamUnreachable}, res)
+}
+
+func TestFailReadinessWhileDraining(t *testing.T) {
+	c := require.New(t)
+
+	usrEndpoints := &userendpoints.UserEndpoints{
+		Health: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return userendpoints.HealthResponse{Status: "ok", Upstream: "SERVING"}, nil
+		},
+	}
+
+	draining := false
+	handler := FailReadinessWhile(func() bool { return draining }, NewHTTPServer(usrEndpoints, http.NotFoundHandler(), Authentication{}, log.NewNopLogger()))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+
+	draining = true
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	c.Equal(http.StatusServiceUnavailable, w.Code)
+
+	var res userendpoints.HealthResponse
+	c.NoError(json.NewDecoder(w.Body).Decode(&res))
+	c.Equal("draining", res.Status)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	c.Equal(http.StatusOK, w.Code, "the liveness probe keeps answering while draining")
+}
@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	userendpoints "github.com/jumaroar-globant/go-bootcamp/http/endpoints/user"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDIsReturned(t *testing.T) {
+	c := require.New(t)
+
+	var endpointRequestID string
+	usrEndpoints := &userendpoints.UserEndpoints{
+		GetUser: func(ctx context.Context, request interface{}) (interface{}, error) {
+			endpointRequestID = shared.RequestIDFromContext(ctx)
+			return nil, shared.ErrUserNotFound
+		},
+	}
+
+	handler := NewHTTPServer(usrEndpoints, http.NotFoundHandler(), Authentication{}, log.NewNopLogger())
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/user/USR123", nil)
+	r.Header.Set(shared.RequestIDHeader, "REQ123")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Equal(http.StatusNotFound, w.Code)
+	c.Equal("REQ123", w.Header().Get(shared.RequestIDHeader))
+	c.Equal("REQ123", endpointRequestID)
+
+	var problem Problem
+	c.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	c.Equal("REQ123", problem.RequestID)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/user/USR123", nil))
+
+	generated := w.Header().Get(shared.RequestIDHeader)
+	c.Len(generated, 32)
+	c.Equal(generated, endpointRequestID)
+
+	c.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	c.Equal(generated, problem.RequestID)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	c.Equal(http.StatusNotFound, w.Code)
+
+	c.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	c.Equal(CodeRouteNotFound, problem.Code)
+	c.Equal(w.Header().Get(shared.RequestIDHeader), problem.RequestID)
+	c.NotEmpty(problem.RequestID)
+}
@@ -3,11 +3,18 @@ package transport
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-kit/kit/transport"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 
 	httptransport "github.com/go-kit/kit/transport/http"
 
@@ -15,20 +22,67 @@ import (
 	"github.com/jumaroar-globant/go-bootcamp/shared"
 )
 
-var (
-	ErrMissingUserID = errors.New("missing user id")
-)
+// GraphQLPath is the path of the GraphQL endpoint, it is not versioned
+const GraphQLPath = "/graphql"
+
+// Authentication identifies the clients of the gateway and the actors that are administrators
+type Authentication struct {
+	Credentials    shared.Credentials
+	TrustedProxies shared.TrustedProxies
+	Admins         []string
+}
 
-// NewHTTPServer generates a new HTTPServer with its endpoints
-func NewHTTPServer(usrEndpoints *userendpoints.UserEndpoints, logger log.Logger) http.Handler {
+// NewHTTPServer generates a new HTTPServer with its endpoints, the API is served under /v1, /v2 and the unprefixed paths
+func NewHTTPServer(usrEndpoints *userendpoints.UserEndpoints, graphQL http.Handler, authentication Authentication, logger log.Logger) http.Handler {
 	r := mux.NewRouter()
-	r.Use(commonMiddleware)
+	r.Use(requestIDMiddleware, authenticationMiddleware(authentication), otelmux.Middleware("gateway"), commonMiddleware)
+	r.NotFoundHandler = requestIDMiddleware(routeErrorHandler(http.StatusNotFound, CodeRouteNotFound))
+	r.MethodNotAllowedHandler = requestIDMiddleware(routeErrorHandler(http.StatusMethodNotAllowed, CodeMethodNotAllowed))
+
+	options := []httptransport.ServerOption{
+		httptransport.ServerBefore(httptransport.PopulateRequestContext, contextFromHeader),
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerErrorHandler(transport.ErrorHandlerFunc(func(ctx context.Context, err error) {
+			level.Error(shared.ContextLogger(ctx, logger)).Log("err", err)
+		})),
+	}
+
+	registerDocs(r)
+	registerHealth(r, usrEndpoints, options)
+
+	r.Methods("POST").Path(GraphQLPath).Handler(requestContextMiddleware(graphQL))
+
+	v1 := r.PathPrefix(v1Prefix).Subrouter()
+	v1.Use(deprecationMiddleware(v1Prefix))
+	registerRoutes(v1, usrEndpoints, logger, options)
+
+	v2 := r.PathPrefix(v2Prefix).Subrouter()
+	registerRoutes(v2, usrEndpoints, logger, options)
+
+	v2.Methods("PATCH").Path("/user/{id}").Handler(
+		httptransport.NewServer(
+			usrEndpoints.PatchUser,
+			decodePatchUserRequest,
+			encodePatchUserResponse,
+			options...,
+		),
+	)
+
+	legacy := r.NewRoute().Subrouter()
+	legacy.Use(deprecationMiddleware(""))
+	registerRoutes(legacy, usrEndpoints, logger, options)
+
+	return r
+}
 
+// registerRoutes registers the routes shared by every version of the API
+func registerRoutes(r *mux.Router, usrEndpoints *userendpoints.UserEndpoints, logger log.Logger, options []httptransport.ServerOption) {
 	r.Methods("POST").Path("/user/auth").Handler(
 		httptransport.NewServer(
 			usrEndpoints.Authenticate,
 			decodeAuthRequest,
 			encodeAuthResponse,
+			options...,
 		),
 	)
 
@@ -37,14 +91,27 @@ func NewHTTPServer(usrEndpoints *userendpoints.UserEndpoints, logger log.Logger)
 			usrEndpoints.CreateUser,
 			decodeCreateUserRequest,
 			encodeCreateUserResponse,
+			options...,
 		),
 	)
 
+	r.Methods("GET").Path("/user").Handler(
+		httptransport.NewServer(
+			usrEndpoints.ListUsers,
+			decodeListUsersRequest,
+			encodeListUsersResponse,
+			options...,
+		),
+	)
+
+	r.Methods("GET").Path("/user/events").Handler(watchUsersHandler(usrEndpoints.WatchUsers, logger))
+
 	r.Methods("GET").Path("/user/{id}").Handler(
 		httptransport.NewServer(
 			usrEndpoints.GetUser,
 			decodeGetUserRequest,
 			encodeGetUserResponse,
+			options...,
 		),
 	)
 
@@ -53,6 +120,7 @@ func NewHTTPServer(usrEndpoints *userendpoints.UserEndpoints, logger log.Logger)
 			usrEndpoints.UpdateUser,
 			decodeUpdateUserRequest,
 			encodeUpdateUserResponse,
+			options...,
 		),
 	)
 
@@ -61,10 +129,63 @@ func NewHTTPServer(usrEndpoints *userendpoints.UserEndpoints, logger log.Logger)
 			usrEndpoints.DeleteUser,
 			decodeDeleteUserRequest,
 			encodeDeleteUserResponse,
+			options...,
 		),
 	)
 
-	return r
+	r.Methods("POST").Path("/user/{id}/restore").Handler(
+		httptransport.NewServer(
+			usrEndpoints.RestoreUser,
+			decodeRestoreUserRequest,
+			encodeRestoreUserResponse,
+			options...,
+		),
+	)
+
+	r.Methods("GET").Path("/user/{id}/audit").Handler(
+		httptransport.NewServer(
+			usrEndpoints.ListAuditEvents,
+			decodeListAuditEventsRequest,
+			encodeListAuditEventsResponse,
+			options...,
+		),
+	)
+
+	r.Methods("POST").Path("/webhooks").Handler(adminMiddleware(
+		httptransport.NewServer(
+			usrEndpoints.CreateWebhookSubscription,
+			decodeCreateWebhookSubscriptionRequest,
+			encodeCreateWebhookSubscriptionResponse,
+			options...,
+		),
+	))
+
+	r.Methods("GET").Path("/webhooks").Handler(adminMiddleware(
+		httptransport.NewServer(
+			usrEndpoints.ListWebhookSubscriptions,
+			decodeListWebhookSubscriptionsRequest,
+			encodeListWebhookSubscriptionsResponse,
+			options...,
+		),
+	))
+
+	r.Methods("DELETE").Path("/webhooks/{id}").Handler(adminMiddleware(
+		httptransport.NewServer(
+			usrEndpoints.DeleteWebhookSubscription,
+			decodeDeleteWebhookSubscriptionRequest,
+			encodeDeleteWebhookSubscriptionResponse,
+			options...,
+		),
+	))
+
+	r.Methods("GET").Path("/webhooks/{id}/deliveries").Handler(adminMiddleware(
+		httptransport.NewServer(
+			usrEndpoints.ListWebhookDeliveries,
+			decodeListWebhookDeliveriesRequest,
+			encodeListWebhookDeliveriesResponse,
+			options...,
+		),
+	))
 }
 
 func commonMiddleware(next http.Handler) http.Handler {
@@ -74,10 +195,74 @@ func commonMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestIDMiddleware keeps the X-Request-ID sent by the client or generates one, and sends it back in the response
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := shared.RequestIDOrNew(r.Header.Get(shared.RequestIDHeader))
+		r.Header.Set(shared.RequestIDHeader, requestID)
+		w.Header().Set(shared.RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticationMiddleware puts the actor and client IP of the request into its context
+func authenticationMiddleware(authentication Authentication) mux.MiddlewareFunc {
+	admins := map[string]bool{}
+	for _, admin := range authentication.Admins {
+		admins[admin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var actor string
+			if authorization := r.Header.Get("Authorization"); authorization != "" {
+				var err error
+				actor, err = authentication.Credentials.Authenticate(authorization)
+				if err != nil {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					encodeError(contextFromHeader(httptransport.PopulateRequestContext(r.Context(), r), r), err, w)
+					return
+				}
+			}
+
+			ctx := shared.ContextWithActor(r.Context(), actor)
+			ctx = shared.ContextWithAdmin(ctx, actor != "" && admins[actor])
+			ctx = shared.ContextWithClientIP(ctx, authentication.TrustedProxies.ClientIP(r))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// adminMiddleware rejects the requests that are not made by an administrator, before they reach the user service
+func adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !shared.AdminFromContext(r.Context()) {
+			encodeError(contextFromHeader(httptransport.PopulateRequestContext(r.Context(), r), r), shared.ErrAdminRequired, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestContextMiddleware moves the request ID into the context of the handlers that are not served by go-kit
+func requestContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(contextFromHeader(r.Context(), r)))
+	})
+}
+
+// contextFromHeader moves the request ID of the request into the context
+func contextFromHeader(ctx context.Context, r *http.Request) context.Context {
+	return shared.ContextWithRequestID(ctx, r.Header.Get(shared.RequestIDHeader))
+}
+
 func decodeAuthRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req userendpoints.AuthenticationRequest
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
-		return nil, e
+		return nil, shared.ErrMalformedBody.Wrap(e)
 	}
 	return req, nil
 }
@@ -91,7 +276,7 @@ func encodeAuthResponse(_ context.Context, w http.ResponseWriter, response inter
 func decodeCreateUserRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req shared.User
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
-		return nil, e
+		return nil, shared.ErrMalformedBody.Wrap(e)
 	}
 	return req, nil
 }
@@ -107,7 +292,7 @@ func decodeGetUserRequest(_ context.Context, r *http.Request) (request interface
 
 	userID := mux.Vars(r)["id"]
 	if userID == "" {
-		return nil, ErrMissingUserID
+		return nil, shared.ErrMissingUserID
 	}
 
 	req.UserID = userID
@@ -124,7 +309,7 @@ func encodeGetUserResponse(_ context.Context, w http.ResponseWriter, response in
 func decodeUpdateUserRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req shared.User
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
-		return nil, e
+		return nil, shared.ErrMalformedBody.Wrap(e)
 	}
 
 	req.ID = mux.Vars(r)["id"]
@@ -138,12 +323,51 @@ func encodeUpdateUserResponse(_ context.Context, w http.ResponseWriter, response
 	return json.NewEncoder(w).Encode(res)
 }
 
+// decodePatchUserRequest reads the fields to update from the update_mask query parameter, or from the body without it
+func decodePatchUserRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, shared.ErrMalformedBody.Wrap(err)
+	}
+
+	var req userendpoints.PatchUserRequest
+	if e := json.Unmarshal(body, &req.User); e != nil {
+		return nil, shared.ErrMalformedBody.Wrap(e)
+	}
+
+	req.User.ID = mux.Vars(r)["id"]
+
+	if mask := r.URL.Query().Get("update_mask"); mask != "" {
+		req.Paths = strings.Split(mask, ",")
+		return req, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if e := json.Unmarshal(body, &fields); e != nil {
+		return nil, shared.ErrMalformedBody.Wrap(e)
+	}
+
+	for field := range fields {
+		req.Paths = append(req.Paths, field)
+	}
+
+	sort.Strings(req.Paths)
+
+	return req, nil
+}
+
+func encodePatchUserResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.User)
+	return json.NewEncoder(w).Encode(res)
+}
+
 func decodeDeleteUserRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req userendpoints.DeleteUserRequest
 
 	userID := mux.Vars(r)["id"]
 	if userID == "" {
-		return nil, ErrMissingUserID
+		return nil, shared.ErrMissingUserID
 	}
 
 	req.UserID = userID
@@ -156,3 +380,165 @@ func encodeDeleteUserResponse(_ context.Context, w http.ResponseWriter, response
 	res := response.(userendpoints.DeleteUserResponse)
 	return json.NewEncoder(w).Encode(res)
 }
+
+func decodeRestoreUserRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req userendpoints.RestoreUserRequest
+
+	userID := mux.Vars(r)["id"]
+	if userID == "" {
+		return nil, shared.ErrMissingUserID
+	}
+
+	req.UserID = userID
+
+	return req, nil
+}
+
+func encodeRestoreUserResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.User)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeListUsersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+
+	req := shared.UserFilter{
+		CreatedBy: query.Get("created_by"),
+		UpdatedBy: query.Get("updated_by"),
+		IDs:       query["id"],
+		PageToken: query.Get("page_token"),
+	}
+
+	timestamps := map[string]*time.Time{
+		"created_after":  &req.CreatedAfter,
+		"created_before": &req.CreatedBefore,
+		"updated_after":  &req.UpdatedAfter,
+		"updated_before": &req.UpdatedBefore,
+	}
+
+	for param, value := range timestamps {
+		if query.Get(param) == "" {
+			continue
+		}
+
+		*value, err = time.Parse(time.RFC3339, query.Get(param))
+		if err != nil {
+			return nil, shared.ErrBadTimestamp
+		}
+	}
+
+	if query.Get("page_size") != "" {
+		req.PageSize, err = strconv.Atoi(query.Get("page_size"))
+		if err != nil {
+			return nil, shared.ErrBadPageSize
+		}
+	}
+
+	return req, nil
+}
+
+func encodeListUsersResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.UserPage)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeListAuditEventsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+
+	req := shared.AuditEventFilter{
+		Target:    mux.Vars(r)["id"],
+		PageToken: query.Get("page_token"),
+	}
+
+	if req.Target == "" {
+		return nil, shared.ErrMissingUserID
+	}
+
+	if query.Get("page_size") != "" {
+		req.PageSize, err = strconv.Atoi(query.Get("page_size"))
+		if err != nil {
+			return nil, shared.ErrBadPageSize
+		}
+	}
+
+	return req, nil
+}
+
+func encodeListAuditEventsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.AuditEventPage)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeCreateWebhookSubscriptionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req shared.WebhookSubscription
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, shared.ErrMalformedBody.Wrap(e)
+	}
+	return req, nil
+}
+
+func encodeCreateWebhookSubscriptionResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.WebhookSubscription)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeListWebhookSubscriptionsRequest(_ context.Context, _ *http.Request) (request interface{}, err error) {
+	return userendpoints.ListWebhookSubscriptionsRequest{}, nil
+}
+
+func encodeListWebhookSubscriptionsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(userendpoints.ListWebhookSubscriptionsResponse)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeDeleteWebhookSubscriptionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req userendpoints.DeleteWebhookSubscriptionRequest
+
+	subscriptionID := mux.Vars(r)["id"]
+	if subscriptionID == "" {
+		return nil, shared.ErrMissingSubscriptionID
+	}
+
+	req.SubscriptionID = subscriptionID
+
+	return req, nil
+}
+
+func encodeDeleteWebhookSubscriptionResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(userendpoints.DeleteWebhookSubscriptionResponse)
+	return json.NewEncoder(w).Encode(res)
+}
+
+func decodeListWebhookDeliveriesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+
+	req := shared.WebhookDeliveryFilter{
+		SubscriptionID: mux.Vars(r)["id"],
+		PageToken:      query.Get("page_token"),
+	}
+
+	if req.SubscriptionID == "" {
+		return nil, shared.ErrMissingSubscriptionID
+	}
+
+	if query.Get("page_size") != "" {
+		req.PageSize, err = strconv.Atoi(query.Get("page_size"))
+		if err != nil {
+			return nil, shared.ErrBadPageSize
+		}
+	}
+
+	return req, nil
+}
+
+func encodeListWebhookDeliveriesResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res := response.(shared.WebhookDeliveryPage)
+	return json.NewEncoder(w).Encode(res)
+}
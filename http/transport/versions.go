@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	v1Prefix = "/v1"
+	v2Prefix = "/v2"
+)
+
+// v1DeprecatedAt is when v1 was deprecated in favour of v2, v1Sunset is when it stops being served
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.October, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// deprecationMiddleware marks the responses of a deprecated version with the Deprecation and Sunset headers
+func deprecationMiddleware(prefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			successor := v2Prefix + strings.TrimPrefix(r.URL.Path, prefix)
+
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", v1DeprecatedAt.Unix()))
+			w.Header().Set("Sunset", v1Sunset.Format(http.TimeFormat))
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package shared
+
+import "context"
+
+const (
+	// ActorHeader is the HTTP header carrying the actor performing a request
+	ActorHeader = "X-Actor"
+	// ActorMetadataKey is the gRPC metadata key carrying the actor performing a request
+	ActorMetadataKey = "x-actor"
+	// AnonymousActor is the actor used when a request does not identify one
+	AnonymousActor = "anonymous"
+	// SystemActor is the actor of the operations the service performs on its own, like the purge of deleted users
+	SystemActor = "system"
+)
+
+type actorContextKey struct{}
+
+type adminContextKey struct{}
+
+// ContextWithActor returns a copy of the context carrying the given actor
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	if actor == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by the context, or AnonymousActor if there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	if !ok || actor == "" {
+		return AnonymousActor
+	}
+
+	return actor
+}
+
+// ContextWithAdmin returns a copy of the context telling whether its actor is an administrator
+func ContextWithAdmin(ctx context.Context, admin bool) context.Context {
+	return context.WithValue(ctx, adminContextKey{}, admin)
+}
+
+// AdminFromContext reports whether the actor of the context is an administrator, it is false when the context does not tell
+func AdminFromContext(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminContextKey{}).(bool)
+	return admin
+}
@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFromContext(t *testing.T) {
+	c := require.New(t)
+
+	ctx := ContextWithActor(context.Background(), "admin")
+	c.Equal("admin", ActorFromContext(ctx))
+}
+
+func TestActorFromContextAnonymous(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(AnonymousActor, ActorFromContext(context.Background()))
+	c.Equal(AnonymousActor, ActorFromContext(ContextWithActor(context.Background(), "")))
+}
+
+func TestAdminFromContext(t *testing.T) {
+	c := require.New(t)
+
+	c.False(AdminFromContext(context.Background()))
+	c.True(AdminFromContext(ContextWithAdmin(context.Background(), true)))
+	c.False(AdminFromContext(ContextWithAdmin(context.Background(), false)))
+}
@@ -0,0 +1,67 @@
+package shared
+
+import "time"
+
+const (
+	// AuditActionAuthenticate is the audit action of an authentication attempt
+	AuditActionAuthenticate = "authenticate"
+	// AuditActionCreateUser is the audit action of a user creation
+	AuditActionCreateUser = "create_user"
+	// AuditActionUpdateUser is the audit action of a user update
+	AuditActionUpdateUser = "update_user"
+	// AuditActionDeleteUser is the audit action of a user deletion
+	AuditActionDeleteUser = "delete_user"
+	// AuditActionRestoreUser is the audit action of a user restoration
+	AuditActionRestoreUser = "restore_user"
+	// AuditActionPurgeUser is the audit action of the permanent removal of a soft deleted user
+	AuditActionPurgeUser = "purge_user"
+	// AuditActionCreateWebhook is the audit action of a webhook subscription creation
+	AuditActionCreateWebhook = "create_webhook"
+	// AuditActionDeleteWebhook is the audit action of a webhook subscription deletion
+	AuditActionDeleteWebhook = "delete_webhook"
+
+	// AuditOutcomeSuccess is the outcome of an operation that succeeded
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure is the outcome of an operation that failed
+	AuditOutcomeFailure = "failure"
+
+	// RedactedValue replaces secrets in audit changes
+	RedactedValue = "[REDACTED]"
+)
+
+// AuditEvent is an immutable record of an operation performed on a user
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Actor      string                 `json:"actor"`
+	Action     string                 `json:"action"`
+	Target     string                 `json:"target"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	ClientIP   string                 `json:"client_ip,omitempty"`
+	Outcome    string                 `json:"outcome"`
+	Reason     string                 `json:"reason,omitempty"`
+}
+
+// AuditChange is the value of a user field before and after an operation
+type AuditChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AuditEventFilter is the set of filters used to list audit events
+type AuditEventFilter struct {
+	Target string
+	// Targets lists the first page of each of these targets instead of the events of Target
+	Targets   []string
+	PageSize  int
+	PageToken string
+}
+
+// AuditEventPage is a page of audit events and the token to request the next one
+type AuditEventPage struct {
+	Events        []AuditEvent `json:"events"`
+	NextPageToken string       `json:"next_page_token,omitempty"`
+	// NextPageTokens are the next page tokens of each of the Targets of the filter, the REST API does not list several targets
+	NextPageTokens map[string]string `json:"-"`
+}
@@ -0,0 +1,386 @@
+package shared
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPrintConfig is returned by LoadConfig when -print-config is set, the caller prints the configuration and exits
+var ErrPrintConfig = errors.New("print the configuration")
+
+// secretFileSuffix is appended to the environment variable of a secret to read it from a file
+const secretFileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigValidator is implemented by the configurations that check their values once they are loaded
+type ConfigValidator interface {
+	Validate() error
+}
+
+// ConfigErrors collects the problems found while validating a configuration, so they are all reported at once
+type ConfigErrors []string
+
+// Addf records a problem
+func (e *ConfigErrors) Addf(format string, args ...interface{}) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+// Err returns nil when no problem was recorded, or an error listing them
+func (e ConfigErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid configuration: %s", strings.Join(e, "; "))
+}
+
+// ConfigOptions tell LoadConfig where the configuration of a binary comes from
+type ConfigOptions struct {
+	// Name is the name of the binary in the usage message of the flags
+	Name string
+	// Args are the command line arguments, without the name of the binary
+	Args []string
+	// LookupEnv reads the environment, os.LookupEnv when nil
+	LookupEnv func(key string) (string, bool)
+	// FileEnv is the environment variable naming the configuration file, the -config flag takes precedence over it
+	FileEnv string
+}
+
+// LoadConfig fills config from a YAML or TOML file, the environment and the flags mapped by its field tags
+func LoadConfig(config interface{}, options ConfigOptions) error {
+	lookupEnv := options.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	root := reflect.ValueOf(config).Elem()
+
+	flags := flag.NewFlagSet(options.Name, flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML or TOML configuration file, overridden by the environment and the flags")
+	printConfig := flags.Bool("print-config", false, "print the configuration with the secrets redacted and exit")
+
+	var configFlags []*configFlag
+	err := walkConfig(root, func(field reflect.Value, tag reflect.StructTag) error {
+		name := tag.Get("flag")
+		if name == "" {
+			return nil
+		}
+
+		value := &configFlag{field: field, defaultValue: formatConfigValue(field)}
+		flags.Var(value, name, tag.Get("usage"))
+		configFlags = append(configFlags, value)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := flags.Parse(options.Args); err != nil {
+		return err
+	}
+
+	if *configFile == "" && options.FileEnv != "" {
+		*configFile, _ = lookupEnv(options.FileEnv)
+	}
+
+	if *configFile != "" {
+		if err := decodeConfigFile(*configFile, config); err != nil {
+			return fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+	}
+
+	err = walkConfig(root, func(field reflect.Value, tag reflect.StructTag) error {
+		return loadConfigEnv(field, tag, lookupEnv)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, value := range configFlags {
+		if value.set {
+			parseConfigValue(value.field, value.raw)
+		}
+	}
+
+	if *printConfig {
+		return ErrPrintConfig
+	}
+
+	if validator, ok := config.(ConfigValidator); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
+
+// PrintConfig writes the configuration as YAML, the secrets that are set are replaced by Redacted
+func PrintConfig(w io.Writer, config interface{}) error {
+	node, err := configNode(reflect.Indirect(reflect.ValueOf(config)))
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// walkConfig calls visit for every field of the struct that is not a nested struct
+func walkConfig(value reflect.Value, visit func(field reflect.Value, tag reflect.StructTag) error) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			if err := walkConfig(field, visit); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := visit(field, structField.Tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeConfigFile(path string, config interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+
+		err := decoder.Decode(config)
+		if err == io.EOF {
+			return nil
+		}
+
+		return err
+	case ".toml":
+		metadata, err := toml.Decode(string(content), config)
+		if err != nil {
+			return err
+		}
+
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %s", undecoded[0])
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unsupported format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+}
+
+func loadConfigEnv(field reflect.Value, tag reflect.StructTag, lookupEnv func(string) (string, bool)) error {
+	name := tag.Get("env")
+	if name == "" {
+		return nil
+	}
+
+	raw, _ := lookupEnv(name)
+
+	if tag.Get("secret") == "true" {
+		path, _ := lookupEnv(name + secretFileSuffix)
+		if path != "" {
+			if raw != "" {
+				return fmt.Errorf("only one of %s and %s can be set", name, name+secretFileSuffix)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name+secretFileSuffix, err)
+			}
+
+			raw = strings.TrimRight(string(content), "\r\n")
+		}
+	}
+
+	if raw == "" {
+		return nil
+	}
+
+	if err := parseConfigValue(field, raw); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+func parseConfigValue(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(value)
+	case reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(value))
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(value)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported configuration type %s", field.Type())
+	}
+
+	return nil
+}
+
+func formatConfigValue(field reflect.Value) string {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+
+	if field.Kind() == reflect.Slice {
+		return strings.Join(field.Interface().([]string), ",")
+	}
+
+	return fmt.Sprint(field.Interface())
+}
+
+// configNode converts the configuration to a YAML mapping keyed by the yaml tags
+func configNode(value reflect.Value) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+
+		key := strings.Split(structField.Tag.Get("yaml"), ",")[0]
+		if structField.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+
+		var child *yaml.Node
+		switch {
+		case field.Kind() == reflect.Struct:
+			var err error
+			child, err = configNode(field)
+			if err != nil {
+				return nil, err
+			}
+		case structField.Tag.Get("secret") == "true" && !field.IsZero():
+			child = &yaml.Node{Kind: yaml.ScalarNode, Value: Redacted}
+		case field.Kind() == reflect.Slice:
+			child = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, item := range field.Interface().([]string) {
+				child.Content = append(child.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+			}
+		default:
+			child = &yaml.Node{}
+			if err := child.Encode(formatConfigScalar(field)); err != nil {
+				return nil, err
+			}
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	}
+
+	return node, nil
+}
+
+func formatConfigScalar(field reflect.Value) interface{} {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+
+	return field.Interface()
+}
+
+// configFlag is a flag.Value keeping the raw value of a flag
+type configFlag struct {
+	field        reflect.Value
+	defaultValue string
+	raw          string
+	set          bool
+}
+
+// String returns the default value shown in the usage message
+func (f *configFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.defaultValue
+}
+
+// Set keeps the raw value, it is parsed right away so a malformed flag is reported by the flag set
+func (f *configFlag) Set(raw string) error {
+	if err := parseConfigValue(reflect.New(f.field.Type()).Elem(), raw); err != nil {
+		return err
+	}
+
+	f.raw = raw
+	f.set = true
+
+	return nil
+}
+
+// IsBoolFlag lets the bool flags be set without a value
+func (f *configFlag) IsBoolFlag() bool {
+	return f.field.Kind() == reflect.Bool
+}
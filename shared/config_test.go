@@ -0,0 +1,207 @@
+package shared
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testDatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"TEST_DATABASE_HOST" flag:"database-host" usage:"database host"`
+	Password string `yaml:"password" toml:"password" env:"TEST_DATABASE_PASSWORD" secret:"true"`
+}
+
+type testConfig struct {
+	Address  string             `yaml:"address" toml:"address" env:"TEST_ADDRESS" flag:"address" usage:"listen address"`
+	Timeout  time.Duration      `yaml:"timeout" toml:"timeout" env:"TEST_TIMEOUT" flag:"timeout" usage:"request timeout"`
+	Debug    bool               `yaml:"debug" toml:"debug" env:"TEST_DEBUG" flag:"debug" usage:"debug mode"`
+	Ratio    float64            `yaml:"ratio" toml:"ratio" env:"TEST_RATIO"`
+	Workers  int                `yaml:"workers" toml:"workers" env:"TEST_WORKERS" flag:"workers" usage:"number of workers"`
+	Tokens   []string           `yaml:"tokens" toml:"tokens" env:"TEST_TOKENS" secret:"true"`
+	Database testDatabaseConfig `yaml:"database" toml:"database"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Workers < 1 {
+		return errors.New("workers must be positive")
+	}
+
+	return nil
+}
+
+func defaultTestConfig() testConfig {
+	return testConfig{Address: ":8080", Timeout: time.Second, Ratio: 1, Workers: 1, Database: testDatabaseConfig{Host: "localhost"}}
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	c := require.New(t)
+
+	config := defaultTestConfig()
+	c.NoError(LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(nil)}))
+	c.Equal(defaultTestConfig(), config)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	c := require.New(t)
+
+	file := writeConfigFile(t, "config.yaml", `
+address: ":9000"
+timeout: 5s
+workers: 4
+database:
+  host: db.internal
+  password: from-file
+`)
+
+	config := defaultTestConfig()
+	err := LoadConfig(&config, ConfigOptions{
+		Args: []string{"-config", file, "-workers", "8", "-debug"},
+		LookupEnv: lookupEnv(map[string]string{
+			"TEST_ADDRESS":       ":9100",
+			"TEST_WORKERS":       "6",
+			"TEST_TOKENS":        "one, two,",
+			"TEST_DATABASE_HOST": "",
+		}),
+	})
+	c.NoError(err)
+
+	c.Equal(":9100", config.Address, "the environment overrides the file")
+	c.Equal(5*time.Second, config.Timeout, "the file overrides the defaults")
+	c.Equal(8, config.Workers, "the flags override the environment")
+	c.True(config.Debug)
+	c.Equal(1.0, config.Ratio)
+	c.Equal([]string{"one", "two"}, config.Tokens)
+	c.Equal("db.internal", config.Database.Host, "an empty variable is ignored")
+	c.Equal("from-file", config.Database.Password)
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	c := require.New(t)
+
+	file := writeConfigFile(t, "config.toml", `
+address = ":9000"
+timeout = "2m"
+tokens = ["one"]
+
+[database]
+host = "db.internal"
+`)
+
+	config := defaultTestConfig()
+	c.NoError(LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{"CONFIG_FILE": file}), FileEnv: "CONFIG_FILE"}))
+	c.Equal(":9000", config.Address)
+	c.Equal(2*time.Minute, config.Timeout)
+	c.Equal([]string{"one"}, config.Tokens)
+	c.Equal("db.internal", config.Database.Host)
+}
+
+func TestLoadConfigSecretFile(t *testing.T) {
+	c := require.New(t)
+
+	secret := writeConfigFile(t, "password", "s3cret\n")
+
+	config := defaultTestConfig()
+	c.NoError(LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{"TEST_DATABASE_PASSWORD_FILE": secret})}))
+	c.Equal("s3cret", config.Database.Password)
+
+	config = defaultTestConfig()
+	err := LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{
+		"TEST_DATABASE_PASSWORD_FILE": secret,
+		"TEST_DATABASE_PASSWORD":      "other",
+	})})
+	c.EqualError(err, "only one of TEST_DATABASE_PASSWORD and TEST_DATABASE_PASSWORD_FILE can be set")
+
+	config = defaultTestConfig()
+	err = LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{"TEST_DATABASE_PASSWORD_FILE": secret + ".missing"})})
+	c.True(errors.Is(err, os.ErrNotExist))
+
+	config = defaultTestConfig()
+	c.NoError(LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{"TEST_ADDRESS_FILE": secret})}))
+	c.Equal(":8080", config.Address, "only the secrets are read from files")
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	c := require.New(t)
+
+	config := defaultTestConfig()
+	err := LoadConfig(&config, ConfigOptions{LookupEnv: lookupEnv(map[string]string{"TEST_TIMEOUT": "soon"})})
+	c.Error(err)
+	c.Contains(err.Error(), "TEST_TIMEOUT")
+
+	config = defaultTestConfig()
+	c.Error(LoadConfig(&config, ConfigOptions{Args: []string{"-workers", "many"}, LookupEnv: lookupEnv(nil)}))
+
+	config = defaultTestConfig()
+	c.EqualError(LoadConfig(&config, ConfigOptions{Args: []string{"-workers", "0"}, LookupEnv: lookupEnv(nil)}), "workers must be positive")
+
+	config = defaultTestConfig()
+	err = LoadConfig(&config, ConfigOptions{Args: []string{"-config", writeConfigFile(t, "config.yaml", "adress: typo\n")}, LookupEnv: lookupEnv(nil)})
+	c.Error(err)
+	c.Contains(err.Error(), "adress")
+
+	config = defaultTestConfig()
+	err = LoadConfig(&config, ConfigOptions{Args: []string{"-config", writeConfigFile(t, "config.toml", "adress = \"typo\"\n")}, LookupEnv: lookupEnv(nil)})
+	c.Error(err)
+	c.Contains(err.Error(), "unknown key adress")
+
+	config = defaultTestConfig()
+	c.Error(LoadConfig(&config, ConfigOptions{Args: []string{"-config", writeConfigFile(t, "config.json", "{}")}, LookupEnv: lookupEnv(nil)}))
+}
+
+func TestPrintConfig(t *testing.T) {
+	c := require.New(t)
+
+	config := defaultTestConfig()
+	err := LoadConfig(&config, ConfigOptions{
+		Args:      []string{"-print-config"},
+		LookupEnv: lookupEnv(map[string]string{"TEST_DATABASE_PASSWORD": "s3cret", "TEST_TOKENS": "tok-1,tok-2"}),
+	})
+	c.Equal(ErrPrintConfig, err)
+
+	var buffer bytes.Buffer
+	c.NoError(PrintConfig(&buffer, config))
+
+	output := buffer.String()
+	c.NotContains(output, "s3cret")
+	c.NotContains(output, "tok-1")
+	c.Equal(`address: :8080
+timeout: 1s
+debug: false
+ratio: 1
+workers: 1
+tokens: '[REDACTED]'
+database:
+  host: localhost
+  password: '[REDACTED]'
+`, output)
+}
+
+func TestConfigErrors(t *testing.T) {
+	c := require.New(t)
+
+	var problems ConfigErrors
+	c.NoError(problems.Err())
+
+	problems.Addf("%s must be positive", "workers")
+	problems.Addf("address is required")
+	c.EqualError(problems.Err(), "invalid configuration: workers must be positive; address is required")
+}
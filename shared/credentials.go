@@ -0,0 +1,73 @@
+package shared
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// credential is a bearer token and the actor it identifies
+type credential struct {
+	actor string
+	token []byte
+}
+
+// Credentials are the bearer tokens a service accepts, each of them identifying the actor presenting it
+type Credentials struct {
+	credentials []credential
+}
+
+// ParseCredentials parses the credentials written as actor:token, several tokens can identify the same actor
+func ParseCredentials(entries []string) (Credentials, error) {
+	var credentials Credentials
+	for i, entry := range entries {
+		separator := strings.Index(entry, ":")
+		if separator < 1 || separator == len(entry)-1 {
+			return Credentials{}, fmt.Errorf("the credential %d is not written as actor:token", i+1)
+		}
+
+		credentials.credentials = append(credentials.credentials, credential{
+			actor: entry[:separator],
+			token: []byte(entry[separator+1:]),
+		})
+	}
+
+	return credentials, nil
+}
+
+// Enabled reports whether any token is accepted
+func (c Credentials) Enabled() bool {
+	return len(c.credentials) > 0
+}
+
+// Actors returns the actors identified by the tokens
+func (c Credentials) Actors() []string {
+	actors := make([]string, 0, len(c.credentials))
+	for _, credential := range c.credentials {
+		actors = append(actors, credential.actor)
+	}
+
+	return actors
+}
+
+// Authenticate returns the actor identified by the bearer token of an Authorization value, or ErrInvalidToken
+func (c Credentials) Authenticate(authorization string) (string, error) {
+	if !strings.HasPrefix(authorization, BearerPrefix) {
+		return "", ErrInvalidToken
+	}
+
+	token := []byte(strings.TrimPrefix(authorization, BearerPrefix))
+
+	actor := ""
+	for _, credential := range c.credentials {
+		if subtle.ConstantTimeCompare(token, credential.token) == 1 && actor == "" {
+			actor = credential.actor
+		}
+	}
+
+	if actor == "" {
+		return "", ErrInvalidToken
+	}
+
+	return actor, nil
+}
@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentials(t *testing.T) {
+	c := require.New(t)
+
+	credentials, err := ParseCredentials([]string{"gateway:old secret", "gateway:new:secret", "batch:token"})
+	c.NoError(err)
+	c.True(credentials.Enabled())
+	c.Equal([]string{"gateway", "gateway", "batch"}, credentials.Actors())
+
+	for token, actor := range map[string]string{"old secret": "gateway", "new:secret": "gateway", "token": "batch"} {
+		authenticated, err := credentials.Authenticate(BearerPrefix + token)
+		c.NoError(err)
+		c.Equal(actor, authenticated)
+	}
+
+	for _, authorization := range []string{"", "token", "Bearer wrong", "Basic token", "Bearer batch:token"} {
+		_, err := credentials.Authenticate(authorization)
+		c.Equal(ErrInvalidToken, err, authorization)
+	}
+
+	credentials, err = ParseCredentials(nil)
+	c.NoError(err)
+	c.False(credentials.Enabled())
+
+	_, err = credentials.Authenticate(BearerPrefix + "token")
+	c.Equal(ErrInvalidToken, err)
+}
+
+func TestParseCredentialsErrors(t *testing.T) {
+	c := require.New(t)
+
+	for _, entry := range []string{"token", ":token", "gateway:"} {
+		_, err := ParseCredentials([]string{"gateway:secret", entry})
+		c.EqualError(err, "the credential 2 is not written as actor:token", entry)
+	}
+}
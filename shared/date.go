@@ -0,0 +1,107 @@
+package shared
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/genproto/googleapis/type/date"
+)
+
+// dateLayout is the layout of the dates in JSON
+const dateLayout = "2006-01-02"
+
+// Date is a calendar date without time zone, it is encoded in JSON as YYYY-MM-DD
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// NewDate returns the date of t in its location
+func NewDate(t time.Time) Date {
+	year, month, day := t.Date()
+
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// EstimateDateOfBirth returns the date of birth of someone who is the given number of years old at t
+func EstimateDateOfBirth(years int, t time.Time) Date {
+	return NewDate(t.AddDate(-years, 0, 0))
+}
+
+// ParseDate parses a YYYY-MM-DD date
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return Date{}, err
+	}
+
+	return NewDate(t), nil
+}
+
+// DateFromProto converts a google.type.Date, it returns nil when d is nil
+func DateFromProto(d *date.Date) *Date {
+	if d == nil {
+		return nil
+	}
+
+	return &Date{Year: int(d.Year), Month: time.Month(d.Month), Day: int(d.Day)}
+}
+
+// Proto converts the date into a google.type.Date, it returns nil when d is nil
+func (d *Date) Proto() *date.Date {
+	if d == nil {
+		return nil
+	}
+
+	return &date.Date{Year: int32(d.Year), Month: int32(d.Month), Day: int32(d.Day)}
+}
+
+// Time returns the midnight UTC of the date
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// Valid reports whether the date exists in the calendar
+func (d Date) Valid() bool {
+	return d.Year > 0 && NewDate(d.Time()) == d
+}
+
+// String returns the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Time().Format(dateLayout)
+}
+
+// AgeOn returns the number of whole years from the date to the day of t
+func (d Date) AgeOn(t time.Time) int {
+	year, month, day := t.Date()
+
+	age := year - d.Year
+	if month < d.Month || (month == d.Month && day < d.Day) {
+		age--
+	}
+
+	return age
+}
+
+// MarshalJSON encodes the date as a YYYY-MM-DD string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a YYYY-MM-DD string
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateAgeOn(t *testing.T) {
+	c := require.New(t)
+
+	birth := Date{Year: 1990, Month: time.June, Day: 15}
+
+	c.Equal(29, birth.AgeOn(time.Date(2020, time.June, 14, 23, 0, 0, 0, time.UTC)))
+	c.Equal(30, birth.AgeOn(time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC)))
+	c.Equal(30, birth.AgeOn(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestDateJSON(t *testing.T) {
+	c := require.New(t)
+
+	user := User{ID: "USR123", DateOfBirth: &Date{Year: 1990, Month: time.June, Day: 5}}
+
+	data, err := json.Marshal(user)
+	c.Nil(err)
+	c.Contains(string(data), `"date_of_birth":"1990-06-05"`)
+
+	var decoded User
+	c.Nil(json.Unmarshal(data, &decoded))
+	c.Equal(user.DateOfBirth, decoded.DateOfBirth)
+
+	c.NotNil(json.Unmarshal([]byte(`{"date_of_birth":"05/06/1990"}`), &decoded))
+}
+
+func TestDateProto(t *testing.T) {
+	c := require.New(t)
+
+	var missing *Date
+	c.Nil(missing.Proto())
+	c.Nil(DateFromProto(nil))
+
+	birth := &Date{Year: 1990, Month: time.June, Day: 5}
+	c.Equal(birth, DateFromProto(birth.Proto()))
+}
@@ -0,0 +1,206 @@
+package shared
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorKind classifies an error, the transports derive their status codes from it
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindInvalidArgument
+	KindNotFound
+	KindAlreadyExists
+	KindUnauthenticated
+	KindPermissionDenied
+	KindCanceled
+	KindDeadlineExceeded
+	KindUnavailable
+	KindUpstream
+)
+
+var errorKindNames = map[ErrorKind]string{
+	KindInternal:         "internal",
+	KindInvalidArgument:  "invalid_argument",
+	KindNotFound:         "not_found",
+	KindAlreadyExists:    "already_exists",
+	KindUnauthenticated:  "unauthenticated",
+	KindPermissionDenied: "permission_denied",
+	KindCanceled:         "canceled",
+	KindDeadlineExceeded: "deadline_exceeded",
+	KindUnavailable:      "unavailable",
+	KindUpstream:         "upstream",
+}
+
+// String returns the name of the kind
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+
+	return errorKindNames[KindInternal]
+}
+
+// parseErrorKind returns the kind with the given name, KindInternal when the name is unknown
+func parseErrorKind(name string) ErrorKind {
+	for kind, kindName := range errorKindNames {
+		if kindName == name {
+			return kind
+		}
+	}
+
+	return KindInternal
+}
+
+// internalErrorMessage is shown to clients instead of the message of errors that are not typed
+const internalErrorMessage = "internal error"
+
+// Error is a typed error, only its message is safe to show to clients
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Field is the request field rejected by a validation error
+	Field string
+	// Violations are the request fields rejected by a validation error that checked several fields
+	Violations []FieldViolation
+	Cause      error
+}
+
+// NewError returns a typed error without cause
+func NewError(kind ErrorKind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// NewValidationError returns a typed error rejecting a request field
+func NewValidationError(code string, field string, message string) *Error {
+	return &Error{
+		Kind:    KindInvalidArgument,
+		Code:    code,
+		Message: message,
+		Field:   field,
+	}
+}
+
+// Error returns the safe message followed by the cause, if any
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.Cause.Error()
+}
+
+// Unwrap returns the internal cause
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the target is a typed error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	return t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Cause = err
+
+	return &wrapped
+}
+
+// WithViolations returns a copy of the error rejecting the given fields
+func (e *Error) WithViolations(violations []FieldViolation) *Error {
+	withViolations := *e
+	withViolations.Violations = violations
+
+	return &withViolations
+}
+
+// FieldViolations returns the fields rejected by the error
+func (e *Error) FieldViolations() []FieldViolation {
+	if len(e.Violations) > 0 || e.Field == "" {
+		return e.Violations
+	}
+
+	return []FieldViolation{{Field: e.Field, Message: e.Message}}
+}
+
+// AsError returns the typed error in the chain of err, or an internal error when there is none
+func AsError(err error) *Error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrDeadlineExceeded.Wrap(err)
+	}
+
+	return ErrInternal.Wrap(err)
+}
+
+// KindOf returns the kind of err
+func KindOf(err error) ErrorKind {
+	return AsError(err).Kind
+}
+
+// SafeMessage returns the message of err that can be shown to clients
+func SafeMessage(err error) string {
+	return AsError(err).Message
+}
+
+// Generic errors
+var (
+	ErrInternal         = NewError(KindInternal, "INTERNAL", internalErrorMessage)
+	ErrCanceled         = NewError(KindCanceled, "CANCELED", "request canceled")
+	ErrDeadlineExceeded = NewError(KindDeadlineExceeded, "DEADLINE_EXCEEDED", "deadline exceeded")
+	ErrUnavailable      = NewError(KindUnavailable, "UNAVAILABLE", "service unavailable")
+	ErrBadReply         = NewError(KindUpstream, "BAD_UPSTREAM_REPLY", "the user service sent an invalid reply")
+	ErrMalformedBody    = NewError(KindInvalidArgument, "MALFORMED_BODY", "request body is not valid JSON")
+	ErrInvalidToken     = NewError(KindUnauthenticated, "INVALID_TOKEN", "missing or invalid service token")
+	ErrAdminRequired    = NewError(KindPermissionDenied, "ADMIN_REQUIRED", "only administrators can do this")
+)
+
+// User errors
+var (
+	ErrUserNotFound       = NewError(KindNotFound, "USER_NOT_FOUND", "user not found")
+	ErrUserAlreadyExists  = NewError(KindAlreadyExists, "USER_ALREADY_EXISTS", "user already exists")
+	ErrInvalidCredentials = NewError(KindUnauthenticated, "INVALID_CREDENTIALS", "invalid credentials")
+	ErrMissingUserID      = NewValidationError("MISSING_USER_ID", "id", "missing user id")
+	ErrMissingUserName    = NewValidationError("MISSING_USER_NAME", "name", "missing username")
+	ErrMissingPassword    = NewValidationError("MISSING_PASSWORD", "password", "missing password")
+	ErrBadAge             = NewValidationError("BAD_AGE", "age", "age is not a number")
+)
+
+// Pagination and filter errors
+var (
+	ErrBadPageToken = NewValidationError("BAD_PAGE_TOKEN", "page_token", "bad page token")
+	ErrBadPageSize  = NewValidationError("BAD_PAGE_SIZE", "page_size", "page size must be a non-negative number")
+	ErrBadTimestamp = NewValidationError("BAD_TIMESTAMP", "", "timestamps must be in RFC 3339 format")
+	ErrBadCursor    = NewValidationError("BAD_CURSOR", "cursor", "bad cursor")
+)
+
+// Webhook errors
+var (
+	ErrWebhookSubscriptionNotFound = NewError(KindNotFound, "WEBHOOK_SUBSCRIPTION_NOT_FOUND", "webhook subscription not found")
+	ErrBadWebhookURL               = NewValidationError("BAD_WEBHOOK_URL", "url", "webhook url must be an absolute http or https url")
+	ErrNonPublicWebhookURL         = NewValidationError("NON_PUBLIC_WEBHOOK_URL", "url", "webhook url must only resolve to public addresses")
+	ErrMissingEventTypes           = NewValidationError("MISSING_EVENT_TYPES", "event_types", "missing event types")
+	ErrUnknownEventType            = NewValidationError("UNKNOWN_EVENT_TYPE", "event_types", "unknown event type")
+	ErrMissingSubscriptionID       = NewValidationError("MISSING_SUBSCRIPTION_ID", "id", "missing subscription id")
+)
@@ -0,0 +1,136 @@
+package shared
+
+import (
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to the gRPC statuses of typed errors
+const ErrorDomain = "go-bootcamp"
+
+// errorKindMetadataKey is the ErrorInfo metadata key carrying the error kind
+const errorKindMetadataKey = "kind"
+
+var grpcCodes = map[ErrorKind]codes.Code{
+	KindInternal:         codes.Internal,
+	KindInvalidArgument:  codes.InvalidArgument,
+	KindNotFound:         codes.NotFound,
+	KindAlreadyExists:    codes.AlreadyExists,
+	KindUnauthenticated:  codes.Unauthenticated,
+	KindPermissionDenied: codes.PermissionDenied,
+	KindCanceled:         codes.Canceled,
+	KindDeadlineExceeded: codes.DeadlineExceeded,
+	KindUnavailable:      codes.Unavailable,
+	KindUpstream:         codes.Internal,
+}
+
+// GRPCCode returns the gRPC status code of the kind
+func (k ErrorKind) GRPCCode() codes.Code {
+	if code, ok := grpcCodes[k]; ok {
+		return code
+	}
+
+	return codes.Internal
+}
+
+// ToGRPCStatus converts err into a gRPC status carrying the safe message, the code and the rejected fields of the typed error
+func ToGRPCStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	typed := AsError(err)
+
+	st := status.New(typed.Kind.GRPCCode(), typed.Message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   typed.Code,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{errorKindMetadataKey: typed.Kind.String()},
+	}
+
+	var withDetails *status.Status
+	if violations := typed.FieldViolations(); len(violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Message,
+			})
+		}
+
+		withDetails, err = st.WithDetails(info, badRequest)
+	} else {
+		withDetails, err = st.WithDetails(info)
+	}
+	if err != nil {
+		return st
+	}
+
+	return withDetails
+}
+
+// FromGRPCError rebuilds the typed error encoded by ToGRPCStatus
+func FromGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+
+	var typed *Error
+	var violations []FieldViolation
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.Domain != ErrorDomain {
+				continue
+			}
+
+			typed = NewError(parseErrorKind(d.Metadata[errorKindMetadataKey]), d.Reason, st.Message())
+		case *errdetails.BadRequest:
+			for _, violation := range d.FieldViolations {
+				violations = append(violations, FieldViolation{Field: violation.Field, Message: violation.Description})
+			}
+		}
+	}
+
+	if typed == nil {
+		return foreignStatusError(st)
+	}
+
+	typed.Violations = violations
+
+	return typed
+}
+
+// foreignStatusError returns the typed error of a status without ErrorInfo
+func foreignStatusError(st *status.Status) *Error {
+	kind := kindFromGRPCCode(st.Code())
+
+	switch kind {
+	case KindInternal:
+		return ErrInternal.Wrap(st.Err())
+	case KindUnavailable:
+		return ErrUnavailable.Wrap(st.Err())
+	case KindCanceled:
+		return ErrCanceled.Wrap(st.Err())
+	case KindDeadlineExceeded:
+		return ErrDeadlineExceeded.Wrap(st.Err())
+	}
+
+	return NewError(kind, strings.ToUpper(kind.String()), st.Message())
+}
+
+func kindFromGRPCCode(code codes.Code) ErrorKind {
+	for kind, kindCode := range grpcCodes {
+		if kindCode == code && kind != KindUpstream {
+			return kind
+		}
+	}
+
+	return KindInternal
+}
@@ -0,0 +1,92 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorIs(t *testing.T) {
+	c := require.New(t)
+
+	_, numErr := strconv.Atoi("old")
+	err := fmt.Errorf("create user: %w", ErrBadAge.Wrap(numErr))
+
+	c.True(errors.Is(err, ErrBadAge))
+	c.False(errors.Is(err, ErrMissingUserID))
+	c.Equal("create user: age is not a number: "+numErr.Error(), err.Error())
+
+	var cause *strconv.NumError
+	c.True(errors.As(err, &cause))
+
+	c.Equal(KindInvalidArgument, KindOf(err))
+	c.Equal("age is not a number", SafeMessage(err))
+}
+
+func TestAsError(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(ErrUserNotFound, AsError(ErrUserNotFound))
+	c.Equal(KindCanceled, KindOf(context.Canceled))
+	c.Equal(KindDeadlineExceeded, KindOf(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+
+	err := AsError(errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	c.Equal(KindInternal, err.Kind)
+	c.Equal("INTERNAL", err.Code)
+	c.Equal("internal error", err.Message)
+	c.True(errors.Is(err, ErrInternal))
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	c := require.New(t)
+
+	for _, sentinel := range []*Error{ErrUserNotFound, ErrInvalidCredentials, ErrUserAlreadyExists, ErrMissingUserID, ErrBadPageToken, ErrBadReply} {
+		st := ToGRPCStatus(sentinel.Wrap(errors.New("internal cause")))
+		c.Equal(sentinel.Kind.GRPCCode(), st.Code())
+		c.Equal(sentinel.Message, st.Message())
+
+		err := FromGRPCError(st.Err())
+		c.True(errors.Is(err, sentinel), sentinel.Code)
+
+		var typed *Error
+		c.True(errors.As(err, &typed))
+		c.Equal(sentinel.Kind, typed.Kind)
+		c.Equal(sentinel.FieldViolations(), typed.FieldViolations())
+		c.Nil(typed.Cause)
+	}
+}
+
+func TestToGRPCStatusHidesUntypedErrors(t *testing.T) {
+	c := require.New(t)
+
+	st := ToGRPCStatus(errors.New("Error 1146: Table 'users' doesn't exist"))
+	c.Equal(codes.Internal, st.Code())
+	c.Equal("internal error", st.Message())
+	c.True(errors.Is(FromGRPCError(st.Err()), ErrInternal))
+
+	denied := status.New(codes.PermissionDenied, "denied")
+	c.Equal(denied, ToGRPCStatus(denied.Err()))
+}
+
+func TestFromGRPCErrorWithoutErrorInfo(t *testing.T) {
+	c := require.New(t)
+
+	c.Nil(FromGRPCError(nil))
+
+	plain := errors.New("plain")
+	c.Equal(plain, FromGRPCError(plain))
+
+	err := FromGRPCError(status.Error(codes.NotFound, "nothing here"))
+	c.Equal(KindNotFound, KindOf(err))
+	c.Equal("nothing here", SafeMessage(err))
+
+	err = FromGRPCError(status.Error(codes.Unavailable, "connection error: dial tcp 10.0.0.1:50051"))
+	c.True(errors.Is(err, ErrUnavailable))
+	c.Equal("service unavailable", SafeMessage(err))
+}
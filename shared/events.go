@@ -0,0 +1,121 @@
+package shared
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EventUserCreated is published when a user is created, its payload is the User without password
+	EventUserCreated = "user.created"
+	// EventUserUpdated is published when a user is updated, its payload is the User without password
+	EventUserUpdated = "user.updated"
+	// EventUserDeleted is published when a user is deleted, its payload is a UserDeletedPayload
+	EventUserDeleted = "user.deleted"
+	// EventUserRestored is published when a deleted user is restored, its payload is the User without password
+	EventUserRestored = "user.restored"
+	// EventUserAuthenticated is published when a user authenticates successfully, its payload is a UserAuthenticatedPayload
+	EventUserAuthenticated = "user.authenticated"
+)
+
+// UserEventTypes are the types of every domain event published for users
+var UserEventTypes = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventUserRestored,
+	EventUserAuthenticated,
+}
+
+// Event is a domain event describing a change to a user, its ID grows with every event
+type Event struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Actor       string          `json:"actor"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	// Cursor is only set on the events of a watch, it resumes the watch after the event
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// WatchCursor is the position of a watch, the events up to After are settled and Sent are the later events already sent
+type WatchCursor struct {
+	After int64
+	Sent  []int64
+}
+
+// String writes the cursor as After, followed by a colon and the sent IDs separated by commas when there are any
+func (c WatchCursor) String() string {
+	cursor := strconv.FormatInt(c.After, 10)
+	if len(c.Sent) == 0 {
+		return cursor
+	}
+
+	sent := make([]int64, len(c.Sent))
+	copy(sent, c.Sent)
+	sort.Slice(sent, func(i, j int) bool { return sent[i] < sent[j] })
+
+	ids := make([]string, len(sent))
+	for i, id := range sent {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+
+	return cursor + ":" + strings.Join(ids, ",")
+}
+
+// ParseWatchCursor reads a cursor written by WatchCursor.String, a plain event ID resumes after that event
+func ParseWatchCursor(cursor string) (WatchCursor, error) {
+	after, sent := cursor, ""
+	if i := strings.IndexByte(cursor, ':'); i >= 0 {
+		after, sent = cursor[:i], cursor[i+1:]
+		if sent == "" {
+			return WatchCursor{}, ErrBadCursor
+		}
+	}
+
+	id, err := strconv.ParseInt(after, 10, 64)
+	if err != nil || id < 0 {
+		return WatchCursor{}, ErrBadCursor
+	}
+
+	parsed := WatchCursor{After: id}
+	if sent == "" {
+		return parsed, nil
+	}
+
+	for _, field := range strings.Split(sent, ",") {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || id <= parsed.After {
+			return WatchCursor{}, ErrBadCursor
+		}
+
+		parsed.Sent = append(parsed.Sent, id)
+	}
+
+	return parsed, nil
+}
+
+// UserDeletedPayload is the payload of EventUserDeleted
+type UserDeletedPayload struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+// UserAuthenticatedPayload is the payload of EventUserAuthenticated
+type UserAuthenticatedPayload struct {
+	ID string `json:"id"`
+}
+
+// EventFilter selects the domain events of a change feed
+type EventFilter struct {
+	AfterID     int64
+	AggregateID string
+	Types       []string
+	Limit       int
+}
@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchCursor(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("12", WatchCursor{After: 12}.String())
+	c.Equal("12:14,15", WatchCursor{After: 12, Sent: []int64{15, 14}}.String())
+
+	cursor, err := ParseWatchCursor("12")
+	c.NoError(err)
+	c.Equal(WatchCursor{After: 12}, cursor)
+
+	cursor, err = ParseWatchCursor("12:14,15")
+	c.NoError(err)
+	c.Equal(WatchCursor{After: 12, Sent: []int64{14, 15}}, cursor)
+
+	for _, bad := range []string{"", "-1", "a", "12:", "12:a", "12:11", "12:14,,15"} {
+		_, err = ParseWatchCursor(bad)
+		c.Equal(ErrBadCursor, err, bad)
+	}
+
+	c.Empty(WatchCursorFormat()(""))
+	c.Empty(WatchCursorFormat()("12:14"))
+	c.Equal("must be a cursor of a previous event", WatchCursorFormat()("12:"))
+}
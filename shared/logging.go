@@ -0,0 +1,21 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextLogger returns the logger with the request ID and the trace ID carried by the context
+func ContextLogger(ctx context.Context, logger log.Logger) log.Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		logger = log.With(logger, "request_id", requestID)
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		logger = log.With(logger, "trace_id", spanContext.TraceID().String())
+	}
+
+	return logger
+}
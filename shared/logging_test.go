@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextLogger(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := log.NewJSONLogger(&buffer)
+
+	ContextLogger(context.Background(), logger).Log("msg", "no request")
+
+	var line map[string]interface{}
+	c.NoError(json.Unmarshal(buffer.Bytes(), &line))
+	c.Equal(map[string]interface{}{"msg": "no request"}, line)
+
+	traceID := trace.TraceID{1, 2, 3}
+	ctx := trace.ContextWithSpanContext(ContextWithRequestID(context.Background(), "REQ123"), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{4},
+	}))
+
+	buffer.Reset()
+	ContextLogger(ctx, logger).Log("msg", "request")
+
+	c.NoError(json.Unmarshal(buffer.Bytes(), &line))
+	c.Equal("REQ123", line["request_id"])
+	c.Equal(traceID.String(), line["trace_id"])
+}
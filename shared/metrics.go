@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// CodeOK is the code label of the requests that succeeded
+	CodeOK = "OK"
+	// MetricsPath is the path of the Prometheus metrics on the admin port
+	MetricsPath = "/metrics"
+)
+
+// RequestMetrics count the requests handled by a component and their errors, and observe their duration in seconds
+type RequestMetrics struct {
+	Requests metrics.Counter
+	Errors   metrics.Counter
+	Duration metrics.Histogram
+}
+
+// NewRequestMetrics registers the request metrics of a component in the default Prometheus registry
+func NewRequestMetrics(namespace string, subsystem string) RequestMetrics {
+	labels := []string{"method", "code"}
+
+	return RequestMetrics{
+		Requests: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests handled.",
+		}, labels),
+		Errors: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errors_total",
+			Help:      "Number of requests that failed.",
+		}, labels),
+		Duration: kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests in seconds.",
+			Buckets:   stdprometheus.DefBuckets,
+		}, labels),
+	}
+}
+
+// Observe records a request of the method that ended with code, failed tells whether it is counted as an error
+func (m RequestMetrics) Observe(method string, code string, failed bool, took time.Duration) {
+	m.Requests.With("method", method, "code", code).Add(1)
+	m.Duration.With("method", method, "code", code).Observe(took.Seconds())
+
+	if failed {
+		m.Errors.With("method", method, "code", code).Add(1)
+	}
+}
+
+// InstrumentingMiddleware records the calls of the endpoint of a method, labelled with the code of the typed error they return or OK
+func InstrumentingMiddleware(m RequestMetrics, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				code := CodeOK
+				if err != nil {
+					code = AsError(err).Code
+				}
+
+				m.Observe(method, code, err != nil, time.Since(begin))
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// NewAdminHandler serves the metrics of the default Prometheus registry, it is meant for a port that is not exposed publicly
+func NewAdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.Handler())
+
+	return mux
+}
@@ -0,0 +1,83 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// requestMetricsMock are request metrics that are not registered, so every test can have its own
+type requestMetricsMock struct {
+	RequestMetrics
+	requests *stdprometheus.CounterVec
+	errors   *stdprometheus.CounterVec
+	duration *stdprometheus.HistogramVec
+}
+
+func newRequestMetricsMock() requestMetricsMock {
+	labels := []string{"method", "code"}
+
+	m := requestMetricsMock{
+		requests: stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "requests_total"}, labels),
+		errors:   stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "errors_total"}, labels),
+		duration: stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "request_duration_seconds"}, labels),
+	}
+
+	m.RequestMetrics = RequestMetrics{
+		Requests: kitprometheus.NewCounter(m.requests),
+		Errors:   kitprometheus.NewCounter(m.errors),
+		Duration: kitprometheus.NewHistogram(m.duration),
+	}
+
+	return m
+}
+
+func TestInstrumentingMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	m := newRequestMetricsMock()
+
+	var fail error
+	endpoint := InstrumentingMiddleware(m.RequestMetrics, "GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return request, fail
+	})
+
+	response, err := endpoint(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal("USR123", response)
+
+	fail = ErrUserNotFound
+	_, err = endpoint(context.Background(), "USR404")
+	c.Equal(ErrUserNotFound, err)
+
+	fail = errors.New("connection refused")
+	_, err = endpoint(context.Background(), "USR500")
+	c.Error(err)
+
+	c.Equal(float64(1), testutil.ToFloat64(m.requests.WithLabelValues("GetUser", CodeOK)))
+	c.Equal(float64(1), testutil.ToFloat64(m.requests.WithLabelValues("GetUser", "USER_NOT_FOUND")))
+	c.Equal(float64(1), testutil.ToFloat64(m.requests.WithLabelValues("GetUser", "INTERNAL")))
+	c.Equal(float64(0), testutil.ToFloat64(m.errors.WithLabelValues("GetUser", CodeOK)))
+	c.Equal(float64(1), testutil.ToFloat64(m.errors.WithLabelValues("GetUser", "USER_NOT_FOUND")))
+	c.Equal(3, testutil.CollectAndCount(m.duration))
+}
+
+func TestNewAdminHandler(t *testing.T) {
+	c := require.New(t)
+
+	w := httptest.NewRecorder()
+	NewAdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	c.Equal(http.StatusOK, w.Code)
+	c.Contains(w.Body.String(), "go_goroutines")
+
+	w = httptest.NewRecorder()
+	NewAdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Equal(http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,79 @@
+package shared
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedForHeader is the HTTP header the reverse proxies append the address of their client to
+const ForwardedForHeader = "X-Forwarded-For"
+
+// TrustedProxies are the networks of the reverse proxies whose X-Forwarded-For header is trusted
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// ParseTrustedProxies parses the trusted networks written in CIDR notation, or as a single IP address
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return TrustedProxies{}, fmt.Errorf("the trusted proxy %q is not an IP address or a CIDR", entry)
+			}
+
+			proxies.networks = append(proxies.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return TrustedProxies{}, fmt.Errorf("the trusted proxy %q is not an IP address or a CIDR", entry)
+		}
+
+		proxies.networks = append(proxies.networks, network)
+	}
+
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusts(ip net.IP) bool {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the IP of the client that originated a request, read from X-Forwarded-For behind trusted proxies
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+
+	if !p.trusts(net.ParseIP(clientIP)) {
+		return clientIP
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			break
+		}
+
+		clientIP = hop
+		if !p.trusts(ip) {
+			break
+		}
+	}
+
+	return clientIP
+}
@@ -0,0 +1,56 @@
+package shared
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	c := require.New(t)
+
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	c.NoError(err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"direct client", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"forged by an untrusted client", "203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:51234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client prepending a forged address", "10.0.0.2:51234", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "192.168.1.1:51234", []string{"198.51.100.1, 10.0.0.3", "10.0.0.2"}, "198.51.100.1"},
+		{"trusted proxy without header", "10.0.0.2:51234", nil, "10.0.0.2"},
+		{"malformed hop", "10.0.0.2:51234", []string{"198.51.100.1, garbage"}, "10.0.0.2"},
+		{"only trusted hops", "10.0.0.2:51234", []string{"10.0.0.4, 10.0.0.3"}, "10.0.0.4"},
+		{"ipv6 proxy", "[fd00::1]:51234", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remoteAddr
+		for _, value := range tc.forwarded {
+			r.Header.Add(ForwardedForHeader, value)
+		}
+
+		c.Equal(tc.expected, proxies.ClientIP(r), tc.name)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:51234"
+	r.Header.Set(ForwardedForHeader, "198.51.100.1")
+	c.Equal("10.0.0.2", TrustedProxies{}.ClientIP(r), "no proxy is trusted by default")
+}
+
+func TestParseTrustedProxiesErrors(t *testing.T) {
+	c := require.New(t)
+
+	for _, entry := range []string{"proxy.internal", "10.0.0.0/33"} {
+		_, err := ParseTrustedProxies([]string{entry})
+		c.EqualError(err, `the trusted proxy "`+entry+`" is not an IP address or a CIDR`)
+	}
+}
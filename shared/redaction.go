@@ -0,0 +1,165 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// Redacted replaces the values that must not be logged
+const Redacted = "[REDACTED]"
+
+// SecretLogFields are the keys whose values are always redacted, at any depth of a logged value
+var SecretLogFields = []string{"password", "pwd_hash", "password_hash", "hash", "token", "secret", "authorization", "api_key"}
+
+// DefaultPIIFields are the personal information keys redacted when no others are configured
+var DefaultPIIFields = []string{"username", "name", "age", "date_of_birth", "additional_information", "parents"}
+
+// secretPatterns find the secrets embedded in logged strings and errors, e.g. in the message of a wrapped error
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`), Redacted},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[^\s"',]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)\b(password|pwd_hash|token|secret)(["']?\s*[:=]\s*["']?)[^\s"',&]+`), "${1}${2}" + Redacted},
+}
+
+// LogValuer is implemented by the types that are logged as a copy of themselves without their secrets
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// LogFields are the fields of a value returned by LogValue, the redacting logger redacts them by key
+type LogFields map[string]interface{}
+
+// MarshalJSON logs the fields as an object with the JSON logger
+func (f LogFields) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}(f))
+}
+
+// String logs the fields as a JSON object with the loggers that only support strings, e.g. logfmt
+func (f LogFields) String() string {
+	encoded, err := f.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(encoded)
+}
+
+// redactingLogger redacts the secret and PII values of every log line before passing it to the next logger
+type redactingLogger struct {
+	next   log.Logger
+	fields map[string]bool
+}
+
+// NewRedactingLogger returns a logger that redacts the secrets and the PII of a line before passing it to next
+func NewRedactingLogger(next log.Logger, piiFields []string) log.Logger {
+	fields := map[string]bool{}
+	for _, field := range append(append([]string{}, SecretLogFields...), piiFields...) {
+		fields[strings.ToLower(field)] = true
+	}
+
+	return &redactingLogger{next: next, fields: fields}
+}
+
+// Log redacts the key value pairs and logs them with the next logger
+func (l *redactingLogger) Log(keyvals ...interface{}) error {
+	redacted := make([]interface{}, len(keyvals))
+	copy(redacted, keyvals)
+
+	for i := 1; i < len(redacted); i += 2 {
+		redacted[i] = l.redact(fmt.Sprint(redacted[i-1]), redacted[i])
+	}
+
+	return l.next.Log(redacted...)
+}
+
+func (l *redactingLogger) redact(key string, value interface{}) interface{} {
+	if l.fields[strings.ToLower(key)] {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case LogValuer:
+		return l.redact(key, v.LogValue())
+	case LogFields:
+		fields := make(LogFields, len(v))
+		for field, fieldValue := range v {
+			fields[field] = l.redact(field, fieldValue)
+		}
+
+		return fields
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = l.redact(key, item)
+		}
+
+		return values
+	case error:
+		return RedactString(v.Error())
+	case string:
+		return RedactString(v)
+	}
+
+	return value
+}
+
+// RedactString replaces the bcrypt hashes, bearer tokens and password, token and secret assignments found in s
+func RedactString(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+
+	return s
+}
+
+// LogValue logs the user without its password, the PII fields are redacted by the redacting logger
+func (u User) LogValue() interface{} {
+	fields := LogFields{
+		"id":                     u.ID,
+		"name":                   u.Name,
+		"age":                    u.Age,
+		"additional_information": u.AdditionalInformation,
+		"created_at":             u.CreatedAt,
+		"updated_at":             u.UpdatedAt,
+		"created_by":             u.CreatedBy,
+		"updated_by":             u.UpdatedBy,
+	}
+
+	if u.Password != "" {
+		fields["password"] = Redacted
+	}
+
+	if u.DateOfBirth != nil {
+		fields["date_of_birth"] = u.DateOfBirth.String()
+	}
+
+	if len(u.Parents) > 0 {
+		fields["parents"] = u.Parents
+	}
+
+	return fields
+}
+
+// LogValue logs the subscription without its secret
+func (s WebhookSubscription) LogValue() interface{} {
+	fields := LogFields{
+		"id":          s.ID,
+		"url":         s.URL,
+		"event_types": s.EventTypes,
+		"created_at":  s.CreatedAt,
+		"created_by":  s.CreatedBy,
+	}
+
+	if s.Secret != "" {
+		fields["secret"] = Redacted
+	}
+
+	return fields
+}
@@ -0,0 +1,94 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/require"
+)
+
+// sensitiveValues are the values that must never reach the log output
+var sensitiveValues = []string{
+	"hunter2",
+	"$2a$14$ajq8Q7fbtFRQvXpdCq7Jcuy.Rx1h/L4J60Otx.gyNLbAYctGMJ9tK",
+	"tok-3f9a",
+	"whsec-81c2",
+	"likes long walks",
+	"1990-06-05",
+	"Jane Doe",
+}
+
+func TestRedactingLoggerJSON(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := NewRedactingLogger(log.NewJSONLogger(&buffer), DefaultPIIFields)
+	logger = log.With(logger, "token", "tok-3f9a")
+
+	dateOfBirth := NewDate(time.Date(1990, time.June, 5, 0, 0, 0, 0, time.UTC))
+	user := User{
+		ID:                    "USR123",
+		Password:              "hunter2",
+		Name:                  "Jane Doe",
+		DateOfBirth:           &dateOfBirth,
+		AdditionalInformation: "likes long walks",
+	}
+
+	level.Error(logger).Log(
+		"user", user,
+		"subscription", WebhookSubscription{ID: "WHS123", URL: "https://example.com/hook", Secret: "whsec-81c2"},
+		"err", ErrInternal.Wrap(errors.New("password=hunter2 rejected, hash $2a$14$ajq8Q7fbtFRQvXpdCq7Jcuy.Rx1h/L4J60Otx.gyNLbAYctGMJ9tK")),
+		"header", "Authorization: Bearer tok-3f9a",
+		"pwd_hash", "$2a$14$ajq8Q7fbtFRQvXpdCq7Jcuy.Rx1h/L4J60Otx.gyNLbAYctGMJ9tK",
+	)
+
+	output := buffer.String()
+	for _, value := range sensitiveValues {
+		c.NotContains(output, value)
+	}
+
+	var line map[string]interface{}
+	c.NoError(json.Unmarshal(buffer.Bytes(), &line))
+	c.Equal(Redacted, line["token"])
+	c.Equal("Authorization: Bearer "+Redacted, line["header"])
+
+	logged := line["user"].(map[string]interface{})
+	c.Equal("USR123", logged["id"])
+	c.Equal(Redacted, logged["password"])
+	c.Equal(Redacted, logged["name"])
+	c.Equal(Redacted, logged["date_of_birth"])
+
+	subscription := line["subscription"].(map[string]interface{})
+	c.Equal("https://example.com/hook", subscription["url"])
+	c.Equal(Redacted, subscription["secret"])
+}
+
+func TestRedactingLoggerLogfmt(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := NewRedactingLogger(log.NewLogfmtLogger(&buffer), []string{"additional_information"})
+
+	logger.Log("user", User{ID: "USR123", Password: "hunter2", Name: "Jane Doe", AdditionalInformation: "likes long walks"}, "Password", "hunter2")
+
+	output := buffer.String()
+	c.NotContains(output, "hunter2")
+	c.NotContains(output, "likes long walks")
+	c.Contains(output, "Jane Doe", "name is only redacted when it is a configured PII field")
+	c.Contains(output, "Password="+Redacted)
+}
+
+func TestRedactString(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("nothing to hide", RedactString("nothing to hide"))
+	c.Equal(`{"password":"`+Redacted+`","name":"admin"}`, RedactString(`{"password":"hunter2","name":"admin"}`))
+	c.Equal("bearer "+Redacted+", secret: "+Redacted, RedactString("bearer tok-3f9a, secret: whsec-81c2"))
+	c.Equal(fmt.Sprintf("hash %s", Redacted), RedactString("hash $2a$14$ajq8Q7fbtFRQvXpdCq7Jcuy.Rx1h/L4J60Otx.gyNLbAYctGMJ9tK"))
+}
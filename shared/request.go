@@ -0,0 +1,80 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request ID
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the gRPC metadata key carrying the request ID
+	RequestIDMetadataKey = "x-request-id"
+	// ClientIPMetadataKey is the gRPC metadata key carrying the IP of the client that originated a request
+	ClientIPMetadataKey = "x-client-ip"
+	// AuthorizationMetadataKey is the gRPC metadata key carrying the bearer token a service authenticates with
+	AuthorizationMetadataKey = "authorization"
+	// BearerPrefix is the scheme prefix of the authorization metadata
+	BearerPrefix = "Bearer "
+	// maxRequestIDLength is the length of the longest request ID accepted from a caller
+	maxRequestIDLength = 128
+)
+
+type requestIDContextKey struct{}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+
+	return hex.EncodeToString(buffer)
+}
+
+// RequestIDOrNew returns the request ID sent by a caller, or a new one when it is missing or invalid
+func RequestIDOrNew(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return NewRequestID()
+	}
+
+	for _, r := range requestID {
+		valid := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':'
+		if !valid {
+			return NewRequestID()
+		}
+	}
+
+	return requestID
+}
+
+type clientIPContextKey struct{}
+
+// ContextWithRequestID returns a copy of the context carrying the given request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// ContextWithClientIP returns a copy of the context carrying the given client IP
+func ContextWithClientIP(ctx context.Context, clientIP string) context.Context {
+	if clientIP == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, clientIPContextKey{}, clientIP)
+}
+
+// ClientIPFromContext returns the client IP carried by the context, if any
+func ClientIPFromContext(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPContextKey{}).(string)
+	return clientIP
+}
@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	c := require.New(t)
+
+	c.Empty(RequestIDFromContext(context.Background()))
+	c.Equal("REQ123", RequestIDFromContext(ContextWithRequestID(context.Background(), "REQ123")))
+}
+
+func TestRequestIDOrNew(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("REQ123", RequestIDOrNew("REQ123"))
+	c.Equal("6f1c2d3e-aa00-4b8e-9d1f-123456789abc", RequestIDOrNew("6f1c2d3e-aa00-4b8e-9d1f-123456789abc"))
+
+	for _, invalid := range []string{"", "REQ 123", "REQ123\nlevel=error", strings.Repeat("a", 129)} {
+		requestID := RequestIDOrNew(invalid)
+		c.Len(requestID, 32)
+		c.NotEqual(invalid, requestID)
+	}
+
+	c.NotEqual(NewRequestID(), NewRequestID())
+}
+
+func TestClientIPFromContext(t *testing.T) {
+	c := require.New(t)
+
+	c.Empty(ClientIPFromContext(context.Background()))
+	c.Empty(ClientIPFromContext(ContextWithClientIP(context.Background(), "")))
+	c.Equal("10.0.0.1", ClientIPFromContext(ContextWithClientIP(context.Background(), "10.0.0.1")))
+}
@@ -0,0 +1,186 @@
+package shared
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
+)
+
+// ShutdownStep is one step of a graceful shutdown, run within Timeout when it is set
+type ShutdownStep struct {
+	Name    string
+	Run     func(ctx context.Context) error
+	Timeout time.Duration
+}
+
+// Shutdown runs the steps in order within timeout and returns the error of the first failing one
+func Shutdown(logger log.Logger, timeout time.Duration, steps ...ShutdownStep) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var firstErr error
+	for _, step := range steps {
+		begin := time.Now()
+
+		err := runShutdownStep(ctx, step)
+		if err != nil {
+			level.Error(logger).Log("shutdown_step", step.Name, "took", time.Since(begin), "err", err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		level.Info(logger).Log("shutdown_step", step.Name, "took", time.Since(begin))
+	}
+
+	return firstErr
+}
+
+func runShutdownStep(ctx context.Context, step ShutdownStep) error {
+	if step.Timeout <= 0 {
+		return step.Run(ctx)
+	}
+
+	stepCtx, cancel := context.WithTimeout(context.Background(), step.Timeout)
+	defer cancel()
+
+	return step.Run(stepCtx)
+}
+
+// DelayStep waits for delay, e.g. so the load balancers notice the failing readiness probe before the servers stop accepting
+func DelayStep(delay time.Duration) ShutdownStep {
+	return ShutdownStep{
+		Name: "delay",
+		Run: func(ctx context.Context) error {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// StopHTTPServer stops accepting connections and waits for the pending requests until ctx is done
+func StopHTTPServer(ctx context.Context, server *http.Server) error {
+	err := server.Shutdown(ctx)
+	if err != nil {
+		server.Close()
+	}
+
+	return err
+}
+
+// StopGRPCServer stops accepting connections and waits for the pending calls until ctx is done
+func StopGRPCServer(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+
+		return ctx.Err()
+	}
+}
+
+// WaitGroup waits for the goroutines of wg, e.g. the background jobs once their context is canceled, until ctx is done
+func WaitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Drain tells the readiness probe that the process is shutting down, the zero value is not draining
+type Drain struct {
+	draining int32
+}
+
+// Start marks the process as draining
+func (d *Drain) Start() {
+	atomic.StoreInt32(&d.draining, 1)
+}
+
+// Draining reports whether Start was called
+func (d *Drain) Draining() bool {
+	return atomic.LoadInt32(&d.draining) == 1
+}
+
+// Streams ends the long-lived streams, like the event streams, when the servers stop
+type Streams struct {
+	init   sync.Once
+	cancel sync.Once
+	done   chan struct{}
+}
+
+func (s *Streams) canceled() chan struct{} {
+	s.init.Do(func() {
+		s.done = make(chan struct{})
+	})
+
+	return s.done
+}
+
+// Context returns a copy of ctx that is canceled when Cancel is called, the stream must be served with it
+func (s *Streams) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	canceled := s.canceled()
+	go func() {
+		select {
+		case <-canceled:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// Cancel cancels the contexts of the open streams and of the ones started afterwards
+func (s *Streams) Cancel() {
+	s.cancel.Do(func() {
+		close(s.canceled())
+	})
+}
+
+// StreamsMiddleware serves a streaming endpoint with a context canceled by streams
+func StreamsMiddleware(streams *Streams) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ctx, cancel := streams.Context(ctx)
+			defer cancel()
+
+			return next(ctx, request)
+		}
+	}
+}
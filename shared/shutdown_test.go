@@ -0,0 +1,205 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestShutdown(t *testing.T) {
+	c := require.New(t)
+
+	errFirst := errors.New("first")
+
+	var ran []string
+	step := func(name string, err error) ShutdownStep {
+		return ShutdownStep{Name: name, Run: func(ctx context.Context) error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+
+	err := Shutdown(log.NewNopLogger(), time.Second,
+		step("servers", nil),
+		step("outbox", errFirst),
+		step("database", errors.New("second")),
+	)
+	c.Equal(errFirst, err)
+	c.Equal([]string{"servers", "outbox", "database"}, ran)
+
+	err = Shutdown(log.NewNopLogger(), 10*time.Millisecond, DelayStep(time.Minute))
+	c.Equal(context.DeadlineExceeded, err)
+}
+
+func TestShutdownStepTimeout(t *testing.T) {
+	c := require.New(t)
+
+	flushed := false
+	err := Shutdown(log.NewNopLogger(), 10*time.Millisecond,
+		DelayStep(time.Minute),
+		ShutdownStep{Name: "outbox", Timeout: time.Second, Run: func(ctx context.Context) error {
+			flushed = ctx.Err() == nil
+			return nil
+		}},
+	)
+	c.Equal(context.DeadlineExceeded, err)
+	c.True(flushed, "the step runs within its own timeout once the shared deadline passed")
+}
+
+func TestStopHTTPServer(t *testing.T) {
+	c := require.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.NoError(err)
+
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		} else {
+			<-release
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	go server.Serve(listener)
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		res.Body.Close()
+		status <- res.StatusCode
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	c.NoError(StopHTTPServer(context.Background(), server))
+	c.Equal(http.StatusNoContent, <-status, "the pending request is drained")
+
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	c.NoError(err)
+
+	server = &http.Server{Handler: server.Handler}
+	go server.Serve(listener)
+	defer close(release)
+
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c.Equal(context.DeadlineExceeded, StopHTTPServer(ctx, server))
+}
+
+func TestStopGRPCServer(t *testing.T) {
+	c := require.New(t)
+
+	start := func() (*grpc.Server, *grpc.ClientConn) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		c.NoError(err)
+
+		server := grpc.NewServer()
+		healthpb.RegisterHealthServer(server, health.NewServer())
+		go server.Serve(listener)
+
+		conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+		c.NoError(err)
+
+		return server, conn
+	}
+
+	server, conn := start()
+	_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	c.NoError(err)
+	c.NoError(StopGRPCServer(context.Background(), server))
+	conn.Close()
+
+	server, conn = start()
+	defer conn.Close()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	c.NoError(err)
+	_, err = stream.Recv()
+	c.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c.Equal(context.DeadlineExceeded, StopGRPCServer(ctx, server), "the open stream is canceled at the deadline")
+}
+
+func TestWaitGroup(t *testing.T) {
+	c := require.New(t)
+
+	var wg sync.WaitGroup
+	c.NoError(WaitGroup(context.Background(), &wg))
+
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Equal(context.DeadlineExceeded, WaitGroup(ctx, &wg))
+
+	wg.Done()
+	c.NoError(WaitGroup(context.Background(), &wg))
+}
+
+func TestDrain(t *testing.T) {
+	c := require.New(t)
+
+	var drain Drain
+	c.False(drain.Draining())
+
+	drain.Start()
+	c.True(drain.Draining())
+}
+
+func TestStreams(t *testing.T) {
+	c := require.New(t)
+
+	var streams Streams
+
+	open, cancel := streams.Context(context.Background())
+	defer cancel()
+
+	ended, endedCancel := streams.Context(context.Background())
+	endedCancel()
+	c.Error(ended.Err())
+
+	c.NoError(open.Err())
+
+	streams.Cancel()
+	streams.Cancel()
+	<-open.Done()
+	c.Equal(context.Canceled, open.Err())
+
+	late, lateCancel := streams.Context(context.Background())
+	defer lateCancel()
+	<-late.Done()
+}
+
+func TestStreamsMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	var streams Streams
+
+	watch := StreamsMiddleware(&streams)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		streams.Cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, err := watch(context.Background(), nil)
+	c.Equal(context.Canceled, err)
+}
@@ -0,0 +1,274 @@
+package shared
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTLSCertificateRequired is returned when a TLS server is configured without a certificate and key
+	ErrTLSCertificateRequired = errors.New("a TLS certificate and key are required")
+	// ErrNoPeerCertificate is returned when the peer of a connection does not present a certificate
+	ErrNoPeerCertificate = errors.New("the peer did not present a certificate")
+	// ErrSANNotAllowed is returned when the certificate of the peer has none of the allowed subject alternative names
+	ErrSANNotAllowed = errors.New("the peer certificate has no allowed subject alternative name")
+	// ErrTLSServerNameRequired is returned when a server dialed by IP address can not be verified because no server name is configured
+	ErrTLSServerNameRequired = errors.New("a TLS server name is required to verify a server dialed by IP address")
+)
+
+// TLSOptions are the files and peer restrictions of one side of a TLS connection
+type TLSOptions struct {
+	// CertFile and KeyFile are the PEM certificate and key presented to the peer
+	CertFile string
+	KeyFile  string
+	// CAFile is the PEM bundle the certificate of the peer is verified against
+	CAFile string
+	// AllowedSANs restricts the peers to the ones whose certificate has one of these SANs, any peer when empty
+	AllowedSANs []string
+	// ServerName is the name the certificate of the server is verified against, a client defaults to the dialed host
+	ServerName string
+}
+
+// NewServerTLSConfig returns the TLS configuration of a server presenting CertFile
+func NewServerTLSConfig(options TLSOptions) (*tls.Config, error) {
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, ErrTLSCertificateRequired
+	}
+
+	reloader, err := newCertificateReloader(options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certificate, _ := reloader.current()
+			return certificate, nil
+		},
+	}
+
+	if options.CAFile != "" {
+		// the client certificate is verified by VerifyConnection, so the CA bundle can be reloaded
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return reloader.verify(state.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+
+	return config, nil
+}
+
+// NewClientTLSConfig returns the TLS configuration of a client verifying the server against CAFile
+func NewClientTLSConfig(options TLSOptions) (*tls.Config, error) {
+	reloader, err := newCertificateReloader(options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: options.ServerName,
+		// the server certificate is verified by VerifyConnection, so the CA bundle can be reloaded
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			serverName := options.ServerName
+			if serverName == "" {
+				serverName = state.ServerName
+			}
+
+			if serverName == "" {
+				return ErrTLSServerNameRequired
+			}
+
+			return reloader.verify(state.PeerCertificates, serverName, x509.ExtKeyUsageServerAuth)
+		},
+	}
+
+	if options.CertFile != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certificate, _ := reloader.current()
+			return certificate, nil
+		}
+	}
+
+	return config, nil
+}
+
+// certificateReloader keeps the certificate and CA bundle of a TLSOptions in memory and loads them again when their files change
+type certificateReloader struct {
+	options TLSOptions
+
+	mu          sync.Mutex
+	modTimes    map[string]time.Time
+	certificate *tls.Certificate
+	roots       *x509.CertPool
+}
+
+func newCertificateReloader(options TLSOptions) (*certificateReloader, error) {
+	reloader := &certificateReloader{options: options}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// current returns the certificate and CA bundle, reloading them first when a file changed
+func (r *certificateReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.changed() {
+		r.load()
+	}
+
+	return r.certificate, r.roots
+}
+
+// verify checks the certificate chain presented by the peer against the CA bundle and the allowed SANs
+func (r *certificateReloader) verify(chain []*x509.Certificate, serverName string, usage x509.ExtKeyUsage) error {
+	if len(chain) == 0 {
+		return ErrNoPeerCertificate
+	}
+
+	_, roots := r.current()
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	if err != nil {
+		return err
+	}
+
+	if !sanAllowed(chain[0], r.options.AllowedSANs) {
+		return fmt.Errorf("%w: %s", ErrSANNotAllowed, strings.Join(subjectAltNames(chain[0]), ", "))
+	}
+
+	return nil
+}
+
+// files returns the files of the options that are set
+func (r *certificateReloader) files() []string {
+	files := []string{}
+	for _, file := range []string{r.options.CertFile, r.options.KeyFile, r.options.CAFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// changed reports whether the modification time of a file is not the one it had when it was loaded
+func (r *certificateReloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// load reads the files, nothing is replaced unless all of them are valid
+func (r *certificateReloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		modTimes[file] = info.ModTime()
+	}
+
+	var certificate *tls.Certificate
+	if r.options.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+		if err != nil {
+			return err
+		}
+
+		certificate = &pair
+	}
+
+	var roots *x509.CertPool
+	if r.options.CAFile != "" {
+		bundle, err := os.ReadFile(r.options.CAFile)
+		if err != nil {
+			return err
+		}
+
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(bundle) {
+			return fmt.Errorf("no certificates found in %s", r.options.CAFile)
+		}
+	}
+
+	r.modTimes = modTimes
+	r.certificate = certificate
+	r.roots = roots
+
+	return nil
+}
+
+// subjectAltNames returns every subject alternative name of the certificate
+func subjectAltNames(certificate *x509.Certificate) []string {
+	names := append([]string{}, certificate.DNSNames...)
+	names = append(names, certificate.EmailAddresses...)
+
+	for _, ip := range certificate.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	for _, uri := range certificate.URIs {
+		names = append(names, uri.String())
+	}
+
+	return names
+}
+
+// sanAllowed reports whether the certificate has one of the allowed SANs, any certificate is allowed when there are none
+func sanAllowed(certificate *x509.Certificate, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, name := range subjectAltNames(certificate) {
+		for _, allowedName := range allowed {
+			if name == allowedName || sameIP(name, allowedName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sameIP reports whether both names are the same IP address written differently
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+
+	return ipA != nil && ipB != nil && ipA.Equal(ipB)
+}
@@ -0,0 +1,248 @@
+package shared
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues the certificates of the TLS tests
+type testCA struct {
+	t           *testing.T
+	dir         string
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	serial      int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	ca := &testCA{t: t, dir: t.TempDir(), certificate: certificate, key: key, serial: 1}
+	writePEM(t, ca.path("ca.pem"), "CERTIFICATE", der)
+
+	return ca
+}
+
+func (ca *testCA) path(name string) string {
+	return filepath.Join(ca.dir, name)
+}
+
+// issue writes a certificate and key for the DNS name to name.pem and name-key.pem, returning the serial number
+func (ca *testCA) issue(name string, dnsName string, usage x509.ExtKeyUsage) int64 {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ca.t, err)
+
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(ca.t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(ca.t, err)
+
+	writePEM(ca.t, ca.path(name+"-key.pem"), "EC PRIVATE KEY", keyDER)
+	writePEM(ca.t, ca.path(name+".pem"), "CERTIFICATE", der)
+
+	return ca.serial
+}
+
+func (ca *testCA) options(name string) TLSOptions {
+	return TLSOptions{CertFile: ca.path(name + ".pem"), KeyFile: ca.path(name + "-key.pem"), CAFile: ca.path("ca.pem")}
+}
+
+// writePEM writes the file and moves its modification time forward, so a rewrite is always noticed
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil && !info.ModTime().Before(modTime) {
+		modTime = info.ModTime().Add(time.Second)
+	}
+
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// handshake runs a TLS handshake over loopback, returning the errors of both sides and the state seen by the client,
+// with TLS 1.3 a rejected client certificate is only reported by the server
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (error, error, tls.ConnectionState) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+
+		server := tls.Server(conn, serverConfig)
+		serverErr <- server.Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	client := tls.Client(conn, clientConfig)
+	clientErr := client.Handshake()
+	state := client.ConnectionState()
+	client.Close()
+
+	return clientErr, <-serverErr, state
+}
+
+func TestMutualTLS(t *testing.T) {
+	c := require.New(t)
+
+	ca := newTestCA(t)
+	ca.issue("server", "user-service", x509.ExtKeyUsageServerAuth)
+	ca.issue("gateway", "http-gateway", x509.ExtKeyUsageClientAuth)
+	ca.issue("intruder", "intruder", x509.ExtKeyUsageClientAuth)
+
+	serverOptions := ca.options("server")
+	serverOptions.AllowedSANs = []string{"http-gateway"}
+
+	serverConfig, err := NewServerTLSConfig(serverOptions)
+	c.NoError(err)
+
+	clientOptions := ca.options("gateway")
+	clientOptions.ServerName = "user-service"
+	clientOptions.AllowedSANs = []string{"user-service"}
+
+	clientConfig, err := NewClientTLSConfig(clientOptions)
+	c.NoError(err)
+
+	clientErr, serverErr, state := handshake(t, serverConfig, clientConfig)
+	c.NoError(clientErr)
+	c.NoError(serverErr)
+	c.Equal("user-service", state.PeerCertificates[0].Subject.CommonName)
+
+	intruderOptions := ca.options("intruder")
+	intruderOptions.ServerName = "user-service"
+
+	intruderConfig, err := NewClientTLSConfig(intruderOptions)
+	c.NoError(err)
+
+	_, serverErr, _ = handshake(t, serverConfig, intruderConfig)
+	c.True(errors.Is(serverErr, ErrSANNotAllowed))
+
+	anonymousConfig, err := NewClientTLSConfig(TLSOptions{CAFile: ca.path("ca.pem"), ServerName: "user-service"})
+	c.NoError(err)
+
+	_, serverErr, _ = handshake(t, serverConfig, anonymousConfig)
+	c.Error(serverErr)
+
+	clientOptions.ServerName = "other-service"
+	clientConfig, err = NewClientTLSConfig(clientOptions)
+	c.NoError(err)
+
+	clientErr, _, _ = handshake(t, serverConfig, clientConfig)
+	c.Error(clientErr)
+}
+
+func TestClientTLSRequiresServerNameForIPAddresses(t *testing.T) {
+	c := require.New(t)
+
+	ca := newTestCA(t)
+	ca.issue("server", "user-service", x509.ExtKeyUsageServerAuth)
+
+	serverConfig, err := NewServerTLSConfig(ca.options("server"))
+	c.NoError(err)
+
+	clientConfig, err := NewClientTLSConfig(TLSOptions{CAFile: ca.path("ca.pem")})
+	c.NoError(err)
+
+	clientErr, _, _ := handshake(t, serverConfig, clientConfig)
+	c.True(errors.Is(clientErr, ErrTLSServerNameRequired))
+
+	clientConfig, err = NewClientTLSConfig(TLSOptions{CAFile: ca.path("ca.pem"), ServerName: "127.0.0.1"})
+	c.NoError(err)
+
+	clientErr, _, _ = handshake(t, serverConfig, clientConfig)
+	c.NoError(clientErr)
+}
+
+func TestTLSCertificatesAreReloaded(t *testing.T) {
+	c := require.New(t)
+
+	ca := newTestCA(t)
+	firstSerial := ca.issue("server", "user-service", x509.ExtKeyUsageServerAuth)
+
+	serverConfig, err := NewServerTLSConfig(ca.options("server"))
+	c.NoError(err)
+
+	clientConfig, err := NewClientTLSConfig(TLSOptions{CAFile: ca.path("ca.pem"), ServerName: "user-service"})
+	c.NoError(err)
+
+	clientErr, _, state := handshake(t, serverConfig, clientConfig)
+	c.NoError(clientErr)
+	c.Equal(firstSerial, state.PeerCertificates[0].SerialNumber.Int64())
+
+	secondSerial := ca.issue("server", "user-service", x509.ExtKeyUsageServerAuth)
+
+	clientErr, _, state = handshake(t, serverConfig, clientConfig)
+	c.NoError(clientErr)
+	c.Equal(secondSerial, state.PeerCertificates[0].SerialNumber.Int64())
+
+	writePEM(t, ca.path("server.pem"), "CERTIFICATE", []byte("half written"))
+
+	clientErr, _, state = handshake(t, serverConfig, clientConfig)
+	c.NoError(clientErr)
+	c.Equal(secondSerial, state.PeerCertificates[0].SerialNumber.Int64())
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	c := require.New(t)
+
+	_, err := NewServerTLSConfig(TLSOptions{CAFile: "ca.pem"})
+	c.Equal(ErrTLSCertificateRequired, err)
+
+	_, err = NewServerTLSConfig(TLSOptions{CertFile: "missing.pem", KeyFile: "missing-key.pem"})
+	c.True(errors.Is(err, os.ErrNotExist))
+
+	_, err = NewClientTLSConfig(TLSOptions{CAFile: "missing.pem"})
+	c.True(errors.Is(err, os.ErrNotExist))
+}
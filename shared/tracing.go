@@ -0,0 +1,137 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-kit/kit/endpoint"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer of the spans created by this module
+const TracerName = "github.com/jumaroar-globant/go-bootcamp"
+
+// Trace exporters
+const (
+	// NoTraceExporter disables the export of the spans, the trace context is still propagated
+	NoTraceExporter = "none"
+	// OTLPTraceExporter sends the spans to an OTLP collector over gRPC
+	OTLPTraceExporter = "otlp"
+	// StdoutTraceExporter writes the spans to the standard output, for local use
+	StdoutTraceExporter = "stdout"
+	// FileTraceExporter appends the spans to a file, for local use
+	FileTraceExporter = "file"
+)
+
+// TracingOptions configure how the spans of a service are exported
+type TracingOptions struct {
+	ServiceName string
+	// Exporter is one of the trace exporters, NoTraceExporter when empty
+	Exporter string
+	// Endpoint is the host:port of the OTLP collector
+	Endpoint string
+	// Insecure sends the spans to the OTLP collector without TLS
+	Insecure bool
+	// FilePath is the file of FileTraceExporter
+	FilePath string
+	// SampleRatio is the fraction of the traces started by the service that are sampled
+	SampleRatio float64
+}
+
+// SetupTracing installs the global tracer provider and returns the function flushing its pending spans
+func SetupTracing(ctx context.Context, options TracingOptions) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newTraceExporter(ctx, options)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(options.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newTraceExporter(ctx context.Context, options TracingOptions) (sdktrace.SpanExporter, error) {
+	switch options.Exporter {
+	case "", NoTraceExporter:
+		return nil, nil
+	case OTLPTraceExporter:
+		otlpOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			otlpOptions = append(otlpOptions, otlptracegrpc.WithInsecure())
+		}
+
+		return otlptracegrpc.New(ctx, otlpOptions...)
+	case StdoutTraceExporter:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case FileTraceExporter:
+		file, err := os.OpenFile(options.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		return newFileTraceExporter(file)
+	}
+
+	return nil, fmt.Errorf("unknown trace exporter %q", options.Exporter)
+}
+
+// fileTraceExporter is a stdout exporter writing to a file, the file is closed on shutdown
+type fileTraceExporter struct {
+	*stdouttrace.Exporter
+	file io.Closer
+}
+
+func newFileTraceExporter(file io.WriteCloser) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return fileTraceExporter{Exporter: exporter, file: file}, nil
+}
+
+// Shutdown flushes the exporter and closes the file
+func (e fileTraceExporter) Shutdown(ctx context.Context) error {
+	if err := e.Exporter.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return e.file.Close()
+}
+
+// TracingMiddleware wraps the endpoint of a method in a span, the typed error it returns is recorded on the span
+func TracingMiddleware(method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			ctx, span := otel.Tracer(TracerName).Start(ctx, "endpoint."+method, trace.WithSpanKind(trace.SpanKindInternal))
+			defer func() {
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, AsError(err).Code)
+				}
+
+				span.End()
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
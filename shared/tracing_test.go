@@ -0,0 +1,81 @@
+package shared
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// withSpanRecorder installs a tracer provider recording the spans for the duration of the test
+func withSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	recorder := withSpanRecorder(t)
+
+	var fail error
+	endpoint := TracingMiddleware("GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return request, fail
+	})
+
+	_, err := endpoint(context.Background(), "USR123")
+	c.NoError(err)
+
+	fail = ErrUserNotFound
+	_, err = endpoint(context.Background(), "USR404")
+	c.Equal(ErrUserNotFound, err)
+
+	spans := recorder.Ended()
+	c.Len(spans, 2)
+	c.Equal("endpoint.GetUser", spans[0].Name())
+	c.Equal(codes.Unset, spans[0].Status().Code)
+	c.Equal(codes.Error, spans[1].Status().Code)
+	c.Equal("USER_NOT_FOUND", spans[1].Status().Description)
+	c.Len(spans[1].Events(), 1)
+}
+
+func TestSetupTracing(t *testing.T) {
+	c := require.New(t)
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	shutdown, err := SetupTracing(context.Background(), TracingOptions{Exporter: NoTraceExporter})
+	c.NoError(err)
+	c.NoError(shutdown(context.Background()))
+
+	_, err = SetupTracing(context.Background(), TracingOptions{Exporter: "zipkin"})
+	c.Error(err)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err = SetupTracing(context.Background(), TracingOptions{ServiceName: "user", Exporter: FileTraceExporter, FilePath: path, SampleRatio: 1})
+	c.NoError(err)
+
+	_, span := otel.Tracer(TracerName).Start(context.Background(), "endpoint.GetUser")
+	span.End()
+	c.NoError(shutdown(context.Background()))
+
+	traces, err := os.ReadFile(path)
+	c.NoError(err)
+	c.Contains(string(traces), "endpoint.GetUser")
+	c.Contains(string(traces), `{"Key":"service.name","Value":{"Type":"STRING","Value":"user"}}`)
+}
@@ -1,13 +1,37 @@
 package shared
 
-// User is the user type
+import "time"
+
+// User is the user type, DateOfBirthEstimated is set when the date of birth was estimated from the legacy age
 type User struct {
-	ID                    string   `json:"id,omitempty"`
-	Password              string   `json:"password,omitempty"`
-	Name                  string   `json:"name,omitempty"`
-	Age                   int      `json:"age,omitempty"`
-	AdditionalInformation string   `json:"additional_information,omitempty"`
-	Parents               []string `json:"parents,omitempty"`
+	ID                    string    `json:"id,omitempty"`
+	Password              string    `json:"password,omitempty"`
+	Name                  string    `json:"name,omitempty"`
+	Age                   int       `json:"age,omitempty"`
+	DateOfBirth           *Date     `json:"date_of_birth,omitempty"`
+	DateOfBirthEstimated  bool      `json:"date_of_birth_estimated,omitempty"`
+	AdditionalInformation string    `json:"additional_information,omitempty"`
+	Parents               []string  `json:"parents,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	CreatedBy             string    `json:"created_by,omitempty"`
+	UpdatedBy             string    `json:"updated_by,omitempty"`
+}
+
+// Fields of a user that can be listed in the update mask of a partial update
+const (
+	UserPathName                  = "name"
+	UserPathDateOfBirth           = "date_of_birth"
+	UserPathAdditionalInformation = "additional_information"
+	UserPathParents               = "parents"
+)
+
+// UserUpdatePaths are the fields of a user that can be updated partially
+var UserUpdatePaths = []string{
+	UserPathName,
+	UserPathDateOfBirth,
+	UserPathAdditionalInformation,
+	UserPathParents,
 }
 
 // Parent is the parent type
@@ -15,3 +39,22 @@ type Parent struct {
 	UserID string
 	Name   string
 }
+
+// UserFilter is the set of optional filters used to list users
+type UserFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	CreatedBy     string
+	UpdatedBy     string
+	IDs           []string
+	PageSize      int
+	PageToken     string
+}
+
+// UserPage is a page of users and the token to request the next one
+type UserPage struct {
+	Users         []User `json:"users"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
@@ -0,0 +1,247 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Bounds of the ages accepted by the validation rules
+const (
+	MinAge = 0
+	MaxAge = 150
+)
+
+// ErrInvalidRequest is returned when a request breaks one or more validation rules, it carries every violation
+var ErrInvalidRequest = NewError(KindInvalidArgument, "INVALID_REQUEST", "invalid request")
+
+// FieldViolation is a request field that broke a validation rule
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Check validates the value of a field, it returns the violation message or an empty string when the value is valid
+type Check func(value interface{}) string
+
+// FieldRules are the checks of a request field, only the first failing check of a field is reported
+type FieldRules struct {
+	Field  string
+	Value  interface{}
+	Checks []Check
+}
+
+// Field declares the checks of a request field
+func Field(name string, value interface{}, checks ...Check) FieldRules {
+	return FieldRules{
+		Field:  name,
+		Value:  value,
+		Checks: checks,
+	}
+}
+
+// Validate runs the checks of every field and returns ErrInvalidRequest with all the violations, or nil when there are none
+func Validate(fields ...FieldRules) error {
+	var violations []FieldViolation
+
+	for _, field := range fields {
+		for _, check := range field.Checks {
+			if message := check(field.Value); message != "" {
+				violations = append(violations, FieldViolation{Field: field.Field, Message: message})
+				break
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return ErrInvalidRequest.WithViolations(violations)
+}
+
+// ValidationMiddleware rejects the requests that break their rules before they reach the endpoint
+func ValidationMiddleware(rules func(request interface{}) error) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if err := rules(request); err != nil {
+				return nil, err
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// Required rejects empty and blank strings, empty lists and nil dates
+func Required() Check {
+	return func(value interface{}) string {
+		switch v := value.(type) {
+		case *Date:
+			if v == nil {
+				return "is required"
+			}
+		case string:
+			if strings.TrimSpace(v) == "" {
+				return "is required"
+			}
+		case []string:
+			if len(v) == 0 {
+				return "is required"
+			}
+		}
+
+		return ""
+	}
+}
+
+// Between rejects the integers out of [min, max], strings must hold an integer and empty strings are ignored
+func Between(min int, max int) Check {
+	return func(value interface{}) string {
+		n, ok, message := integerValue(value)
+		if !ok {
+			return message
+		}
+
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+
+		return ""
+	}
+}
+
+// WatchCursorFormat rejects the strings that are not read by ParseWatchCursor, empty strings are ignored
+func WatchCursorFormat() Check {
+	return func(value interface{}) string {
+		if cursor, ok := value.(string); ok && cursor != "" {
+			if _, err := ParseWatchCursor(cursor); err != nil {
+				return "must be a cursor of a previous event"
+			}
+		}
+
+		return ""
+	}
+}
+
+// AtLeast rejects the integers lower than min, strings must hold an integer and empty strings are ignored
+func AtLeast(min int) Check {
+	return func(value interface{}) string {
+		n, ok, message := integerValue(value)
+		if !ok {
+			return message
+		}
+
+		if n < min {
+			return fmt.Sprintf("must be at least %d", min)
+		}
+
+		return ""
+	}
+}
+
+// BornBetween rejects the dates of birth that do not exist, are in the future or give an age out of [min, max]
+func BornBetween(min int, max int) Check {
+	return func(value interface{}) string {
+		d, _ := value.(*Date)
+		if d == nil {
+			return ""
+		}
+
+		if !d.Valid() {
+			return "must be a valid date"
+		}
+
+		now := time.Now()
+		if d.Time().After(now) {
+			return "must not be in the future"
+		}
+
+		if age := d.AgeOn(now); age < min || age > max {
+			return fmt.Sprintf("must give an age between %d and %d", min, max)
+		}
+
+		return ""
+	}
+}
+
+// AbsoluteURL rejects the values that are not absolute URLs with one of the given schemes, empty strings are ignored
+func AbsoluteURL(schemes ...string) Check {
+	return func(value interface{}) string {
+		v, _ := value.(string)
+		if v == "" {
+			return ""
+		}
+
+		parsed, err := url.Parse(v)
+		if err != nil || parsed.Host == "" || !contains(schemes, parsed.Scheme) {
+			return fmt.Sprintf("must be an absolute %s url", strings.Join(schemes, " or "))
+		}
+
+		return ""
+	}
+}
+
+// OneOf rejects the strings, or the list elements, that are not one of the allowed values
+func OneOf(allowed ...string) Check {
+	return func(value interface{}) string {
+		var values []string
+
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				values = []string{v}
+			}
+		case []string:
+			values = v
+		}
+
+		for _, v := range values {
+			if !contains(allowed, v) {
+				return fmt.Sprintf("%q must be one of %s", v, strings.Join(allowed, ", "))
+			}
+		}
+
+		return ""
+	}
+}
+
+// integerValue returns the integer held by value, ok is false when there is nothing to check or the value is not an integer
+func integerValue(value interface{}) (n int, ok bool, message string) {
+	switch v := value.(type) {
+	case int:
+		return v, true, ""
+	case int32:
+		return int(v), true, ""
+	case int64:
+		return int(v), true, ""
+	case string:
+		if v == "" {
+			return 0, false, ""
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, false, "must be a number"
+		}
+
+		return n, true, ""
+	}
+
+	return 0, false, ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecks(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("is required", Required()(""))
+	c.Equal("is required", Required()("  "))
+	c.Equal("is required", Required()([]string{}))
+	c.Empty(Required()("test"))
+	c.Empty(Required()(42))
+
+	c.Empty(Between(0, 150)(99))
+	c.Empty(Between(0, 150)("150"))
+	c.Empty(Between(0, 150)(""))
+	c.Equal("must be between 0 and 150", Between(0, 150)(-1))
+	c.Equal("must be between 0 and 150", Between(0, 150)("5000"))
+	c.Equal("must be a number", Between(0, 150)("old"))
+
+	c.Empty(AtLeast(0)(int32(0)))
+	c.Equal("must be at least 0", AtLeast(0)(int32(-1)))
+
+	var noDate *Date
+	c.Empty(BornBetween(0, 150)(noDate))
+	c.Empty(BornBetween(0, 150)(&Date{Year: 1990, Month: 2, Day: 28}))
+	c.Equal("must be a valid date", BornBetween(0, 150)(&Date{Year: 1990, Month: 2, Day: 30}))
+	c.Equal("must not be in the future", BornBetween(0, 150)(&Date{Year: 9999, Month: 1, Day: 1}))
+	c.Equal("must give an age between 0 and 150", BornBetween(0, 150)(&Date{Year: 1800, Month: 1, Day: 1}))
+
+	c.Empty(AbsoluteURL("http", "https")("https://example.com/hook"))
+	c.Empty(AbsoluteURL("http", "https")(""))
+	c.Equal("must be an absolute http or https url", AbsoluteURL("http", "https")("ftp://example.com"))
+	c.Equal("must be an absolute http or https url", AbsoluteURL("http", "https")("/hook"))
+
+	c.Empty(OneOf("a", "b")([]string{"a", "b"}))
+	c.Empty(OneOf("a", "b")(""))
+	c.Equal(`"c" must be one of a, b`, OneOf("a", "b")([]string{"a", "c"}))
+}
+
+func TestValidate(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(Validate(Field("name", "test", Required())))
+
+	err := Validate(
+		Field("name", "", Required()),
+		Field("age", "old", Required(), Between(MinAge, MaxAge)),
+		Field("password", "clave123", Required()),
+	)
+	c.True(errors.Is(err, ErrInvalidRequest))
+	c.Equal([]FieldViolation{
+		{Field: "name", Message: "is required"},
+		{Field: "age", Message: "must be a number"},
+	}, AsError(err).FieldViolations())
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	called := false
+	endpoint := ValidationMiddleware(func(request interface{}) error {
+		return Validate(Field("name", request, Required()))
+	})(func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	})
+
+	_, err := endpoint(context.Background(), "")
+	c.True(errors.Is(err, ErrInvalidRequest))
+	c.False(called)
+
+	response, err := endpoint(context.Background(), "test")
+	c.NoError(err)
+	c.Equal("ok", response)
+	c.True(called)
+}
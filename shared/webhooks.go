@@ -0,0 +1,76 @@
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// WebhookDeliveryPending is the status of a delivery waiting for its first attempt or a retry
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliverySucceeded is the status of a delivery accepted by the subscriber
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryDeadLetter is the status of a delivery that failed every attempt
+	WebhookDeliveryDeadLetter = "dead_letter"
+	// WebhookDeliveryCancelled is the status of a pending delivery whose subscription was deleted
+	WebhookDeliveryCancelled = "cancelled"
+
+	// WebhookEventHeader carries the event type of a webhook delivery
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookDeliveryHeader carries the ID of a webhook delivery, it is the same on every retry
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+	// WebhookTimestampHeader carries the Unix time a webhook delivery was signed at
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader carries the signature of a webhook delivery, see SignWebhookPayload
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookSubscription asks for the events of the given types to be posted to a URL
+type WebhookSubscription struct {
+	ID         string    `json:"id,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	EventTypes []string  `json:"event_types,omitempty"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+}
+
+// WebhookDelivery is the delivery of an event to a subscription and the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             int64     `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        int64     `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastAttemptAt  time.Time `json:"last_attempt_at"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryFilter is the set of filters used to list webhook deliveries
+type WebhookDeliveryFilter struct {
+	SubscriptionID string
+	PageSize       int
+	PageToken      string
+}
+
+// WebhookDeliveryPage is a page of webhook deliveries and the token to request the next one
+type WebhookDeliveryPage struct {
+	Deliveries    []WebhookDelivery `json:"deliveries"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+// SignWebhookPayload returns the value of WebhookSignatureHeader for a delivery sent at timestamp
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
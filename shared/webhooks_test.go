@@ -0,0 +1,17 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	c := require.New(t)
+
+	body := []byte(`{"id":1}`)
+
+	c.Equal("sha256=adabd61623ff2b981aa16ab9dece0abd94535cbe8bd70cb81c3b6d1b20fbe0a6", SignWebhookPayload("secret", "1633082400", body))
+	c.NotEqual(SignWebhookPayload("secret", "1633082400", body), SignWebhookPayload("other secret", "1633082400", body))
+	c.NotEqual(SignWebhookPayload("secret", "1633082400", body), SignWebhookPayload("secret", "1633082401", body))
+}
@@ -1,60 +1,304 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 	"github.com/jumaroar-globant/go-bootcamp/user/config"
 	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
+	"github.com/jumaroar-globant/go-bootcamp/user/events"
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
 	"github.com/jumaroar-globant/go-bootcamp/user/repository"
 	"github.com/jumaroar-globant/go-bootcamp/user/service"
+	"github.com/jumaroar-globant/go-bootcamp/user/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 
 	transport "github.com/jumaroar-globant/go-bootcamp/user/transports"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	switch {
+	case err == sharedLib.ErrPrintConfig:
+		if err := sharedLib.PrintConfig(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case err == flag.ErrHelp:
+		return
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	var logger log.Logger
 	logger = log.NewJSONLogger(os.Stdout)
+	logger = sharedLib.NewRedactingLogger(logger, cfg.Logging.PIIFields)
 	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 	logger = log.With(logger, "caller", log.DefaultCaller)
 
-	db, err := config.Connect()
+	shutdownTracing, err := sharedLib.SetupTracing(context.Background(), cfg.Tracing.Options())
+	if err != nil {
+		level.Error(logger).Log("error_setting_up_tracing", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
+	db, err := config.Connect(cfg.Database)
+	if err != nil {
+		level.Error(logger).Log("error_connecting_to_database", err)
+		return
+	}
+
+	// the database may still be starting along with the service, a signal stops waiting for it
+	waitCtx, stopWaiting := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	startupPolicy := service.RetryPolicy{
+		MaxAttempts: cfg.Database.StartupAttempts,
+		Backoff:     cfg.Database.StartupBackoff,
+		MaxBackoff:  cfg.Database.StartupMaxBackoff,
+	}
+	err = service.WaitForDatabase(waitCtx, db, startupPolicy, cfg.Database.PingTimeout, logger)
+	stopWaiting()
 	if err != nil {
 		level.Error(logger).Log("error_connecting_to_database", err)
+		db.Close()
 		return
 	}
 
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "user"))
+	shared.PasswordHashDuration = kitprometheus.NewHistogramFrom(prometheus.HistogramOpts{
+		Namespace: "user",
+		Name:      "password_hash_duration_seconds",
+		Help:      "Duration of the bcrypt password hashing and checking in seconds.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2, 4},
+	}, []string{"operation"})
+
 	userRepository := repository.NewUserRepository(db, logger)
 	userService := service.NewUserService(userRepository, logger)
-	userEndpoints := endpoints.MakeEndpoints(userService)
+	userEndpoints := endpoints.Instrument(endpoints.MakeEndpoints(userService), sharedLib.NewRequestMetrics("user", "endpoint"))
 	grpcServer := transport.NewGRPCServer(userEndpoints, logger)
+	grpcServerV2 := transport.NewGRPCServerV2(userEndpoints, logger)
+
+	// the background jobs run until the shutdown cancels ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var jobs sync.WaitGroup
+	runJob := func(run func(context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			run(ctx)
+		}()
+	}
+
+	purgeJob := service.NewPurgeJob(userRepository, cfg.Purge.RetentionPeriod, cfg.Purge.Interval, logger)
+	runJob(purgeJob.Run)
+
+	var natsClient *events.NATSClient
+	publisher := events.NewLogPublisher(logger)
+	switch cfg.Outbox.Publisher {
+	case config.FileEventPublisher:
+		publisher = events.NewFilePublisher(cfg.Outbox.FilePath)
+	case config.NATSEventPublisher:
+		natsOptions := events.NATSOptions{
+			Address:  cfg.Outbox.NATSAddress,
+			Timeout:  cfg.Outbox.NATSTimeout,
+			Username: cfg.Outbox.NATSUsername,
+			Password: cfg.Outbox.NATSPassword,
+			Token:    cfg.Outbox.NATSToken,
+		}
+
+		if cfg.Outbox.NATSTLSEnabled() {
+			natsOptions.TLS, err = sharedLib.NewClientTLSConfig(cfg.Outbox.NATSTLSOptions())
+			if err != nil {
+				logger.Log("during", "NewClientTLSConfig", "err", err)
+				os.Exit(1)
+			}
+		}
+
+		natsClient = events.NewNATSClient(natsOptions)
+		publisher = events.NewNATSPublisher(natsClient, cfg.Outbox.NATSSubjectPrefix)
+	}
+
+	webhookPolicy := service.RetryPolicy{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Backoff:     cfg.Webhooks.Backoff,
+		MaxBackoff:  cfg.Webhooks.MaxBackoff,
+	}
+
+	webhookDispatcher := service.NewWebhookDispatcher(userRepository, service.NewWebhookClient(cfg.Webhooks.Timeout), webhookPolicy, cfg.Webhooks.BatchSize, cfg.Webhooks.DispatchInterval, logger)
+	runJob(webhookDispatcher.Run)
+
+	publisher = events.NewMultiPublisher(publisher, webhookDispatcher)
+
+	outboxRelay := service.NewOutboxRelay(userRepository, publisher, cfg.Outbox.BatchSize, cfg.Outbox.RelayInterval, logger)
+	runJob(outboxRelay.Run)
 
-	errs := make(chan error)
+	// errs is buffered for every sender
+	errs := make(chan error, 4)
 	go func() {
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGALRM)
 		errs <- fmt.Errorf("%s", <-c)
 	}()
 
-	grpcListener, err := net.Listen("tcp", ":50051")
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddress)
 	if err != nil {
 		logger.Log("during", "Listen", "err", err)
 		os.Exit(1)
 	}
 
+	var grpcOptions []grpc.ServerOption
+	var tlsConfig *tls.Config
+	if cfg.TLS.CertFile != "" {
+		tlsConfig, err = sharedLib.NewServerTLSConfig(cfg.TLS.Options())
+		if err != nil {
+			logger.Log("during", "NewServerTLSConfig", "err", err)
+			os.Exit(1)
+		}
+
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else {
+		level.Warn(logger).Log("msg", "serving without TLS, passwords cross the network in plaintext")
+	}
+
+	callerCredentials, err := sharedLib.ParseCredentials(cfg.Auth.Tokens)
+	if err != nil {
+		logger.Log("during", "ParseCredentials", "err", err)
+		os.Exit(1)
+	}
+
+	authenticator := transport.NewTokenAuthenticator(callerCredentials, cfg.Auth.TrustedCallers, cfg.Auth.Admins)
+	if !authenticator.Enabled() {
+		level.Warn(logger).Log("msg", "USER_AUTH_TOKENS is empty, the calls are not authenticated")
+	}
+
+	var streams sharedLib.Streams
+
+	grpcOptions = append(grpcOptions,
+		grpc.ChainUnaryInterceptor(transport.UnaryServerInterceptors(logger, authenticator)...),
+		grpc.ChainStreamInterceptor(transport.StreamServerInterceptors(logger, authenticator, &streams)...),
+	)
+
+	healthServer := health.NewServer()
+	healthChecker := service.NewHealthChecker(db, healthServer, []string{pb.UserService_ServiceDesc.ServiceName, pbv2.UserService_ServiceDesc.ServiceName}, cfg.Health.CheckInterval, cfg.Health.CheckTimeout, logger)
+	runJob(healthChecker.Run)
+
+	baseServer := grpc.NewServer(grpcOptions...)
+	pb.RegisterUserServiceServer(baseServer, grpcServer)
+	pbv2.RegisterUserServiceServer(baseServer, grpcServerV2)
+	healthpb.RegisterHealthServer(baseServer, healthServer)
+	if cfg.GRPCReflection {
+		reflection.Register(baseServer)
+	}
+
 	go func() {
-		baseServer := grpc.NewServer()
-		pb.RegisterUserServiceServer(baseServer, grpcServer)
 		level.Info(logger).Log("msg", "Server started successfully 🚀")
-		baseServer.Serve(grpcListener)
+		errs <- baseServer.Serve(grpcListener)
 	}()
 
+	var adminServer *http.Server
+	if cfg.AdminAddress != "" {
+		adminServer = &http.Server{Addr: cfg.AdminAddress, Handler: sharedLib.NewAdminHandler()}
+
+		go func() {
+			level.Info(logger).Log("msg", "admin server started", "address", cfg.AdminAddress)
+			errs <- adminServer.ListenAndServe()
+		}()
+	}
+
+	var gatewayServer *http.Server
+	if cfg.GatewayAddress != "" {
+		proxies, err := sharedLib.ParseTrustedProxies(cfg.GatewayTrustedProxies)
+		if err != nil {
+			logger.Log("during", "ParseTrustedProxies", "err", err)
+			os.Exit(1)
+		}
+
+		gateway, err := transport.NewGatewayHandler(ctx, grpcServerV2, authenticator, proxies)
+		if err != nil {
+			logger.Log("during", "NewGatewayHandler", "err", err)
+			os.Exit(1)
+		}
+
+		gatewayServer = &http.Server{Addr: cfg.GatewayAddress, Handler: gateway, TLSConfig: tlsConfig}
+
+		go func() {
+			level.Info(logger).Log("msg", "gateway started", "address", cfg.GatewayAddress)
+			if tlsConfig != nil {
+				errs <- gatewayServer.ListenAndServeTLS("", "")
+				return
+			}
+
+			errs <- gatewayServer.ListenAndServe()
+		}()
+	}
+
 	level.Error(logger).Log("exit", <-errs)
+
+	// the streams are canceled first and the outbox is flushed once the calls are drained
+	sharedLib.Shutdown(logger, cfg.Shutdown.Timeout,
+		sharedLib.ShutdownStep{Name: "readiness", Run: func(context.Context) error {
+			healthServer.Shutdown()
+			return nil
+		}},
+		sharedLib.DelayStep(cfg.Shutdown.Delay),
+		sharedLib.ShutdownStep{Name: "streams", Run: func(context.Context) error {
+			streams.Cancel()
+			return nil
+		}},
+		sharedLib.ShutdownStep{Name: "gateway", Run: func(ctx context.Context) error {
+			if gatewayServer == nil {
+				return nil
+			}
+
+			return sharedLib.StopHTTPServer(ctx, gatewayServer)
+		}},
+		sharedLib.ShutdownStep{Name: "grpc", Run: func(ctx context.Context) error {
+			return sharedLib.StopGRPCServer(ctx, baseServer)
+		}},
+		sharedLib.ShutdownStep{Name: "jobs", Run: func(shutdownCtx context.Context) error {
+			cancel()
+			return sharedLib.WaitGroup(shutdownCtx, &jobs)
+		}},
+		sharedLib.ShutdownStep{Name: "outbox", Run: outboxRelay.Flush, Timeout: cfg.Shutdown.FlushTimeout},
+		sharedLib.ShutdownStep{Name: "nats", Run: func(context.Context) error {
+			if natsClient == nil {
+				return nil
+			}
+
+			return natsClient.Close()
+		}},
+		sharedLib.ShutdownStep{Name: "admin", Run: func(ctx context.Context) error {
+			if adminServer == nil {
+				return nil
+			}
+
+			return sharedLib.StopHTTPServer(ctx, adminServer)
+		}},
+		sharedLib.ShutdownStep{Name: "database", Run: func(context.Context) error {
+			return db.Close()
+		}},
+	)
 }
@@ -0,0 +1,11 @@
+package config
+
+// AuthConfig is the authentication of the callers of the gRPC server and the REST gateway
+type AuthConfig struct {
+	// Tokens are the bearer tokens the callers must present, written as actor:token
+	Tokens []string `yaml:"tokens" toml:"tokens" env:"USER_AUTH_TOKENS" secret:"true"`
+	// TrustedCallers are the actors, like the http gateway, whose actor and client IP metadata is trusted
+	TrustedCallers []string `yaml:"trusted_callers" toml:"trusted_callers" env:"USER_AUTH_TRUSTED_CALLERS" flag:"trusted-callers" usage:"comma separated actors whose sent actor and client IP are trusted"`
+	// Admins are the actors that are administrators, only they can manage the webhook subscriptions
+	Admins []string `yaml:"admins" toml:"admins" env:"USER_AUTH_ADMINS" flag:"admins" usage:"comma separated actors that are administrators"`
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// FileEnv names the YAML or TOML configuration file of the service when the -config flag is not set
+const FileEnv = "USER_CONFIG_FILE"
+
+// Config is the configuration of the user service
+type Config struct {
+	// GRPCAddress is the address the gRPC server listens on
+	GRPCAddress string `yaml:"grpc_address" toml:"grpc_address" env:"USER_GRPC_ADDRESS" flag:"grpc-addr" usage:"gRPC listen address"`
+	// GatewayAddress is the address the grpc-gateway REST surface of the v2 API listens on, it is disabled when empty
+	GatewayAddress string `yaml:"gateway_address" toml:"gateway_address" env:"USER_GATEWAY_ADDRESS" flag:"gateway-addr" usage:"REST gateway listen address, disabled when empty"`
+	// GatewayTrustedProxies are the IPs or CIDRs of the reverse proxies in front of the REST gateway
+	GatewayTrustedProxies []string `yaml:"gateway_trusted_proxies" toml:"gateway_trusted_proxies" env:"USER_GATEWAY_TRUSTED_PROXIES" flag:"gateway-trusted-proxies" usage:"comma separated IPs or CIDRs of the proxies whose X-Forwarded-For is trusted by the REST gateway"`
+	// AdminAddress is the address the Prometheus metrics are served on, disabled when empty
+	AdminAddress string `yaml:"admin_address" toml:"admin_address" env:"USER_ADMIN_ADDRESS" flag:"admin-addr" usage:"admin listen address of the Prometheus metrics, disabled when empty"`
+	// GRPCReflection enables the gRPC server reflection, for tools like grpcurl
+	GRPCReflection bool `yaml:"grpc_reflection" toml:"grpc_reflection" env:"USER_GRPC_REFLECTION" flag:"grpc-reflection" usage:"enable the gRPC server reflection"`
+
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	TLS      TLSConfig      `yaml:"tls" toml:"tls"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Health   HealthConfig   `yaml:"health" toml:"health"`
+	Outbox   OutboxConfig   `yaml:"outbox" toml:"outbox"`
+	Purge    PurgeConfig    `yaml:"purge" toml:"purge"`
+	Webhooks WebhooksConfig `yaml:"webhooks" toml:"webhooks"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+	Logging  LoggingConfig  `yaml:"logging" toml:"logging"`
+	Shutdown ShutdownConfig `yaml:"shutdown" toml:"shutdown"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() Config {
+	return Config{
+		GRPCAddress:  ":50051",
+		AdminAddress: ":9091",
+		Database: DatabaseConfig{
+			Driver:   "mysql",
+			Username: "root",
+			Host:     "127.0.0.1",
+			Port:     "3306",
+			Name:     "bootcamp",
+			TLS: DatabaseTLSConfig{
+				Mode: "false",
+			},
+			MaxOpenConns:      20,
+			MaxIdleConns:      10,
+			ConnMaxLifetime:   5 * time.Minute,
+			ConnMaxIdleTime:   time.Minute,
+			StartupAttempts:   10,
+			StartupBackoff:    time.Second,
+			StartupMaxBackoff: 10 * time.Second,
+			PingTimeout:       5 * time.Second,
+		},
+		Health: HealthConfig{
+			CheckInterval: 5 * time.Second,
+			CheckTimeout:  time.Second,
+		},
+		Outbox: OutboxConfig{
+			RelayInterval:     time.Second,
+			BatchSize:         100,
+			Publisher:         LogEventPublisher,
+			FilePath:          "user_events.jsonl",
+			NATSSubjectPrefix: "users",
+			NATSTimeout:       5 * time.Second,
+		},
+		Purge: PurgeConfig{
+			RetentionPeriod: 30 * 24 * time.Hour,
+			Interval:        time.Hour,
+		},
+		Webhooks: WebhooksConfig{
+			DispatchInterval: 5 * time.Second,
+			BatchSize:        50,
+			Timeout:          10 * time.Second,
+			MaxAttempts:      8,
+			Backoff:          30 * time.Second,
+			MaxBackoff:       time.Hour,
+		},
+		Tracing: TracingConfig{
+			Exporter:    sharedLib.NoTraceExporter,
+			Endpoint:    "localhost:4317",
+			FilePath:    "user-traces.json",
+			SampleRatio: 1,
+		},
+		Logging: LoggingConfig{
+			PIIFields: sharedLib.DefaultPIIFields,
+		},
+		Shutdown: ShutdownConfig{
+			Timeout:      30 * time.Second,
+			FlushTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Load reads the configuration from the command line arguments, without the name of the binary
+func Load(args []string) (Config, error) {
+	config := Default()
+	err := sharedLib.LoadConfig(&config, sharedLib.ConfigOptions{Name: "user", Args: args, FileEnv: FileEnv})
+
+	return config, err
+}
+
+// Validate reports every invalid value of the configuration
+func (c Config) Validate() error {
+	var problems sharedLib.ConfigErrors
+
+	if c.GRPCAddress == "" {
+		problems.Addf("the gRPC address is required")
+	}
+
+	if c.Database.Driver == "" {
+		problems.Addf("the database driver is required")
+	}
+
+	if c.Database.Host == "" {
+		problems.Addf("the database host is required")
+	}
+
+	if port, err := strconv.Atoi(c.Database.Port); err != nil || port < 1 || port > 65535 {
+		problems.Addf("the database port %q is not a port number", c.Database.Port)
+	}
+
+	if c.Database.Name == "" {
+		problems.Addf("the database name is required")
+	}
+
+	for _, param := range c.Database.Params {
+		if !strings.Contains(param, "=") {
+			problems.Addf("the database parameter %q is not a key=value pair", param)
+		}
+	}
+
+	if _, err := c.Database.DSN(); err != nil {
+		problems.Addf("the database parameters are invalid: %v", err)
+	}
+
+	switch c.Database.TLS.Mode {
+	case "false", "skip-verify", "preferred":
+		if c.Database.TLS.custom() {
+			problems.Addf("the database TLS files and server name require the database TLS mode true")
+		}
+	case "true":
+	default:
+		problems.Addf("unknown database TLS mode %q, use false, true, skip-verify or preferred", c.Database.TLS.Mode)
+	}
+
+	if (c.Database.TLS.CertFile == "") != (c.Database.TLS.KeyFile == "") {
+		problems.Addf("the database TLS certificate and key must be set together")
+	}
+
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		problems.Addf("the database connection limits must not be negative")
+	}
+
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems.Addf("the database max idle connections must not exceed the max open connections")
+	}
+
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		problems.Addf("the database connection lifetimes must not be negative")
+	}
+
+	if c.Database.StartupMaxBackoff < c.Database.StartupBackoff {
+		problems.Addf("the database startup max backoff must not be shorter than the database startup backoff")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problems.Addf("the TLS certificate and key must be set together")
+	}
+
+	if c.TLS.CertFile == "" && (c.TLS.ClientCAFile != "" || len(c.TLS.AllowedSANs) > 0) {
+		problems.Addf("the TLS client CA and allowed SANs require a TLS certificate")
+	}
+
+	if len(c.TLS.AllowedSANs) > 0 && c.TLS.ClientCAFile == "" {
+		problems.Addf("the TLS allowed SANs require a TLS client CA")
+	}
+
+	if credentials, err := sharedLib.ParseCredentials(c.Auth.Tokens); err != nil {
+		problems.Addf("the auth tokens are invalid: %v", err)
+	} else {
+		actors := map[string]bool{}
+		for _, actor := range credentials.Actors() {
+			actors[actor] = true
+		}
+
+		for _, caller := range c.Auth.TrustedCallers {
+			if !actors[caller] {
+				problems.Addf("the trusted caller %q has no auth token", caller)
+			}
+		}
+	}
+
+	if _, err := sharedLib.ParseTrustedProxies(c.GatewayTrustedProxies); err != nil {
+		problems.Addf("the gateway trusted proxies are invalid: %v", err)
+	}
+
+	switch c.Outbox.Publisher {
+	case LogEventPublisher:
+	case FileEventPublisher:
+		if c.Outbox.FilePath == "" {
+			problems.Addf("the event file is required by the %s event publisher", FileEventPublisher)
+		}
+	case NATSEventPublisher:
+		if c.Outbox.NATSAddress == "" {
+			problems.Addf("the NATS address is required by the %s event publisher", NATSEventPublisher)
+		}
+
+		if c.Outbox.NATSSubjectPrefix == "" || strings.ContainsAny(c.Outbox.NATSSubjectPrefix, " \t\r\n*>") {
+			problems.Addf("the NATS subject prefix %q is not a valid subject", c.Outbox.NATSSubjectPrefix)
+		}
+
+		if c.Outbox.NATSTimeout <= 0 {
+			problems.Addf("the NATS timeout must be positive")
+		}
+
+		if (c.Outbox.NATSUsername == "") != (c.Outbox.NATSPassword == "") {
+			problems.Addf("the NATS username and password must be set together")
+		}
+
+		if c.Outbox.NATSToken != "" && c.Outbox.NATSUsername != "" {
+			problems.Addf("the NATS token and username can not be used together")
+		}
+
+		if (c.Outbox.NATSTLSCertFile == "") != (c.Outbox.NATSTLSKeyFile == "") {
+			problems.Addf("the NATS TLS certificate and key must be set together")
+		}
+	default:
+		problems.Addf("unknown event publisher %q, use %s, %s or %s", c.Outbox.Publisher, LogEventPublisher, FileEventPublisher, NATSEventPublisher)
+	}
+
+	switch c.Tracing.Exporter {
+	case sharedLib.NoTraceExporter, sharedLib.OTLPTraceExporter, sharedLib.StdoutTraceExporter, sharedLib.FileTraceExporter:
+	default:
+		problems.Addf("unknown trace exporter %q", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems.Addf("the tracing sample ratio must be between 0 and 1")
+	}
+
+	positiveDurations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"the database startup backoff", c.Database.StartupBackoff},
+		{"the database ping timeout", c.Database.PingTimeout},
+		{"the health check interval", c.Health.CheckInterval},
+		{"the health check timeout", c.Health.CheckTimeout},
+		{"the outbox relay interval", c.Outbox.RelayInterval},
+		{"the purge retention period", c.Purge.RetentionPeriod},
+		{"the purge interval", c.Purge.Interval},
+		{"the webhook dispatch interval", c.Webhooks.DispatchInterval},
+		{"the webhook timeout", c.Webhooks.Timeout},
+		{"the webhook backoff", c.Webhooks.Backoff},
+		{"the shutdown timeout", c.Shutdown.Timeout},
+		{"the shutdown flush timeout", c.Shutdown.FlushTimeout},
+	}
+	for _, duration := range positiveDurations {
+		if duration.value <= 0 {
+			problems.Addf("%s must be positive", duration.name)
+		}
+	}
+
+	if c.Webhooks.MaxBackoff < c.Webhooks.Backoff {
+		problems.Addf("the webhook max backoff must not be shorter than the webhook backoff")
+	}
+
+	positiveCounts := []struct {
+		name  string
+		value int
+	}{
+		{"the database startup attempts", c.Database.StartupAttempts},
+		{"the outbox batch size", c.Outbox.BatchSize},
+		{"the webhook batch size", c.Webhooks.BatchSize},
+		{"the webhook max attempts", c.Webhooks.MaxAttempts},
+	}
+	for _, count := range positiveCounts {
+		if count.value < 1 {
+			problems.Addf("%s must be positive", count.name)
+		}
+	}
+
+	if c.Shutdown.Delay < 0 || c.Shutdown.Delay >= c.Shutdown.Timeout {
+		problems.Addf("the shutdown delay must be shorter than the shutdown timeout")
+	}
+
+	return problems.Err()
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(Default().Validate())
+}
+
+func TestLoad(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "user.yaml")
+	c.NoError(os.WriteFile(file, []byte("grpc_address: \":6000\"\ndatabase:\n  host: db.internal\noutbox:\n  batch_size: 10\n"), 0600))
+
+	password := filepath.Join(dir, "password")
+	c.NoError(os.WriteFile(password, []byte("s3cret\n"), 0600))
+
+	t.Setenv(FileEnv, file)
+	t.Setenv("DATABASE_IP", "10.0.0.5")
+	t.Setenv("DATABASE_PASSWORD_FILE", password)
+	t.Setenv("USER_AUTH_TOKENS", "gateway:token-1,gateway:token-2")
+
+	config, err := Load([]string{"-outbox-batch-size", "20", "-shutdown-timeout", "1m"})
+	c.NoError(err)
+	c.Equal(":6000", config.GRPCAddress)
+	c.Equal("10.0.0.5", config.Database.Host)
+	c.Equal("s3cret", config.Database.Password)
+	c.Equal([]string{"gateway:token-1", "gateway:token-2"}, config.Auth.Tokens)
+	c.Equal(20, config.Outbox.BatchSize)
+	c.Equal(time.Minute, config.Shutdown.Timeout)
+}
+
+func TestLoadPrintConfig(t *testing.T) {
+	c := require.New(t)
+
+	_, err := Load([]string{"-print-config", "-database-port", "not-a-port"})
+	c.Equal(sharedLib.ErrPrintConfig, err, "the configuration is printed before it is validated")
+}
+
+func TestValidate(t *testing.T) {
+	c := require.New(t)
+
+	config := Default()
+	config.GRPCAddress = ""
+	config.Database.Port = "0"
+	config.TLS.CertFile = "server.pem"
+	config.Auth.Tokens = []string{"gateway:secret"}
+	config.Auth.TrustedCallers = []string{"gateway", "batch"}
+	config.GatewayTrustedProxies = []string{"proxy.internal"}
+	config.Outbox.Publisher = "kafka"
+	config.Tracing.SampleRatio = 2
+	config.Webhooks.MaxAttempts = 0
+	config.Shutdown.Delay = time.Minute
+
+	c.EqualError(config.Validate(), "invalid configuration: "+
+		"the gRPC address is required; "+
+		"the database port \"0\" is not a port number; "+
+		"the TLS certificate and key must be set together; "+
+		"the trusted caller \"batch\" has no auth token; "+
+		"the gateway trusted proxies are invalid: the trusted proxy \"proxy.internal\" is not an IP address or a CIDR; "+
+		"unknown event publisher \"kafka\", use log, file or nats; "+
+		"the tracing sample ratio must be between 0 and 1; "+
+		"the webhook max attempts must be positive; "+
+		"the shutdown delay must be shorter than the shutdown timeout")
+
+	config = Default()
+	config.Outbox.Publisher = NATSEventPublisher
+	config.Outbox.NATSSubjectPrefix = "users.*"
+	config.Outbox.NATSUsername = "user-service"
+	config.Outbox.NATSToken = "secret"
+	config.Outbox.NATSTLSCertFile = "client.pem"
+	c.EqualError(config.Validate(), "invalid configuration: "+
+		"the NATS address is required by the nats event publisher; "+
+		"the NATS subject prefix \"users.*\" is not a valid subject; "+
+		"the NATS username and password must be set together; "+
+		"the NATS token and username can not be used together; "+
+		"the NATS TLS certificate and key must be set together")
+
+	config = Default()
+	config.Auth.Tokens = []string{"secret"}
+	c.EqualError(config.Validate(), "invalid configuration: the auth tokens are invalid: the credential 1 is not written as actor:token")
+}
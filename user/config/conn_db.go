@@ -2,29 +2,132 @@ package config
 
 import (
 	"database/sql"
-	"fmt"
+	"net"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/jumaroar-globant/go-bootcamp/user/shared"
-)
+	"github.com/XSAM/otelsql"
+	"github.com/go-sql-driver/mysql"
 
-var (
-	dbDriver   = shared.GetStringEnvVar("DATABASE_DRIVER", "mysql")
-	dbUsername = shared.GetStringEnvVar("DATABASE_USERNAME", "root")
-	dbPassword = shared.GetStringEnvVar("DATABASE_PASSWORD", "")
-	dbIP       = shared.GetStringEnvVar("DATABASE_IP", "127.0.0.1")
-	dbPort     = shared.GetStringEnvVar("DATABASE_PORT", "3306")
-	dbName     = shared.GetStringEnvVar("DATABASE_NAME", "bootcamp")
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 )
 
+// databaseTLSConfigName is the name the TLS configuration built from DatabaseTLSConfig is registered under in the MySQL driver
+const databaseTLSConfigName = "user-database"
+
+// DatabaseConfig is the connection to the MySQL database
+type DatabaseConfig struct {
+	Driver   string `yaml:"driver" toml:"driver" env:"DATABASE_DRIVER" flag:"database-driver" usage:"database/sql driver of the database"`
+	Username string `yaml:"username" toml:"username" env:"DATABASE_USERNAME" flag:"database-username" usage:"user the database is connected as"`
+	// Password has no flag so it does not show in the process list, it is read from the file named by DATABASE_PASSWORD_FILE instead
+	Password string `yaml:"password" toml:"password" env:"DATABASE_PASSWORD" secret:"true"`
+	Host     string `yaml:"host" toml:"host" env:"DATABASE_IP" flag:"database-host" usage:"host of the database"`
+	Port     string `yaml:"port" toml:"port" env:"DATABASE_PORT" flag:"database-port" usage:"port of the database"`
+	Name     string `yaml:"name" toml:"name" env:"DATABASE_NAME" flag:"database-name" usage:"name of the database"`
+	// Params are extra key=value parameters of the MySQL DSN, like timeout=5s or loc=UTC
+	Params []string `yaml:"params" toml:"params" env:"DATABASE_PARAMS" flag:"database-params" usage:"comma separated key=value parameters of the MySQL DSN"`
+
+	TLS DatabaseTLSConfig `yaml:"tls" toml:"tls"`
+
+	// MaxOpenConns limits the connections to the database, it is unlimited when 0
+	MaxOpenConns int `yaml:"max_open_conns" toml:"max_open_conns" env:"DATABASE_MAX_OPEN_CONNS" flag:"database-max-open-conns" usage:"maximum number of open connections to the database, unlimited when 0"`
+	// MaxIdleConns is how many connections are kept open for the next queries
+	MaxIdleConns int `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" flag:"database-max-idle-conns" usage:"maximum number of idle connections kept open"`
+	// ConnMaxLifetime closes the connections once they are this old, never when it is 0
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME" flag:"database-conn-max-lifetime" usage:"age the connections are closed at, never when 0"`
+	// ConnMaxIdleTime closes the connections idle for this long, they are never closed for being idle when it is 0
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DATABASE_CONN_MAX_IDLE_TIME" flag:"database-conn-max-idle-time" usage:"idle time the connections are closed after, never when 0"`
+
+	// StartupAttempts is how many times the database is pinged on startup before the service gives up
+	StartupAttempts   int           `yaml:"startup_attempts" toml:"startup_attempts" env:"DATABASE_STARTUP_ATTEMPTS" flag:"database-startup-attempts" usage:"how many times the database is pinged on startup before giving up"`
+	StartupBackoff    time.Duration `yaml:"startup_backoff" toml:"startup_backoff" env:"DATABASE_STARTUP_BACKOFF" flag:"database-startup-backoff" usage:"wait after the first failed startup ping, it doubles after every ping"`
+	StartupMaxBackoff time.Duration `yaml:"startup_max_backoff" toml:"startup_max_backoff" env:"DATABASE_STARTUP_MAX_BACKOFF" flag:"database-startup-max-backoff" usage:"longest wait between two startup pings"`
+	// PingTimeout is how long a startup ping may take
+	PingTimeout time.Duration `yaml:"ping_timeout" toml:"ping_timeout" env:"DATABASE_PING_TIMEOUT" flag:"database-ping-timeout" usage:"how long a startup ping may take"`
+}
+
+// DatabaseTLSConfig is the TLS of the connections to the database
+type DatabaseTLSConfig struct {
+	// Mode is false, true, skip-verify or preferred, like the tls parameter of the MySQL driver
+	Mode       string `yaml:"mode" toml:"mode" env:"DATABASE_TLS" flag:"database-tls" usage:"TLS to the database: false, true, skip-verify or preferred"`
+	CAFile     string `yaml:"ca_file" toml:"ca_file" env:"DATABASE_TLS_CA_FILE" flag:"database-tls-ca" usage:"CA bundle verifying the database certificate"`
+	CertFile   string `yaml:"cert_file" toml:"cert_file" env:"DATABASE_TLS_CERT_FILE" flag:"database-tls-cert" usage:"client certificate presented to the database"`
+	KeyFile    string `yaml:"key_file" toml:"key_file" env:"DATABASE_TLS_KEY_FILE" flag:"database-tls-key" usage:"key of the client certificate presented to the database"`
+	ServerName string `yaml:"server_name" toml:"server_name" env:"DATABASE_TLS_SERVER_NAME" flag:"database-tls-server-name" usage:"name the database certificate is verified against, defaults to the database host"`
+}
+
+// custom reports whether the TLS configuration of the driver is replaced by one built from the files
+func (c DatabaseTLSConfig) custom() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.ServerName != ""
+}
+
+// DSN returns the data source name of the MySQL driver
+func (c DatabaseConfig) DSN() (string, error) {
+	dsn, err := mysql.ParseDSN("/?" + strings.Join(c.Params, "&"))
+	if err != nil {
+		return "", err
+	}
+
+	dsn.User = c.Username
+	dsn.Passwd = c.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(c.Host, c.Port)
+	dsn.DBName = c.Name
+	dsn.ParseTime = true
+
+	if c.TLS.Mode != "" {
+		dsn.TLSConfig = c.TLS.Mode
+	}
+
+	if c.TLS.custom() {
+		dsn.TLSConfig = databaseTLSConfigName
+	}
+
+	return dsn.FormatDSN(), nil
+}
+
 // Connect is a function to connect to the database
-func Connect() (*sql.DB, error) {
-	dbConnString := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", dbUsername, dbPassword, dbIP, dbPort, dbName)
+func Connect(database DatabaseConfig) (*sql.DB, error) {
+	if database.TLS.custom() {
+		serverName := database.TLS.ServerName
+		if serverName == "" {
+			serverName = database.Host
+		}
+
+		tlsConfig, err := sharedLib.NewClientTLSConfig(sharedLib.TLSOptions{
+			CertFile:   database.TLS.CertFile,
+			KeyFile:    database.TLS.KeyFile,
+			CAFile:     database.TLS.CAFile,
+			ServerName: serverName,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := mysql.RegisterTLSConfig(databaseTLSConfigName, tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	dbConnString, err := database.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	tracedDriver, err := otelsql.Register(database.Driver, database.Driver)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open(dbDriver, dbConnString)
+	db, err := sql.Open(tracedDriver, dbConnString)
 	if err != nil {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(database.MaxOpenConns)
+	db.SetMaxIdleConns(database.MaxIdleConns)
+	db.SetConnMaxLifetime(database.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(database.ConnMaxIdleTime)
+
 	return db, nil
 }
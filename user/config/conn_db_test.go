@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -9,16 +10,74 @@ import (
 func TestConnect(t *testing.T) {
 	c := require.New(t)
 
-	db, err := Connect()
+	db, err := Connect(Default().Database)
 	c.NotNil(db)
 	c.Nil(err)
+	c.Equal(20, db.Stats().MaxOpenConnections)
 }
 
 func TestConnectFails(t *testing.T) {
 	c := require.New(t)
 
-	dbDriver = ""
-	db, err := Connect()
+	database := Default().Database
+	database.Driver = ""
+	db, err := Connect(database)
 	c.Nil(db)
 	c.EqualError(err, "sql: unknown driver \"\" (forgotten import?)")
 }
+
+func TestConnectFailsWithoutTheTLSFiles(t *testing.T) {
+	c := require.New(t)
+
+	database := Default().Database
+	database.TLS.Mode = "true"
+	database.TLS.CAFile = "missing-ca.pem"
+	db, err := Connect(database)
+	c.Nil(db)
+	c.Error(err)
+}
+
+func TestDSN(t *testing.T) {
+	c := require.New(t)
+
+	database := Default().Database
+	dsn, err := database.DSN()
+	c.NoError(err)
+	c.Equal("root@tcp(127.0.0.1:3306)/bootcamp?parseTime=true&tls=false", dsn)
+
+	database.Password = "p@ss/word"
+	database.Params = []string{"timeout=5s", "loc=UTC", "parseTime=false", "sql_mode=TRADITIONAL"}
+	database.TLS.Mode = "skip-verify"
+	dsn, err = database.DSN()
+	c.NoError(err)
+	c.Equal("root:p@ss/word@tcp(127.0.0.1:3306)/bootcamp?parseTime=true&timeout=5s&tls=skip-verify&sql_mode=TRADITIONAL", dsn)
+
+	database.TLS.Mode = "true"
+	database.TLS.CAFile = "ca.pem"
+	dsn, err = database.DSN()
+	c.NoError(err)
+	c.Contains(dsn, "tls="+databaseTLSConfigName)
+
+	database.Params = []string{"timeout=soon"}
+	_, err = database.DSN()
+	c.Error(err)
+}
+
+func TestValidateDatabase(t *testing.T) {
+	c := require.New(t)
+
+	config := Default()
+	config.Database.Params = []string{"timeout"}
+	config.Database.TLS.Mode = "preferred"
+	config.Database.TLS.CAFile = "ca.pem"
+	config.Database.MaxIdleConns = 50
+	config.Database.StartupAttempts = 0
+	config.Database.StartupMaxBackoff = time.Millisecond
+
+	c.EqualError(config.Validate(), "invalid configuration: "+
+		"the database parameter \"timeout\" is not a key=value pair; "+
+		"the database TLS files and server name require the database TLS mode true; "+
+		"the database max idle connections must not exceed the max open connections; "+
+		"the database startup max backoff must not be shorter than the database startup backoff; "+
+		"the database startup attempts must be positive")
+}
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"time"
+)
+
+// HealthConfig is the database check behind the gRPC health status
+type HealthConfig struct {
+	// CheckInterval is how often the database is pinged to update the gRPC health status
+	CheckInterval time.Duration `yaml:"check_interval" toml:"check_interval" env:"USER_HEALTH_CHECK_INTERVAL" flag:"health-check-interval" usage:"how often the database is pinged to update the health status"`
+	// CheckTimeout is how long a database ping may take before the service is reported as not serving
+	CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout" env:"USER_HEALTH_CHECK_TIMEOUT" flag:"health-check-timeout" usage:"how long a database ping may take before the service is not serving"`
+}
@@ -0,0 +1,7 @@
+package config
+
+// LoggingConfig is the redaction of the logs
+type LoggingConfig struct {
+	// PIIFields are the keys whose values are redacted from the logs on top of the secrets
+	PIIFields []string `yaml:"pii_fields" toml:"pii_fields" env:"USER_LOG_PII_FIELDS" flag:"log-pii-fields" usage:"comma separated keys whose values are redacted from the logs, on top of the secrets"`
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+const (
+	// LogEventPublisher publishes domain events to the service log
+	LogEventPublisher = "log"
+	// FileEventPublisher appends domain events to OutboxConfig.FilePath
+	FileEventPublisher = "file"
+	// NATSEventPublisher sends domain events to the NATS server at OutboxConfig.NATSAddress
+	NATSEventPublisher = "nats"
+)
+
+// OutboxConfig is the relay publishing the domain events written to the outbox
+type OutboxConfig struct {
+	// RelayInterval is how often the outbox relay looks for domain events to publish
+	RelayInterval time.Duration `yaml:"relay_interval" toml:"relay_interval" env:"USER_OUTBOX_RELAY_INTERVAL" flag:"outbox-relay-interval" usage:"how often the outbox relay looks for domain events to publish"`
+	// BatchSize is the maximum number of domain events published by each relay run
+	BatchSize int `yaml:"batch_size" toml:"batch_size" env:"USER_OUTBOX_BATCH_SIZE" flag:"outbox-batch-size" usage:"maximum number of domain events published by each relay run"`
+	// Publisher selects where domain events are published, LogEventPublisher, FileEventPublisher or NATSEventPublisher
+	Publisher string `yaml:"publisher" toml:"publisher" env:"USER_EVENT_PUBLISHER" flag:"event-publisher" usage:"where domain events are published: log, file or nats"`
+	// FilePath is the file domain events are appended to when Publisher is FileEventPublisher
+	FilePath string `yaml:"file" toml:"file" env:"USER_EVENT_FILE" flag:"event-file" usage:"file domain events are appended to by the file publisher"`
+	// NATSAddress is the host:port of the NATS server domain events are sent to when Publisher is NATSEventPublisher
+	NATSAddress string `yaml:"nats_address" toml:"nats_address" env:"USER_EVENT_NATS_ADDRESS" flag:"event-nats-addr" usage:"host:port of the NATS server the nats publisher sends domain events to"`
+	// NATSSubjectPrefix is the prefix of the subjects, every event is sent to prefix.event_type
+	NATSSubjectPrefix string `yaml:"nats_subject_prefix" toml:"nats_subject_prefix" env:"USER_EVENT_NATS_SUBJECT_PREFIX" flag:"event-nats-subject-prefix" usage:"prefix of the NATS subjects, every event is sent to prefix.event_type"`
+	// NATSTimeout bounds the dial and the acknowledgement of every event by the NATS server
+	NATSTimeout time.Duration `yaml:"nats_timeout" toml:"nats_timeout" env:"USER_EVENT_NATS_TIMEOUT" flag:"event-nats-timeout" usage:"how long the NATS server may take to acknowledge a domain event"`
+	// NATSUsername and NATSPassword, or NATSToken, authenticate the service to the NATS server
+	NATSUsername string `yaml:"nats_username" toml:"nats_username" env:"USER_EVENT_NATS_USERNAME" flag:"event-nats-username" usage:"user the NATS server is connected as"`
+	NATSPassword string `yaml:"nats_password" toml:"nats_password" env:"USER_EVENT_NATS_PASSWORD" secret:"true"`
+	NATSToken    string `yaml:"nats_token" toml:"nats_token" env:"USER_EVENT_NATS_TOKEN" secret:"true"`
+	// NATSTLS connects to the NATS server over TLS even when it does not require it, TLS is always used when it does
+	NATSTLS           bool   `yaml:"nats_tls" toml:"nats_tls" env:"USER_EVENT_NATS_TLS" flag:"event-nats-tls" usage:"connect to the NATS server over TLS even when it does not require it"`
+	NATSTLSCAFile     string `yaml:"nats_tls_ca_file" toml:"nats_tls_ca_file" env:"USER_EVENT_NATS_TLS_CA_FILE" flag:"event-nats-tls-ca" usage:"CA bundle verifying the NATS server certificate, the system roots when empty"`
+	NATSTLSCertFile   string `yaml:"nats_tls_cert_file" toml:"nats_tls_cert_file" env:"USER_EVENT_NATS_TLS_CERT_FILE" flag:"event-nats-tls-cert" usage:"client certificate presented to the NATS server"`
+	NATSTLSKeyFile    string `yaml:"nats_tls_key_file" toml:"nats_tls_key_file" env:"USER_EVENT_NATS_TLS_KEY_FILE" flag:"event-nats-tls-key" usage:"key of the client certificate presented to the NATS server"`
+	NATSTLSServerName string `yaml:"nats_tls_server_name" toml:"nats_tls_server_name" env:"USER_EVENT_NATS_TLS_SERVER_NAME" flag:"event-nats-tls-server-name" usage:"name the NATS server certificate is verified against, defaults to the NATS host"`
+}
+
+// NATSTLSEnabled reports whether the connection to the NATS server is configured to use TLS
+func (c OutboxConfig) NATSTLSEnabled() bool {
+	return c.NATSTLS || c.NATSTLSCAFile != "" || c.NATSTLSCertFile != "" || c.NATSTLSServerName != ""
+}
+
+// NATSTLSOptions returns the TLS options of the connection to the NATS server
+func (c OutboxConfig) NATSTLSOptions() sharedLib.TLSOptions {
+	return sharedLib.TLSOptions{
+		CertFile:   c.NATSTLSCertFile,
+		KeyFile:    c.NATSTLSKeyFile,
+		CAFile:     c.NATSTLSCAFile,
+		ServerName: c.NATSTLSServerName,
+	}
+}
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"time"
+)
+
+// PurgeConfig is the job permanently removing the soft deleted users
+type PurgeConfig struct {
+	// RetentionPeriod is how long a soft deleted user is kept before being permanently removed
+	RetentionPeriod time.Duration `yaml:"retention_period" toml:"retention_period" env:"USER_PURGE_RETENTION_PERIOD" flag:"purge-retention-period" usage:"how long a soft deleted user is kept before being permanently removed"`
+	// Interval is how often the purge job looks for users to permanently remove
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"USER_PURGE_INTERVAL" flag:"purge-interval" usage:"how often the purge job looks for users to permanently remove"`
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"time"
+)
+
+// ShutdownConfig is the graceful shutdown of the service
+type ShutdownConfig struct {
+	// Timeout is the deadline of the whole graceful shutdown, the calls still running when it passes are canceled
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"USER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"deadline of the graceful shutdown, the calls still running when it passes are canceled"`
+	// Delay is how long the service keeps accepting calls after reporting itself as not serving
+	Delay time.Duration `yaml:"delay" toml:"delay" env:"USER_SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"how long the service keeps accepting calls after reporting itself as not serving"`
+	// FlushTimeout is how long the pending outbox events are published for once the calls are drained
+	FlushTimeout time.Duration `yaml:"flush_timeout" toml:"flush_timeout" env:"USER_SHUTDOWN_FLUSH_TIMEOUT" flag:"shutdown-flush-timeout" usage:"how long the pending outbox events are published for once the calls are drained"`
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// TLSConfig is the certificate of the gRPC server and of the REST gateway
+type TLSConfig struct {
+	CertFile     string   `yaml:"cert_file" toml:"cert_file" env:"USER_TLS_CERT_FILE" flag:"tls-cert" usage:"certificate of the gRPC server and the gateway, they serve plaintext when empty"`
+	KeyFile      string   `yaml:"key_file" toml:"key_file" env:"USER_TLS_KEY_FILE" flag:"tls-key" usage:"key of the server certificate"`
+	ClientCAFile string   `yaml:"client_ca_file" toml:"client_ca_file" env:"USER_TLS_CLIENT_CA_FILE" flag:"tls-client-ca" usage:"CA bundle of the client certificates the servers require"`
+	AllowedSANs  []string `yaml:"allowed_sans" toml:"allowed_sans" env:"USER_TLS_ALLOWED_SANS" flag:"tls-allowed-sans" usage:"comma separated SANs the client certificates must have one of"`
+}
+
+// Options returns the TLS options of the servers
+func (c TLSConfig) Options() sharedLib.TLSOptions {
+	return sharedLib.TLSOptions{
+		CertFile:    c.CertFile,
+		KeyFile:     c.KeyFile,
+		CAFile:      c.ClientCAFile,
+		AllowedSANs: c.AllowedSANs,
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// TracingConfig is the export of the spans, the trace context of the callers is propagated even when they are not exported
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"USER_TRACING_EXPORTER" flag:"tracing-exporter" usage:"exporter of the spans: none, otlp, stdout or file"`
+	Endpoint    string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"USER_OTLP_ENDPOINT" flag:"otlp-endpoint" usage:"host:port of the OTLP collector"`
+	Insecure    bool    `yaml:"otlp_insecure" toml:"otlp_insecure" env:"USER_OTLP_INSECURE" flag:"otlp-insecure" usage:"send the spans to the OTLP collector without TLS"`
+	FilePath    string  `yaml:"file" toml:"file" env:"USER_TRACING_FILE" flag:"tracing-file" usage:"file the spans are appended to by the file exporter"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"USER_TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"fraction of the traces started by the service that are sampled"`
+}
+
+// Options returns the tracing options of the service
+func (c TracingConfig) Options() sharedLib.TracingOptions {
+	return sharedLib.TracingOptions{
+		ServiceName: "user",
+		Exporter:    c.Exporter,
+		Endpoint:    c.Endpoint,
+		Insecure:    c.Insecure,
+		FilePath:    c.FilePath,
+		SampleRatio: c.SampleRatio,
+	}
+}
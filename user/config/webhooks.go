@@ -0,0 +1,21 @@
+package config
+
+import (
+	"time"
+)
+
+// WebhooksConfig is the dispatcher delivering the domain events to the webhook subscribers
+type WebhooksConfig struct {
+	// DispatchInterval is how often the webhook dispatcher looks for due deliveries
+	DispatchInterval time.Duration `yaml:"dispatch_interval" toml:"dispatch_interval" env:"USER_WEBHOOK_DISPATCH_INTERVAL" flag:"webhook-dispatch-interval" usage:"how often the webhook dispatcher looks for due deliveries"`
+	// BatchSize is the maximum number of webhook deliveries attempted by each dispatcher run
+	BatchSize int `yaml:"batch_size" toml:"batch_size" env:"USER_WEBHOOK_BATCH_SIZE" flag:"webhook-batch-size" usage:"maximum number of webhook deliveries attempted by each dispatcher run"`
+	// Timeout is how long a subscriber has to answer a webhook delivery
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"USER_WEBHOOK_TIMEOUT" flag:"webhook-timeout" usage:"how long a subscriber has to answer a webhook delivery"`
+	// MaxAttempts is how many times a webhook delivery is attempted before it is dead-lettered
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts" env:"USER_WEBHOOK_MAX_ATTEMPTS" flag:"webhook-max-attempts" usage:"how many times a webhook delivery is attempted before it is dead-lettered"`
+	// Backoff is the wait after the first failed webhook delivery attempt, it doubles after every attempt
+	Backoff time.Duration `yaml:"backoff" toml:"backoff" env:"USER_WEBHOOK_BACKOFF" flag:"webhook-backoff" usage:"wait after the first failed webhook delivery attempt, it doubles after every attempt"`
+	// MaxBackoff is the longest wait between two webhook delivery attempts
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"USER_WEBHOOK_MAX_BACKOFF" flag:"webhook-max-backoff" usage:"longest wait between two webhook delivery attempts"`
+}
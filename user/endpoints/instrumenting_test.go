@@ -0,0 +1,44 @@
+package endpoints
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func TestInstrument(t *testing.T) {
+	c := require.New(t)
+
+	var e UserEndpoints
+	fields := reflect.ValueOf(&e).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		fields.Field(i).Set(reflect.ValueOf(endpoint.Nop))
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"method", "code"})
+	instrumented := reflect.ValueOf(Instrument(e, sharedLib.RequestMetrics{
+		Requests: kitprometheus.NewCounter(requests),
+		Errors:   discard.NewCounter(),
+		Duration: discard.NewHistogram(),
+	}))
+
+	for i := 0; i < instrumented.NumField(); i++ {
+		name := instrumented.Type().Field(i).Name
+
+		instrumentedEndpoint, ok := instrumented.Field(i).Interface().(endpoint.Endpoint)
+		c.True(ok && instrumentedEndpoint != nil, name)
+
+		_, err := instrumentedEndpoint(context.Background(), nil)
+		c.NoError(err)
+		c.Equal(float64(1), testutil.ToFloat64(requests.WithLabelValues(name, sharedLib.CodeOK)), name)
+	}
+}
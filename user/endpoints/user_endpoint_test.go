@@ -1,11 +1,13 @@
 package endpoints
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
@@ -42,12 +44,17 @@ func TestMakeCreateUserEndpoint(t *testing.T) {
 	intAge, err := strconv.Atoi(req.Age)
 	c.NoError(err)
 
+	mock.ExpectBegin()
+
 	sqlString := regexp.QuoteMeta(repository.InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), req.Name, sqlmock.AnyArg(), intAge, req.AdditionalInformation).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), req.Name, sqlmock.AnyArg(), intAge, req.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	parentSSQLString := regexp.QuoteMeta(repository.InsertParentStatement)
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), req.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), req.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := createUserEndpoint(context.Background(), req)
 
@@ -79,10 +86,14 @@ func TestMakeAuthenticateEndpoint(t *testing.T) {
 		Password: "testPassword",
 	}
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(repository.PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := authenticatendpoint(context.Background(), req)
 
@@ -116,7 +127,7 @@ func TestMakeGetUserEndpoint(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
 
 	sqlString := regexp.QuoteMeta(repository.UserDataQuery)
 	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
@@ -158,9 +169,19 @@ func TestMakeUpdateUserEndpoint(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
+	storedRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(storedRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
 	sqlUpdateString := regexp.QuoteMeta(repository.UpdateUserStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, user.AdditionalInformation, user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, sqlmock.AnyArg(), true, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	sqlDeleteString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
 
@@ -171,7 +192,7 @@ func TestMakeUpdateUserEndpoint(t *testing.T) {
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation, time.Time{}, time.Time{}, "", "", nil, false)
 
 	sqlSelectString := regexp.QuoteMeta(repository.UserDataQuery)
 
@@ -182,6 +203,9 @@ func TestMakeUpdateUserEndpoint(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parent[0]).AddRow(user.Parent[1])
 
 	mock.ExpectQuery(parentSSQLString).WithArgs(user.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := updateendpoint(context.Background(), user)
 
@@ -207,11 +231,17 @@ func TestMakeDeleteEndpoint(t *testing.T) {
 		Id: "USR123",
 	}
 
-	sqlString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
 
-	parentSSQLString := regexp.QuoteMeta(repository.DeleteUserStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlString := regexp.QuoteMeta(repository.DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := deletendpoint(context.Background(), req)
 
@@ -222,6 +252,257 @@ func TestMakeDeleteEndpoint(t *testing.T) {
 	c.Equal(errBadRequest, err)
 }
 
+func TestMakeRestoreUserEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	restoreendpoint := makeRestoreUserEndpoint(svc)
+
+	req := &pb.RestoreUserRequest{
+		Id: "USR123",
+	}
+
+	user := shared.User{
+		ID:                    "USR123",
+		Name:                  "test",
+		Age:                   99,
+		AdditionalInformation: "not much",
+		Parents:               []string{"John Doe", "Jane Doe"},
+	}
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(repository.RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LiveUserNameCountQuery)).WithArgs(req.Id).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), req.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.UserDataQuery)
+	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+
+	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parents[0]).AddRow(user.Parents[1])
+
+	mock.ExpectQuery(parentSSQLString).WithArgs(req.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := restoreendpoint(context.Background(), req)
+
+	c.Equal(user, result.(shared.User))
+	c.NoError(err)
+
+	_, err = restoreendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeListUsersEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	listendpoint := makeListUsersEndpoint(svc)
+
+	req := &pb.ListUsersRequest{
+		CreatedBy: "admin",
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).
+		AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "admin", "admin", nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.ListUsersQuery + " AND created_by = ? ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("admin", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+	mock.ExpectQuery(parentSSQLString).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	result, err := listendpoint(context.Background(), req)
+
+	c.Equal("USR123", result.(shared.UserPage).Users[0].ID)
+	c.NoError(err)
+
+	_, err = listendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeListAuditEventsEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	auditendpoint := makeListAuditEventsEndpoint(svc)
+
+	req := &pb.ListAuditEventsRequest{
+		Target: "USR123",
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target", "changes", "request_id", "client_ip", "outcome", "reason"}).
+		AddRow(1, time.Time{}, "admin", shared.AuditActionCreateUser, "USR123", "null", "", "", shared.AuditOutcomeSuccess, "")
+
+	sqlString := regexp.QuoteMeta(repository.ListAuditEventsQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(req.Target, repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	result, err := auditendpoint(context.Background(), req)
+
+	c.Equal(int64(1), result.(shared.AuditEventPage).Events[0].ID)
+	c.NoError(err)
+
+	_, err = auditendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeWatchUsersEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	watchendpoint := makeWatchUsersEndpoint(svc)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(2, shared.EventUserCreated, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+
+	sqlString := regexp.QuoteMeta(repository.ListEventsQuery + " AND event_type IN (?, ?, ?, ?) ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WillReturnRows(rows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []shared.Event
+	_, err := watchendpoint(ctx, WatchUsersRequest{
+		Request: &pb.WatchUsersRequest{Cursor: "1"},
+		Send: func(event shared.Event) error {
+			received = append(received, event)
+			cancel()
+
+			return nil
+		},
+	})
+	c.NoError(err)
+	c.Len(received, 1)
+	c.Equal(int64(2), received[0].ID)
+
+	_, err = watchendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+
+	_, err = watchendpoint(context.Background(), WatchUsersRequest{Request: &pb.WatchUsersRequest{}})
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeCreateWebhookSubscriptionEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	webhookendpoint := makeCreateWebhookSubscriptionEndpoint(svc)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertWebhookSubscriptionStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := webhookendpoint(shared.ContextWithAdmin(context.Background(), true), &pb.CreateWebhookSubscriptionRequest{
+		Url:        "https://93.184.216.34/hook",
+		EventTypes: []string{shared.EventUserCreated},
+		Secret:     "secret",
+	})
+	c.NoError(err)
+	c.Equal("secret", result.(shared.WebhookSubscription).Secret)
+
+	_, err = webhookendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeListWebhookSubscriptionsEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	webhookendpoint := makeListWebhookSubscriptionsEndpoint(svc)
+
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "created_at", "created_by"}).
+		AddRow("WHK123", "https://example.com/hook", `["user.created"]`, time.Time{}, "admin")
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.ListWebhookSubscriptionsQuery)).WillReturnRows(rows)
+
+	result, err := webhookendpoint(shared.ContextWithAdmin(context.Background(), true), &pb.ListWebhookSubscriptionsRequest{})
+	c.NoError(err)
+	c.Equal("WHK123", result.([]shared.WebhookSubscription)[0].ID)
+
+	_, err = webhookendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeDeleteWebhookSubscriptionEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, _ := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	webhookendpoint := makeDeleteWebhookSubscriptionEndpoint(svc)
+
+	_, err := webhookendpoint(shared.ContextWithAdmin(context.Background(), true), &pb.DeleteWebhookSubscriptionRequest{})
+	c.Equal(shared.ErrMissingSubscriptionID, err)
+
+	_, err = webhookendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
+func TestMakeListWebhookDeliveriesEndpoint(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	webhookendpoint := makeListWebhookDeliveriesEndpoint(svc)
+
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at"}).
+		AddRow(1, "WHK123", 7, shared.EventUserCreated, shared.WebhookDeliverySucceeded, 1, time.Time{}, time.Time{}, 200, "", time.Time{})
+
+	sqlString := regexp.QuoteMeta(repository.ListWebhookDeliveriesQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("WHK123", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	result, err := webhookendpoint(shared.ContextWithAdmin(context.Background(), true), &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123"})
+	c.NoError(err)
+	c.Equal(shared.WebhookDeliverySucceeded, result.(shared.WebhookDeliveryPage).Deliveries[0].Status)
+
+	_, err = webhookendpoint(context.Background(), "bad request")
+	c.Equal(errBadRequest, err)
+}
+
 func TestMakeEndpoints(t *testing.T) {
 	c := require.New(t)
 
@@ -244,13 +525,31 @@ func TestMakeEndpoints(t *testing.T) {
 		Password: "testPassword",
 	}
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(repository.PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := endpoints.Authenticate(context.Background(), req)
 
 	c.Equal("User authenticated!", result.(string))
 	c.NoError(err)
 }
+
+func TestPatchUserRequestIsRedacted(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := shared.NewRedactingLogger(log.NewJSONLogger(&buffer), shared.DefaultPIIFields)
+
+	logger.Log("request", PatchUserRequest{User: shared.User{ID: "USR123", Password: "hunter2", Name: "Jane Doe"}, Paths: []string{"name"}})
+
+	output := buffer.String()
+	c.NotContains(output, "hunter2")
+	c.NotContains(output, "Jane Doe")
+	c.Contains(output, "USR123")
+}
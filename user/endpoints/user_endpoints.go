@@ -8,6 +8,8 @@ import (
 
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
 	"github.com/jumaroar-globant/go-bootcamp/user/service"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 )
 
 var (
@@ -16,21 +18,60 @@ var (
 
 // UserEndpoints are the user endpoints
 type UserEndpoints struct {
-	Authenticate endpoint.Endpoint
-	CreateUser   endpoint.Endpoint
-	GetUser      endpoint.Endpoint
-	UpdateUser   endpoint.Endpoint
-	DeleteUser   endpoint.Endpoint
+	Authenticate    endpoint.Endpoint
+	CreateUser      endpoint.Endpoint
+	GetUser         endpoint.Endpoint
+	UpdateUser      endpoint.Endpoint
+	PatchUser       endpoint.Endpoint
+	DeleteUser      endpoint.Endpoint
+	RestoreUser     endpoint.Endpoint
+	ListUsers       endpoint.Endpoint
+	ListAuditEvents endpoint.Endpoint
+	WatchUsers      endpoint.Endpoint
+
+	CreateWebhookSubscription endpoint.Endpoint
+	ListWebhookSubscriptions  endpoint.Endpoint
+	DeleteWebhookSubscription endpoint.Endpoint
+	ListWebhookDeliveries     endpoint.Endpoint
+}
+
+// WatchUsersRequest is the request of the WatchUsers endpoint, Send is called for every streamed event
+type WatchUsersRequest struct {
+	Request *pb.WatchUsersRequest
+	Send    func(sharedLib.Event) error
+}
+
+// PatchUserRequest is the request of the PatchUser endpoint, only the fields of User listed in Paths are updated
+type PatchUserRequest struct {
+	User  sharedLib.User
+	Paths []string
+}
+
+// LogValue logs the partial update request without the password of the user
+func (r PatchUserRequest) LogValue() interface{} {
+	return sharedLib.LogFields{"user": r.User, "paths": r.Paths}
 }
 
 // MakeEndpoints func initializes the Endpoint instances
 func MakeEndpoints(s service.UserService) UserEndpoints {
+	validate := sharedLib.ValidationMiddleware(validateRequest)
+
 	return UserEndpoints{
-		Authenticate: makeAuthenticateEndpoint(s),
-		CreateUser:   makeCreateUserEndpoint(s),
-		GetUser:      makeGetUserEndpoint(s),
-		UpdateUser:   makeUpdateUserEndpoint(s),
-		DeleteUser:   makeDeleteUserEndpoint(s),
+		Authenticate:    validate(makeAuthenticateEndpoint(s)),
+		CreateUser:      validate(makeCreateUserEndpoint(s)),
+		GetUser:         validate(makeGetUserEndpoint(s)),
+		UpdateUser:      validate(makeUpdateUserEndpoint(s)),
+		PatchUser:       validate(makePatchUserEndpoint(s)),
+		DeleteUser:      validate(makeDeleteUserEndpoint(s)),
+		RestoreUser:     validate(makeRestoreUserEndpoint(s)),
+		ListUsers:       validate(makeListUsersEndpoint(s)),
+		ListAuditEvents: validate(makeListAuditEventsEndpoint(s)),
+		WatchUsers:      validate(makeWatchUsersEndpoint(s)),
+
+		CreateWebhookSubscription: validate(makeCreateWebhookSubscriptionEndpoint(s)),
+		ListWebhookSubscriptions:  validate(makeListWebhookSubscriptionsEndpoint(s)),
+		DeleteWebhookSubscription: validate(makeDeleteWebhookSubscriptionEndpoint(s)),
+		ListWebhookDeliveries:     validate(makeListWebhookDeliveriesEndpoint(s)),
 	}
 }
 
@@ -78,6 +119,17 @@ func makeUpdateUserEndpoint(s service.UserService) endpoint.Endpoint {
 	}
 }
 
+func makePatchUserEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(PatchUserRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.PatchUser(ctx, req.User, req.Paths)
+	}
+}
+
 func makeDeleteUserEndpoint(s service.UserService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req, ok := request.(*pb.DeleteUserRequest)
@@ -88,3 +140,91 @@ func makeDeleteUserEndpoint(s service.UserService) endpoint.Endpoint {
 		return s.DeleteUser(ctx, req)
 	}
 }
+
+func makeRestoreUserEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.RestoreUserRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.RestoreUser(ctx, req)
+	}
+}
+
+func makeListUsersEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.ListUsersRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListUsers(ctx, req)
+	}
+}
+
+func makeListAuditEventsEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.ListAuditEventsRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListAuditEvents(ctx, req)
+	}
+}
+
+func makeWatchUsersEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(WatchUsersRequest)
+		if !ok || req.Request == nil || req.Send == nil {
+			return nil, errBadRequest
+		}
+
+		return nil, s.WatchUsers(ctx, req.Request, req.Send)
+	}
+}
+
+func makeCreateWebhookSubscriptionEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.CreateWebhookSubscriptionRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.CreateWebhookSubscription(ctx, req)
+	}
+}
+
+func makeListWebhookSubscriptionsEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.ListWebhookSubscriptionsRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListWebhookSubscriptions(ctx, req)
+	}
+}
+
+func makeDeleteWebhookSubscriptionEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.DeleteWebhookSubscriptionRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.DeleteWebhookSubscription(ctx, req)
+	}
+}
+
+func makeListWebhookDeliveriesEndpoint(s service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*pb.ListWebhookDeliveriesRequest)
+		if !ok {
+			return nil, errBadRequest
+		}
+
+		return s.ListWebhookDeliveries(ctx, req)
+	}
+}
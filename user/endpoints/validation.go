@@ -0,0 +1,99 @@
+package endpoints
+
+import (
+	"google.golang.org/genproto/googleapis/type/date"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// validateRequest holds the validation rules of every request, the requests without rules are always valid
+func validateRequest(request interface{}) error {
+	switch req := request.(type) {
+	case *pb.UserAuthRequest:
+		return sharedLib.Validate(
+			sharedLib.Field("username", req.Username, sharedLib.Required()),
+			sharedLib.Field("password", req.Password, sharedLib.Required()),
+		)
+	case *pb.CreateUserRequest:
+		return sharedLib.Validate(
+			sharedLib.Field("name", req.Name, sharedLib.Required()),
+			sharedLib.Field("password", req.Password, sharedLib.Required()),
+			birthRules(req.DateOfBirth, req.Age),
+		)
+	case *pb.UpdateUserRequest:
+		return sharedLib.Validate(
+			sharedLib.Field("id", req.Id, sharedLib.Required()),
+			sharedLib.Field("name", req.Name, sharedLib.Required()),
+			birthRules(req.DateOfBirth, req.Age),
+		)
+	case PatchUserRequest:
+		return sharedLib.Validate(patchRules(req)...)
+	case *pb.GetUserRequest:
+		return sharedLib.Validate(sharedLib.Field("id", req.Id, sharedLib.Required()))
+	case *pb.DeleteUserRequest:
+		return sharedLib.Validate(sharedLib.Field("id", req.Id, sharedLib.Required()))
+	case *pb.RestoreUserRequest:
+		return sharedLib.Validate(sharedLib.Field("id", req.Id, sharedLib.Required()))
+	case *pb.ListUsersRequest:
+		return sharedLib.Validate(sharedLib.Field("page_size", req.PageSize, sharedLib.AtLeast(0)))
+	case *pb.ListAuditEventsRequest:
+		if len(req.Targets) > 0 {
+			return sharedLib.Validate(sharedLib.Field("page_size", req.PageSize, sharedLib.AtLeast(0)))
+		}
+
+		return sharedLib.Validate(
+			sharedLib.Field("target", req.Target, sharedLib.Required()),
+			sharedLib.Field("page_size", req.PageSize, sharedLib.AtLeast(0)),
+		)
+	case WatchUsersRequest:
+		if req.Request == nil {
+			return nil
+		}
+
+		return sharedLib.Validate(sharedLib.Field("cursor", req.Request.Cursor, sharedLib.AtLeast(0)))
+	case *pb.CreateWebhookSubscriptionRequest:
+		return sharedLib.Validate(
+			sharedLib.Field("url", req.Url, sharedLib.Required(), sharedLib.AbsoluteURL("http", "https")),
+			sharedLib.Field("event_types", req.EventTypes, sharedLib.Required(), sharedLib.OneOf(sharedLib.UserEventTypes...)),
+		)
+	case *pb.DeleteWebhookSubscriptionRequest:
+		return sharedLib.Validate(sharedLib.Field("id", req.Id, sharedLib.Required()))
+	case *pb.ListWebhookDeliveriesRequest:
+		return sharedLib.Validate(
+			sharedLib.Field("subscription_id", req.SubscriptionId, sharedLib.Required()),
+			sharedLib.Field("page_size", req.PageSize, sharedLib.AtLeast(0)),
+		)
+	}
+
+	return nil
+}
+
+// patchRules checks the update mask of a partial update and only the fields listed in it
+func patchRules(req PatchUserRequest) []sharedLib.FieldRules {
+	rules := []sharedLib.FieldRules{
+		sharedLib.Field("id", req.User.ID, sharedLib.Required()),
+		sharedLib.Field("update_mask", req.Paths, sharedLib.Required(), sharedLib.OneOf(sharedLib.UserUpdatePaths...)),
+	}
+
+	for _, path := range req.Paths {
+		switch path {
+		case sharedLib.UserPathName:
+			rules = append(rules, sharedLib.Field("name", req.User.Name, sharedLib.Required()))
+		case sharedLib.UserPathDateOfBirth:
+			rules = append(rules, sharedLib.Field("date_of_birth", req.User.DateOfBirth, sharedLib.Required(), sharedLib.BornBetween(sharedLib.MinAge, sharedLib.MaxAge)))
+		}
+	}
+
+	return rules
+}
+
+// birthRules checks the date of birth of a request, or the legacy age when a v1 client sends it instead
+func birthRules(dateOfBirth *date.Date, age string) sharedLib.FieldRules {
+	if dateOfBirth == nil && age != "" {
+		return sharedLib.Field("age", age, sharedLib.Between(sharedLib.MinAge, sharedLib.MaxAge))
+	}
+
+	return sharedLib.Field("date_of_birth", sharedLib.DateFromProto(dateOfBirth), sharedLib.Required(), sharedLib.BornBetween(sharedLib.MinAge, sharedLib.MaxAge))
+}
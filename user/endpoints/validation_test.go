@@ -0,0 +1,124 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/jumaroar-globant/go-bootcamp/user/service"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/date"
+)
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    interface{}
+		violations []shared.FieldViolation
+	}{
+		{
+			name:    "valid create user",
+			request: &pb.CreateUserRequest{Name: "test", Password: "clave123", Age: "99"},
+		},
+		{
+			name:    "create user breaking every rule",
+			request: &pb.CreateUserRequest{Name: " ", Age: "5000"},
+			violations: []shared.FieldViolation{
+				{Field: "name", Message: "is required"},
+				{Field: "password", Message: "is required"},
+				{Field: "age", Message: "must be between 0 and 150"},
+			},
+		},
+		{
+			name:    "update user without name and a negative age",
+			request: &pb.UpdateUserRequest{Id: "USR123", Age: "-1"},
+			violations: []shared.FieldViolation{
+				{Field: "name", Message: "is required"},
+				{Field: "age", Message: "must be between 0 and 150"},
+			},
+		},
+		{
+			name:       "update user with a non numeric age",
+			request:    &pb.UpdateUserRequest{Id: "USR123", Name: "test", Age: "old"},
+			violations: []shared.FieldViolation{{Field: "age", Message: "must be a number"}},
+		},
+		{
+			name:    "create user with a date of birth and no age",
+			request: &pb.CreateUserRequest{Name: "test", Password: "clave123", DateOfBirth: &date.Date{Year: 1990, Month: 6, Day: 5}},
+		},
+		{
+			name:       "update user with a date of birth that does not exist",
+			request:    &pb.UpdateUserRequest{Id: "USR123", Name: "test", Age: "old", DateOfBirth: &date.Date{Year: 1990, Month: 2, Day: 30}},
+			violations: []shared.FieldViolation{{Field: "date_of_birth", Message: "must be a valid date"}},
+		},
+		{
+			name:    "patch user without a date of birth",
+			request: PatchUserRequest{User: shared.User{ID: "USR123"}, Paths: []string{shared.UserPathDateOfBirth, "password"}},
+			violations: []shared.FieldViolation{
+				{Field: "update_mask", Message: `"password" must be one of name, date_of_birth, additional_information, parents`},
+				{Field: "date_of_birth", Message: "is required"},
+			},
+		},
+		{
+			name:       "list users with a negative page size",
+			request:    &pb.ListUsersRequest{PageSize: -1},
+			violations: []shared.FieldViolation{{Field: "page_size", Message: "must be at least 0"}},
+		},
+		{
+			name:       "watch users with a bad cursor",
+			request:    WatchUsersRequest{Request: &pb.WatchUsersRequest{Cursor: "bad"}},
+			violations: []shared.FieldViolation{{Field: "cursor", Message: "must be a number"}},
+		},
+		{
+			name:    "webhook subscription with a bad url and an unknown event type",
+			request: &pb.CreateWebhookSubscriptionRequest{Url: "ftp://example.com", EventTypes: []string{shared.EventUserCreated, "user.exploded"}},
+			violations: []shared.FieldViolation{
+				{Field: "url", Message: "must be an absolute http or https url"},
+				{Field: "event_types", Message: `"user.exploded" must be one of user.created, user.updated, user.deleted, user.restored, user.authenticated`},
+			},
+		},
+		{
+			name:    "request without rules",
+			request: &pb.ListWebhookSubscriptionsRequest{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := require.New(t)
+
+			err := validateRequest(tt.request)
+			if tt.violations == nil {
+				c.NoError(err)
+				return
+			}
+
+			c.True(errors.Is(err, shared.ErrInvalidRequest))
+			c.Equal(tt.violations, shared.AsError(err).FieldViolations())
+		})
+	}
+}
+
+func TestMakeEndpointsValidatesRequests(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := MakeEndpoints(svc)
+
+	_, err := userEndpoints.UpdateUser(context.Background(), &pb.UpdateUserRequest{Id: "USR123", Age: "5000"})
+	c.True(errors.Is(err, shared.ErrInvalidRequest))
+	c.Len(shared.AsError(err).FieldViolations(), 2)
+	c.NoError(mock.ExpectationsWereMet())
+}
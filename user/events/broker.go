@@ -0,0 +1,290 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var (
+	ErrNATSProtocol = errors.New("unexpected answer of the NATS server")
+)
+
+// NATSConn is the part of a NATS connection used to publish events
+type NATSConn interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+type natsPublisher struct {
+	conn          NATSConn
+	subjectPrefix string
+}
+
+// NewNATSPublisher is the constructor of a Publisher that sends every event to the subject prefix.event_type
+func NewNATSPublisher(conn NATSConn, subjectPrefix string) Publisher {
+	return &natsPublisher{
+		conn:          conn,
+		subjectPrefix: subjectPrefix,
+	}
+}
+
+// Publish is the natsPublisher method to send an event to its subject
+func (p *natsPublisher) Publish(ctx context.Context, event sharedLib.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.conn.Publish(ctx, p.subjectPrefix+"."+event.Type, data)
+}
+
+// NATSOptions are the server and credentials of a NATSClient
+type NATSOptions struct {
+	// Address is the host:port of the NATS server
+	Address string
+	// Timeout bounds the dial and every publish
+	Timeout time.Duration
+	// TLS is used whenever it is set, a server requiring TLS is verified against the system roots when it is nil
+	TLS *tls.Config
+	// Username and Password, or Token, authenticate the client
+	Username string
+	Password string
+	Token    string
+}
+
+// NATSClient is a connection to a NATS server speaking the core protocol, every publish waits for the server's PONG
+type NATSClient struct {
+	options NATSOptions
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSClient is the constructor of a NATSClient, it dials the server on the first publish and again after a failure
+func NewNATSClient(options NATSOptions) *NATSClient {
+	return &NATSClient{
+		options: options,
+	}
+}
+
+// Publish is the NATSClient method to send data to a subject, it gives up when ctx is done
+func (c *NATSClient) Publish(ctx context.Context, subject string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			c.closeConn()
+			return contextError(ctx, err)
+		}
+	}
+
+	stop := c.watch(ctx)
+	err := c.send(fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data))
+	stop()
+
+	if err != nil {
+		c.closeConn()
+		return contextError(ctx, err)
+	}
+
+	return nil
+}
+
+// Close is the NATSClient method to close its connection
+func (c *NATSClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closeConn()
+}
+
+func (c *NATSClient) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.options.Timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", c.options.Address)
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	stop := c.watch(ctx)
+	defer stop()
+
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(line, "INFO ") {
+		return ErrNATSProtocol
+	}
+
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return err
+	}
+
+	secure := info.TLSRequired || c.options.TLS != nil
+	if secure {
+		tlsConn := tls.Client(conn, c.tlsConfig())
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+
+		c.conn = tlsConn
+		c.reader = bufio.NewReader(tlsConn)
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: secure,
+		Name:        "user-service",
+		Lang:        "go",
+		User:        c.options.Username,
+		Pass:        c.options.Password,
+		AuthToken:   c.options.Token,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.send(fmt.Sprintf("CONNECT %s\r\nPING\r\n", connect))
+}
+
+// natsConnect is the CONNECT message of the NATS protocol
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// tlsConfig returns the TLS configuration of the options, verifying the server against the NATS host unless it names another
+func (c *NATSClient) tlsConfig() *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.options.TLS != nil {
+		config = c.options.TLS.Clone()
+	}
+
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(c.options.Address)
+		if err != nil {
+			host = c.options.Address
+		}
+
+		config.ServerName = host
+	}
+
+	return config
+}
+
+// watch bounds the reads and writes on the connection by the timeout and by ctx until stop is called
+func (c *NATSClient) watch(ctx context.Context) (stop func()) {
+	deadline := time.Now().Add(c.options.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	conn := c.conn
+	conn.SetDeadline(deadline)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		select {
+		case <-ctx.Done():
+			// a deadline in the past unblocks the read or write in progress
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// send writes the commands, which end with a PING, and waits for the PONG of the server
+func (c *NATSClient) send(commands string) error {
+	if _, err := c.conn.Write([]byte(commands)); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := c.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("%w: %s", ErrNATSProtocol, strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (c *NATSClient) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *NATSClient) closeConn() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+
+	return err
+}
+
+// contextError returns the error of ctx when it is done, it is what made the connection fail
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	// the connection deadline can pass a moment before ctx reports it
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
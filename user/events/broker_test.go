@@ -0,0 +1,254 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var errBrokerDown = errors.New("broker down")
+
+// natsStandIn records what would have been sent to a NATS server
+type natsStandIn struct {
+	subjects []string
+	messages [][]byte
+	fail     bool
+}
+
+func (n *natsStandIn) Publish(ctx context.Context, subject string, data []byte) error {
+	if n.fail {
+		return errBrokerDown
+	}
+
+	n.subjects = append(n.subjects, subject)
+	n.messages = append(n.messages, data)
+
+	return nil
+}
+
+func TestNATSPublisher(t *testing.T) {
+	c := require.New(t)
+
+	conn := &natsStandIn{}
+
+	publisher := NewNATSPublisher(conn, "users")
+
+	err := publisher.Publish(context.Background(), testEvent)
+	c.NoError(err)
+	c.Equal([]string{"users.user.created"}, conn.subjects)
+
+	var event sharedLib.Event
+	c.NoError(json.Unmarshal(conn.messages[0], &event))
+	c.Equal(testEvent, event)
+
+	conn.fail = true
+
+	err = publisher.Publish(context.Background(), testEvent)
+	c.Equal(errBrokerDown, err)
+}
+
+// natsServerStandIn is a local NATS server speaking enough of the core protocol to receive the published messages
+type natsServerStandIn struct {
+	listener net.Listener
+	info     string
+	messages chan string
+	connects chan string
+	// tls upgrades the connections once the INFO is sent
+	tls *tls.Config
+	// stalled makes the server read the commands without ever answering them
+	stalled bool
+}
+
+func newNATSServerStandIn(t *testing.T, info string) *natsServerStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	return &natsServerStandIn{listener: listener, info: info, messages: make(chan string, 10), connects: make(chan string, 10)}
+}
+
+func (s *natsServerStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *natsServerStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO %s\r\n", s.info)
+
+	if s.tls != nil {
+		tlsConn := tls.Server(conn, s.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+
+		conn = tlsConn
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0 || s.stalled:
+		case fields[0] == "CONNECT":
+			s.connects <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+		case fields[0] == "PING":
+			fmt.Fprint(conn, "PING\r\nPONG\r\n")
+		case fields[0] == "PUB" && fields[1] == "forbidden":
+			fmt.Fprint(conn, "-ERR 'Permissions Violation for Publish to forbidden'\r\n")
+		case fields[0] == "PUB":
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			s.messages <- fields[1] + " " + string(payload[:size])
+		}
+	}
+}
+
+func TestNATSClient(t *testing.T) {
+	c := require.New(t)
+
+	server := newNATSServerStandIn(t, `{"server_id":"stand-in"}`)
+	go server.serve()
+
+	client := NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Second})
+	defer client.Close()
+
+	publisher := NewNATSPublisher(client, "users")
+	c.NoError(publisher.Publish(context.Background(), testEvent))
+
+	message := <-server.messages
+	c.True(strings.HasPrefix(message, "users.user.created {"), message)
+
+	var event sharedLib.Event
+	c.NoError(json.Unmarshal([]byte(strings.TrimPrefix(message, "users.user.created ")), &event))
+	c.Equal(testEvent, event)
+
+	err := client.Publish(context.Background(), "forbidden", []byte("{}"))
+	c.True(errors.Is(err, ErrNATSProtocol))
+	c.Contains(err.Error(), "Permissions Violation")
+
+	c.NoError(client.Publish(context.Background(), "users.again", []byte("{}")), "the client dials again after a failure")
+	c.Equal("users.again {}", <-server.messages)
+}
+
+func TestNATSClientCredentials(t *testing.T) {
+	c := require.New(t)
+
+	server := newNATSServerStandIn(t, `{"server_id":"stand-in","auth_required":true}`)
+	go server.serve()
+
+	client := NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Second, Username: "user-service", Password: "secret"})
+	defer client.Close()
+
+	c.NoError(client.Publish(context.Background(), "users", []byte("{}")))
+
+	var connect natsConnect
+	c.NoError(json.Unmarshal([]byte(<-server.connects), &connect))
+	c.Equal("user-service", connect.User)
+	c.Equal("secret", connect.Pass)
+	c.Empty(connect.AuthToken)
+
+	client = NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Second, Token: "token"})
+	defer client.Close()
+
+	c.NoError(client.Publish(context.Background(), "users", []byte("{}")))
+
+	connect = natsConnect{}
+	c.NoError(json.Unmarshal([]byte(<-server.connects), &connect))
+	c.Equal("token", connect.AuthToken)
+	c.Empty(connect.User)
+}
+
+func TestNATSClientTLS(t *testing.T) {
+	c := require.New(t)
+
+	// the httptest server provides a certificate for 127.0.0.1 and a client trusting it
+	certificates := httptest.NewUnstartedServer(http.NotFoundHandler())
+	certificates.StartTLS()
+	defer certificates.Close()
+
+	server := newNATSServerStandIn(t, `{"server_id":"stand-in","tls_required":true}`)
+	server.tls = certificates.TLS
+	go server.serve()
+
+	roots := certificates.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	client := NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Second, TLS: &tls.Config{RootCAs: roots}})
+	defer client.Close()
+
+	c.NoError(client.Publish(context.Background(), "users", []byte("{}")))
+	c.Equal("users {}", <-server.messages)
+
+	var connect natsConnect
+	c.NoError(json.Unmarshal([]byte(<-server.connects), &connect))
+	c.True(connect.TLSRequired)
+
+	err := NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Second}).Publish(context.Background(), "users", []byte("{}"))
+	c.Error(err, "the server certificate is not signed by the system roots")
+}
+
+func TestNATSClientContext(t *testing.T) {
+	c := require.New(t)
+
+	server := newNATSServerStandIn(t, `{"server_id":"stand-in"}`)
+	server.stalled = true
+	go server.serve()
+
+	client := NewNATSClient(NATSOptions{Address: server.listener.Addr().String(), Timeout: time.Minute})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	c.Equal(context.DeadlineExceeded, client.Publish(ctx, "users", []byte("{}")))
+	c.Less(int64(time.Since(start)), int64(time.Second))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c.Equal(context.Canceled, client.Publish(ctx, "users", []byte("{}")))
+
+	c.Equal(context.Canceled, client.Publish(ctx, "users", []byte("{}")), "a done context is not dialled")
+}
+
+func TestNATSClientFails(t *testing.T) {
+	c := require.New(t)
+
+	server := newNATSServerStandIn(t, `{"server_id":"stand-in"}`)
+	address := server.listener.Addr().String()
+	server.listener.Close()
+
+	c.Error(NewNATSClient(NATSOptions{Address: address, Timeout: time.Second}).Publish(context.Background(), "users", []byte("{}")))
+}
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+type filePublisher struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFilePublisher is the constructor of a Publisher that appends every event to a file, one JSON document per line
+func NewFilePublisher(path string) Publisher {
+	return &filePublisher{
+		path: path,
+	}
+}
+
+// Publish is the filePublisher method to append an event to the file
+func (p *filePublisher) Publish(_ context.Context, event sharedLib.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func TestFilePublisher(t *testing.T) {
+	c := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	publisher := NewFilePublisher(path)
+
+	second := testEvent
+	second.ID = 2
+	second.Type = sharedLib.EventUserDeleted
+
+	c.NoError(publisher.Publish(context.Background(), testEvent))
+	c.NoError(publisher.Publish(context.Background(), second))
+
+	file, err := os.Open(path)
+	c.NoError(err)
+	defer file.Close()
+
+	var published []sharedLib.Event
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event sharedLib.Event
+		c.NoError(json.Unmarshal(scanner.Bytes(), &event))
+
+		published = append(published, event)
+	}
+
+	c.Equal([]sharedLib.Event{testEvent, second}, published)
+}
+
+func TestFilePublisherFails(t *testing.T) {
+	c := require.New(t)
+
+	publisher := NewFilePublisher(filepath.Join(t.TempDir(), "missing", "events.jsonl"))
+
+	err := publisher.Publish(context.Background(), testEvent)
+	c.Error(err)
+}
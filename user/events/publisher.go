@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var (
+	ErrPublisherClosed = errors.New("publisher closed")
+)
+
+// Publisher delivers domain events to their consumers
+type Publisher interface {
+	Publish(ctx context.Context, event sharedLib.Event) error
+}
+
+type logPublisher struct {
+	logger log.Logger
+}
+
+// NewLogPublisher is the constructor of a Publisher that writes every event to the logger
+func NewLogPublisher(logger log.Logger) Publisher {
+	return &logPublisher{
+		logger: log.With(logger, "publisher", "log"),
+	}
+}
+
+// Publish is the logPublisher method to log an event
+func (p *logPublisher) Publish(_ context.Context, event sharedLib.Event) error {
+	return level.Info(p.logger).Log(
+		"event_id", event.ID,
+		"event_type", event.Type,
+		"aggregate_id", event.AggregateID,
+		"actor", event.Actor,
+		"payload", string(event.Payload),
+	)
+}
+
+type channelPublisher struct {
+	events chan<- sharedLib.Event
+	done   <-chan struct{}
+}
+
+// NewChannelPublisher is the constructor of a Publisher that sends every event to an in-process channel
+func NewChannelPublisher(events chan<- sharedLib.Event, done <-chan struct{}) Publisher {
+	return &channelPublisher{
+		events: events,
+		done:   done,
+	}
+}
+
+// Publish is the channelPublisher method to send an event to the channel
+func (p *channelPublisher) Publish(ctx context.Context, event sharedLib.Event) error {
+	// a closed publisher must not accept events even when the channel has room for them
+	select {
+	case <-p.done:
+		return ErrPublisherClosed
+	default:
+	}
+
+	select {
+	case p.events <- event:
+		return nil
+	case <-p.done:
+		return ErrPublisherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type multiPublisher struct {
+	publishers []Publisher
+}
+
+// NewMultiPublisher is the constructor of a Publisher that publishes every event to all the publishers in order
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return &multiPublisher{
+		publishers: publishers,
+	}
+}
+
+// Publish is the multiPublisher method to publish an event to every publisher
+func (p *multiPublisher) Publish(ctx context.Context, event sharedLib.Event) error {
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var testEvent = sharedLib.Event{
+	ID:          1,
+	Type:        sharedLib.EventUserCreated,
+	AggregateID: "USR123",
+	Actor:       "admin",
+	Payload:     json.RawMessage(`{"id":"USR123"}`),
+}
+
+func TestLogPublisher(t *testing.T) {
+	c := require.New(t)
+
+	var buf bytes.Buffer
+
+	publisher := NewLogPublisher(log.NewJSONLogger(&buf))
+
+	err := publisher.Publish(context.Background(), testEvent)
+	c.NoError(err)
+
+	c.Contains(buf.String(), `"event_type":"user.created"`)
+	c.Contains(buf.String(), `"aggregate_id":"USR123"`)
+}
+
+func TestChannelPublisher(t *testing.T) {
+	c := require.New(t)
+
+	events := make(chan sharedLib.Event, 1)
+	done := make(chan struct{})
+
+	publisher := NewChannelPublisher(events, done)
+
+	err := publisher.Publish(context.Background(), testEvent)
+	c.NoError(err)
+	c.Equal(testEvent, <-events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = NewChannelPublisher(make(chan sharedLib.Event), done).Publish(ctx, testEvent)
+	c.Equal(context.Canceled, err)
+
+	close(done)
+
+	err = NewChannelPublisher(make(chan sharedLib.Event), done).Publish(context.Background(), testEvent)
+	c.Equal(ErrPublisherClosed, err)
+}
+
+func TestMultiPublisher(t *testing.T) {
+	c := require.New(t)
+
+	first := &natsStandIn{}
+	second := &natsStandIn{}
+
+	publisher := NewMultiPublisher(NewNATSPublisher(first, "first"), NewNATSPublisher(second, "second"))
+
+	err := publisher.Publish(context.Background(), testEvent)
+	c.NoError(err)
+	c.Equal([]string{"first.user.created"}, first.subjects)
+	c.Equal([]string{"second.user.created"}, second.subjects)
+
+	first.fail = true
+
+	err = publisher.Publish(context.Background(), testEvent)
+	c.Equal(errBrokerDown, err)
+	c.Len(second.subjects, 1, "the publishers after a failure are not called")
+}
@@ -0,0 +1,7 @@
+DROP INDEX users_updated_at_idx ON users;
+DROP INDEX users_created_at_idx ON users;
+ALTER TABLE users
+    DROP COLUMN updated_by,
+    DROP COLUMN created_by,
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at;
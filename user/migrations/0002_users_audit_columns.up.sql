@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN updated_by VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX users_created_at_idx ON users (created_at);
+CREATE INDEX users_updated_at_idx ON users (updated_at);
@@ -0,0 +1,11 @@
+CREATE TABLE outbox_events (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    occurred_at DATETIME(6) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSON NOT NULL,
+    published_at DATETIME(6) NULL,
+    INDEX outbox_events_pending_idx (published_at, id)
+);
@@ -0,0 +1,27 @@
+CREATE TABLE webhook_subscriptions (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    event_types JSON NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    deleted_at DATETIME(6) NULL DEFAULT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    subscription_id VARCHAR(255) NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSON NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6) NOT NULL,
+    last_attempt_at DATETIME(6) NULL DEFAULT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    UNIQUE KEY webhook_deliveries_event_idx (subscription_id, event_id),
+    INDEX webhook_deliveries_due_idx (status, next_attempt_at),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN date_of_birth DATE NULL DEFAULT NULL;
+UPDATE users
+    SET date_of_birth = DATE_SUB(CURDATE(), INTERVAL age YEAR)
+    WHERE date_of_birth IS NULL AND age IS NOT NULL;
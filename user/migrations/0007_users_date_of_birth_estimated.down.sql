@@ -0,0 +1,2 @@
+ALTER TABLE users
+    DROP COLUMN date_of_birth_estimated;
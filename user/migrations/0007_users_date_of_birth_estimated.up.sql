@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN date_of_birth_estimated BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users
+    SET date_of_birth_estimated = TRUE
+    WHERE age IS NOT NULL AND date_of_birth IS NOT NULL AND DATE_ADD(date_of_birth, INTERVAL age YEAR) >= DATE(updated_at);
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// auditedChange is a change to the database whose audit event is written in the same transaction
+type auditedChange func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error)
+
+// withAudit runs the change in a transaction together with its audit event, a failed change is audited on its own
+func (r *userRepository) withAudit(ctx context.Context, action string, target string, change auditedChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	changes, err := change(tx)
+	if err != nil {
+		tx.Rollback()
+		r.recordFailure(ctx, action, target, err)
+
+		return err
+	}
+
+	err = insertAuditEvent(ctx, tx, newAuditEvent(ctx, action, target, changes, nil))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// recordFailure audits a failed operation, it only logs when the audit event can not be written
+func (r *userRepository) recordFailure(ctx context.Context, action string, target string, cause error) {
+	err := insertAuditEvent(ctx, r.db, newAuditEvent(ctx, action, target, nil, cause))
+	if err != nil {
+		level.Error(sharedLib.ContextLogger(ctx, r.logger)).Log("error_writing_audit_event", err, "action", action)
+	}
+}
+
+func newAuditEvent(ctx context.Context, action string, target string, changes map[string]sharedLib.AuditChange, cause error) sharedLib.AuditEvent {
+	event := sharedLib.AuditEvent{
+		OccurredAt: time.Now().UTC(),
+		Actor:      sharedLib.ActorFromContext(ctx),
+		Action:     action,
+		Target:     target,
+		Changes:    changes,
+		RequestID:  sharedLib.RequestIDFromContext(ctx),
+		ClientIP:   sharedLib.ClientIPFromContext(ctx),
+		Outcome:    sharedLib.AuditOutcomeSuccess,
+	}
+
+	if cause != nil {
+		event.Outcome = sharedLib.AuditOutcomeFailure
+		event.Reason = cause.Error()
+	}
+
+	return event
+}
+
+func insertAuditEvent(ctx context.Context, q querier, event sharedLib.AuditEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, InsertAuditEventStatement, event.OccurredAt, event.Actor, event.Action, event.Target, string(changes), event.RequestID, event.ClientIP, event.Outcome, event.Reason)
+
+	return err
+}
+
+// diffUsers returns the user fields that differ between before and after, the password value is never recorded
+func diffUsers(before sharedLib.User, after sharedLib.User) map[string]sharedLib.AuditChange {
+	beforeFields := auditedFields(before)
+	afterFields := auditedFields(after)
+
+	changes := map[string]sharedLib.AuditChange{}
+	for field, beforeValue := range beforeFields {
+		if afterValue := afterFields[field]; afterValue != beforeValue {
+			changes[field] = sharedLib.AuditChange{Before: beforeValue, After: afterValue}
+		}
+	}
+
+	if before.Password != after.Password {
+		change := sharedLib.AuditChange{}
+		if before.Password != "" {
+			change.Before = sharedLib.RedactedValue
+		}
+
+		if after.Password != "" {
+			change.After = sharedLib.RedactedValue
+		}
+
+		changes["password"] = change
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return changes
+}
+
+func auditedFields(user sharedLib.User) map[string]string {
+	fields := map[string]string{
+		"name":                   user.Name,
+		"age":                    "",
+		"date_of_birth":          "",
+		"additional_information": user.AdditionalInformation,
+		"parents":                strings.Join(user.Parents, ", "),
+	}
+
+	if user.ID != "" {
+		fields["age"] = strconv.Itoa(user.Age)
+	}
+
+	if user.DateOfBirth != nil {
+		fields["date_of_birth"] = user.DateOfBirth.String()
+	}
+
+	return fields
+}
+
+// ListAuditEvents is the userRepository method to list the audit events of a target, oldest first
+func (r *userRepository) ListAuditEvents(ctx context.Context, filter sharedLib.AuditEventFilter) (sharedLib.AuditEventPage, error) {
+	pageSize := normalizePageSize(filter.PageSize)
+
+	if len(filter.Targets) > 0 {
+		return r.listFirstAuditEventPages(ctx, filter.Targets, pageSize)
+	}
+
+	query := ListAuditEventsQuery
+	args := []interface{}{filter.Target}
+
+	if filter.PageToken != "" {
+		lastID, err := strconv.ParseInt(filter.PageToken, 10, 64)
+		if err != nil {
+			return sharedLib.AuditEventPage{}, sharedLib.ErrBadPageToken
+		}
+
+		query += " AND id > ?"
+		args = append(args, lastID)
+	}
+
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, pageSize+1)
+
+	events, err := r.queryAuditEvents(ctx, query, args...)
+	if err != nil {
+		return sharedLib.AuditEventPage{}, err
+	}
+
+	page := sharedLib.AuditEventPage{Events: events}
+	if len(page.Events) > pageSize {
+		page.Events = page.Events[:pageSize]
+		page.NextPageToken = strconv.FormatInt(page.Events[pageSize-1].ID, 10)
+	}
+
+	return page, nil
+}
+
+// listFirstAuditEventPages lists the first page of every target in a single query, oldest first
+func (r *userRepository) listFirstAuditEventPages(ctx context.Context, targets []string, pageSize int) (sharedLib.AuditEventPage, error) {
+	query := ListFirstAuditEventPagesQuery + strings.Repeat(", ?", len(targets)-1) + ")) AS pages WHERE position <= ? ORDER BY id"
+
+	args := make([]interface{}, 0, len(targets)+1)
+	for _, target := range targets {
+		args = append(args, target)
+	}
+
+	args = append(args, pageSize+1)
+
+	events, err := r.queryAuditEvents(ctx, query, args...)
+	if err != nil {
+		return sharedLib.AuditEventPage{}, err
+	}
+
+	page := sharedLib.AuditEventPage{NextPageTokens: map[string]string{}}
+
+	counts := map[string]int{}
+	for _, event := range events {
+		counts[event.Target]++
+
+		switch {
+		case counts[event.Target] <= pageSize:
+			page.Events = append(page.Events, event)
+		case counts[event.Target] == pageSize+1:
+			page.NextPageTokens[event.Target] = strconv.FormatInt(lastEventID(page.Events, event.Target), 10)
+		}
+	}
+
+	return page, nil
+}
+
+// lastEventID returns the ID of the last event of the target in events
+func lastEventID(events []sharedLib.AuditEvent, target string) int64 {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Target == target {
+			return events[i].ID
+		}
+	}
+
+	return 0
+}
+
+func (r *userRepository) queryAuditEvents(ctx context.Context, query string, args ...interface{}) ([]sharedLib.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []sharedLib.AuditEvent
+	for rows.Next() {
+		event := sharedLib.AuditEvent{}
+
+		var changes string
+		err := rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.Action, &event.Target, &changes, &event.RequestID, &event.ClientIP, &event.Outcome, &event.Reason)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(changes), &event.Changes); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffUsers(t *testing.T) {
+	c := require.New(t)
+
+	before := sharedLib.User{ID: "USR123", Name: "test", Password: "oldHash", Age: 99, Parents: []string{"John Doe"}}
+	after := sharedLib.User{ID: "USR123", Name: "test", Password: "newHash", Age: 100, Parents: []string{"John Doe", "Jane Doe"}}
+
+	changes := diffUsers(before, after)
+	c.Equal(map[string]sharedLib.AuditChange{
+		"age":      {Before: "99", After: "100"},
+		"parents":  {Before: "John Doe", After: "John Doe, Jane Doe"},
+		"password": {Before: sharedLib.RedactedValue, After: sharedLib.RedactedValue},
+	}, changes)
+
+	changes = diffUsers(sharedLib.User{}, sharedLib.User{ID: "USR123", Name: "test", Password: "hash", Age: 1})
+	c.Equal(sharedLib.AuditChange{After: sharedLib.RedactedValue}, changes["password"])
+	c.Equal(sharedLib.AuditChange{After: "1"}, changes["age"])
+
+	c.Nil(diffUsers(before, before))
+}
+
+func TestNewAuditEvent(t *testing.T) {
+	c := require.New(t)
+
+	ctx := sharedLib.ContextWithActor(context.Background(), "admin")
+	ctx = sharedLib.ContextWithRequestID(ctx, "REQ123")
+	ctx = sharedLib.ContextWithClientIP(ctx, "10.0.0.1")
+
+	event := newAuditEvent(ctx, sharedLib.AuditActionDeleteUser, "USR123", nil, nil)
+	c.Equal("admin", event.Actor)
+	c.Equal("REQ123", event.RequestID)
+	c.Equal("10.0.0.1", event.ClientIP)
+	c.Equal(sharedLib.AuditOutcomeSuccess, event.Outcome)
+	c.Empty(event.Reason)
+
+	event = newAuditEvent(ctx, sharedLib.AuditActionDeleteUser, "USR123", nil, errors.New("boom"))
+	c.Equal(sharedLib.AuditOutcomeFailure, event.Outcome)
+	c.Equal("boom", event.Reason)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target", "changes", "request_id", "client_ip", "outcome", "reason"}).
+		AddRow(11, occurredAt, "admin", sharedLib.AuditActionUpdateUser, "USR123", `{"name":{"before":"old","after":"new"}}`, "REQ123", "10.0.0.1", sharedLib.AuditOutcomeSuccess, "").
+		AddRow(12, occurredAt, "admin", sharedLib.AuditActionDeleteUser, "USR123", "null", "REQ456", "10.0.0.1", sharedLib.AuditOutcomeSuccess, "")
+
+	sqlString := regexp.QuoteMeta(ListAuditEventsQuery + " AND id > ? ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", int64(10), 2).WillReturnRows(rows)
+
+	page, err := userRepo.ListAuditEvents(context.Background(), sharedLib.AuditEventFilter{Target: "USR123", PageSize: 1, PageToken: "10"})
+	c.NoError(err)
+	c.Equal([]sharedLib.AuditEvent{{
+		ID:         11,
+		OccurredAt: occurredAt,
+		Actor:      "admin",
+		Action:     sharedLib.AuditActionUpdateUser,
+		Target:     "USR123",
+		Changes:    map[string]sharedLib.AuditChange{"name": {Before: "old", After: "new"}},
+		RequestID:  "REQ123",
+		ClientIP:   "10.0.0.1",
+		Outcome:    sharedLib.AuditOutcomeSuccess,
+	}}, page.Events)
+	c.Equal("11", page.NextPageToken)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListFirstAuditEventPages(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target", "changes", "request_id", "client_ip", "outcome", "reason"}).
+		AddRow(11, occurredAt, "admin", sharedLib.AuditActionUpdateUser, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "").
+		AddRow(12, occurredAt, "admin", sharedLib.AuditActionUpdateUser, "USR456", "null", "", "", sharedLib.AuditOutcomeSuccess, "").
+		AddRow(13, occurredAt, "admin", sharedLib.AuditActionDeleteUser, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "").
+		AddRow(14, occurredAt, "admin", sharedLib.AuditActionUpdateUser, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "")
+
+	sqlString := regexp.QuoteMeta(ListFirstAuditEventPagesQuery + ", ?, ?)) AS pages WHERE position <= ? ORDER BY id")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", "USR456", "USR789", 3).WillReturnRows(rows)
+
+	page, err := userRepo.ListAuditEvents(context.Background(), sharedLib.AuditEventFilter{Targets: []string{"USR123", "USR456", "USR789"}, PageSize: 2})
+	c.NoError(err)
+
+	var ids []int64
+	for _, event := range page.Events {
+		ids = append(ids, event.ID)
+	}
+
+	c.Equal([]int64{11, 12, 13}, ids)
+	c.Equal(map[string]string{"USR123": "13"}, page.NextPageTokens, "only USR123 has more events than the page size")
+	c.Empty(page.NextPageToken)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListAuditEventsFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	_, err := userRepo.ListAuditEvents(context.Background(), sharedLib.AuditEventFilter{Target: "USR123", PageToken: "bad"})
+	c.Equal(sharedLib.ErrBadPageToken, err)
+
+	sqlString := regexp.QuoteMeta(ListAuditEventsQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", DefaultPageSize+1).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListAuditEvents(context.Background(), sharedLib.AuditEventFilter{Target: "USR123"})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
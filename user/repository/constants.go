@@ -2,19 +2,71 @@ package repository
 
 const (
 	// PasswordHashQuery is a SQL query to obtain a password hash
-	PasswordHashQuery string = "SELECT password_hash FROM users WHERE name=?"
+	PasswordHashQuery string = "SELECT id, password_hash FROM users WHERE name=? AND deleted_at IS NULL"
 	// InsertUserStatement is a SQL statement to insert a user
-	InsertUserStatement string = "INSERT INTO users (id, name, password_hash, age, additional_information) VALUES(?, ?, ?, ?, ?)"
+	InsertUserStatement string = "INSERT INTO users (id, name, password_hash, age, additional_information, created_at, updated_at, created_by, updated_by, date_of_birth, date_of_birth_estimated) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	// InsertParentStatement is an SQL statement to insert a parent
 	InsertParentStatement string = "INSERT INTO user_parents (user_id, name) VALUES(?, ?)"
 	// UpdateUserStatement is an SQL statement to update a user
-	UpdateUserStatement string = "UPDATE users SET name=?, age=?, additional_information=?  WHERE id = ?"
+	UpdateUserStatement string = "UPDATE users SET name=?, age=?, date_of_birth=?, date_of_birth_estimated=?, additional_information=?, updated_at=?, updated_by=? WHERE id = ? AND deleted_at IS NULL"
 	// DeleteUserParentsStatement is an SQL statement to delete a user parents
 	DeleteUserParentsStatement string = "DELETE FROM user_parents WHERE user_id=?"
 	// UserDataQuery is a SQL query to obtain a user data
-	UserDataQuery string = "SELECT id, name, age, additional_information FROM users WHERE id=?"
+	UserDataQuery string = "SELECT id, name, age, additional_information, created_at, updated_at, created_by, updated_by, date_of_birth, date_of_birth_estimated FROM users WHERE id=? AND deleted_at IS NULL"
 	// UserParentsQuery is a SQL query to obtain a user parents
 	UserParentsQuery string = "SELECT name FROM user_parents WHERE user_id=?"
-	//DeleteUserStatement is a SQL statement to delete a user
-	DeleteUserStatement string = "DELETE FROM users WHERE id=?"
+	//DeleteUserStatement is a SQL statement to soft delete a user
+	DeleteUserStatement string = "UPDATE users SET deleted_at=?, updated_at=?, updated_by=? WHERE id=? AND deleted_at IS NULL"
+	// RestoreUserStatement is a SQL statement to restore a soft deleted user
+	RestoreUserStatement string = "UPDATE users SET deleted_at=NULL, updated_at=?, updated_by=? WHERE id=? AND deleted_at IS NOT NULL"
+	// LiveUserNameCountQuery is a SQL query to count the users not deleted that have the name of a user
+	LiveUserNameCountQuery string = "SELECT COUNT(*) FROM users WHERE name=(SELECT name FROM users WHERE id=?) AND deleted_at IS NULL FOR UPDATE"
+	// PurgeableUsersQuery is a SQL query to lock the users soft deleted before a date, so each purged user is audited
+	PurgeableUsersQuery string = "SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE"
+	// PurgeUserParentsStatement is a SQL statement to permanently delete the parents of users soft deleted before a date
+	PurgeUserParentsStatement string = "DELETE FROM user_parents WHERE user_id IN (SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?)"
+	// PurgeUsersStatement is a SQL statement to permanently delete users soft deleted before a date
+	PurgeUsersStatement string = "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+	// ListUsersQuery is a SQL query to list the users not deleted, filters and pagination are appended to it
+	ListUsersQuery string = "SELECT id, name, age, additional_information, created_at, updated_at, created_by, updated_by, date_of_birth, date_of_birth_estimated FROM users WHERE deleted_at IS NULL"
+	// InsertAuditEventStatement is a SQL statement to append an audit event
+	InsertAuditEventStatement string = "INSERT INTO audit_events (occurred_at, actor, action, target, changes, request_id, client_ip, outcome, reason) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	// ListAuditEventsQuery is a SQL query to list the audit events of a target, pagination is appended to it
+	ListAuditEventsQuery string = "SELECT id, occurred_at, actor, action, target, changes, request_id, client_ip, outcome, reason FROM audit_events WHERE target=?"
+	// ListFirstAuditEventPagesQuery is a SQL query to list the first events of several targets, the targets and the page size are appended to it
+	ListFirstAuditEventPagesQuery string = "SELECT id, occurred_at, actor, action, target, changes, request_id, client_ip, outcome, reason FROM (SELECT id, occurred_at, actor, action, target, changes, request_id, client_ip, outcome, reason, ROW_NUMBER() OVER (PARTITION BY target ORDER BY id) AS position FROM audit_events WHERE target IN (?"
+	// InsertOutboxEventStatement is a SQL statement to add a domain event to the outbox
+	InsertOutboxEventStatement string = "INSERT INTO outbox_events (event_type, aggregate_id, occurred_at, actor, request_id, payload) VALUES(?, ?, ?, ?, ?, ?)"
+	// PendingOutboxEventsQuery is a SQL query to obtain the oldest domain events not published yet
+	PendingOutboxEventsQuery string = "SELECT id, event_type, aggregate_id, occurred_at, actor, request_id, payload FROM outbox_events WHERE published_at IS NULL ORDER BY id LIMIT ?"
+	// MarkOutboxEventPublishedStatement is a SQL statement to flag a domain event as published
+	MarkOutboxEventPublishedStatement string = "UPDATE outbox_events SET published_at=? WHERE id=?"
+	// ListEventsQuery is a SQL query to list the domain events after a cursor, filters and the limit are appended to it
+	ListEventsQuery string = "SELECT id, event_type, aggregate_id, occurred_at, actor, request_id, payload FROM outbox_events WHERE id > ?"
+	// LatestEventIDQuery is a SQL query to obtain the ID of the most recent domain event
+	LatestEventIDQuery string = "SELECT COALESCE(MAX(id), 0) FROM outbox_events"
+	// InsertWebhookSubscriptionStatement is a SQL statement to insert a webhook subscription
+	InsertWebhookSubscriptionStatement string = "INSERT INTO webhook_subscriptions (id, url, event_types, secret, created_at, created_by) VALUES(?, ?, ?, ?, ?, ?)"
+	// ListWebhookSubscriptionsQuery is a SQL query to list the webhook subscriptions not deleted
+	ListWebhookSubscriptionsQuery string = "SELECT id, url, event_types, created_at, created_by FROM webhook_subscriptions WHERE deleted_at IS NULL ORDER BY created_at, id"
+	// DeleteWebhookSubscriptionStatement is a SQL statement to soft delete a webhook subscription
+	DeleteWebhookSubscriptionStatement string = "UPDATE webhook_subscriptions SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+	// CancelWebhookDeliveriesStatement is a SQL statement to cancel the deliveries of a subscription that are still in a status
+	CancelWebhookDeliveriesStatement string = "UPDATE webhook_deliveries SET status=? WHERE subscription_id=? AND status=?"
+	// EnqueueWebhookDeliveriesStatement is a SQL statement to add a delivery of an event for every subscription to its type, ignoring the ones already added
+	EnqueueWebhookDeliveriesStatement string = "INSERT IGNORE INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, last_error, created_at) SELECT id, ?, ?, ?, ?, ?, '', ? FROM webhook_subscriptions WHERE deleted_at IS NULL AND JSON_CONTAINS(event_types, JSON_QUOTE(?))"
+	// DueWebhookDeliveriesQuery is a SQL query to obtain the pending webhook deliveries whose next attempt is due, together with where to send them
+	DueWebhookDeliveriesQuery string = "SELECT d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, d.created_at, s.url, s.secret FROM webhook_deliveries d JOIN webhook_subscriptions s ON s.id = d.subscription_id WHERE d.status=? AND d.next_attempt_at <= ? AND s.deleted_at IS NULL ORDER BY d.next_attempt_at, d.id LIMIT ?"
+	// UpdateWebhookDeliveryStatement is a SQL statement to record the outcome of a webhook delivery attempt
+	UpdateWebhookDeliveryStatement string = "UPDATE webhook_deliveries SET status=?, attempts=?, next_attempt_at=?, last_attempt_at=?, response_status=?, last_error=? WHERE id=?"
+	// ListWebhookDeliveriesQuery is a SQL query to list the deliveries of a webhook subscription, pagination is appended to it
+	ListWebhookDeliveriesQuery string = "SELECT id, subscription_id, event_id, event_type, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at FROM webhook_deliveries WHERE subscription_id=?"
+
+	// DefaultPageSize is the number of users listed when the page size is not given
+	DefaultPageSize int = 50
+	// MaxPageSize is the maximum number of users listed in a single page
+	MaxPageSize int = 500
+
+	// mysqlDuplicateEntry is the MySQL error number of a unique key violation
+	mysqlDuplicateEntry uint16 = 1062
 )
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func newDomainEvent(ctx context.Context, eventType string, aggregateID string, payload interface{}) (sharedLib.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return sharedLib.Event{}, err
+	}
+
+	return sharedLib.Event{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Actor:       sharedLib.ActorFromContext(ctx),
+		RequestID:   sharedLib.RequestIDFromContext(ctx),
+		Payload:     data,
+	}, nil
+}
+
+// insertOutboxEvent adds a domain event to the outbox, it must run in the transaction of the change it describes
+func insertOutboxEvent(ctx context.Context, q querier, eventType string, aggregateID string, payload interface{}) error {
+	event, err := newDomainEvent(ctx, eventType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, InsertOutboxEventStatement, event.Type, event.AggregateID, event.OccurredAt, event.Actor, event.RequestID, string(event.Payload))
+
+	return err
+}
+
+// insertUserOutboxEvent adds a domain event whose payload is the user, the password is never published
+func insertUserOutboxEvent(ctx context.Context, q querier, eventType string, user sharedLib.User) error {
+	user.Password = ""
+
+	return insertOutboxEvent(ctx, q, eventType, user.ID, user)
+}
+
+// ListPendingEvents is the userRepository method to obtain the oldest domain events not published yet
+func (r *userRepository) ListPendingEvents(ctx context.Context, limit int) ([]sharedLib.Event, error) {
+	rows, err := r.db.QueryContext(ctx, PendingOutboxEventsQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+// ListEvents is the userRepository method to list the domain events matching a filter, oldest first
+func (r *userRepository) ListEvents(ctx context.Context, filter sharedLib.EventFilter) ([]sharedLib.Event, error) {
+	query := ListEventsQuery
+	args := []interface{}{filter.AfterID}
+
+	if filter.AggregateID != "" {
+		query += " AND aggregate_id = ?"
+		args = append(args, filter.AggregateID)
+	}
+
+	if len(filter.Types) > 0 {
+		query += " AND event_type IN (?" + strings.Repeat(", ?", len(filter.Types)-1) + ")"
+		for _, eventType := range filter.Types {
+			args = append(args, eventType)
+		}
+	}
+
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, normalizePageSize(filter.Limit))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+// LatestEventID is the userRepository method to obtain the ID of the most recent domain event, 0 when there is none
+func (r *userRepository) LatestEventID(ctx context.Context) (int64, error) {
+	var id int64
+
+	err := r.db.QueryRowContext(ctx, LatestEventIDQuery).Scan(&id)
+
+	return id, err
+}
+
+func scanEvents(rows *sql.Rows) ([]sharedLib.Event, error) {
+	defer rows.Close()
+
+	var events []sharedLib.Event
+	for rows.Next() {
+		event := sharedLib.Event{}
+
+		var payload string
+		err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.OccurredAt, &event.Actor, &event.RequestID, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Payload = json.RawMessage(payload)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkEventPublished is the userRepository method to flag a domain event as published
+func (r *userRepository) MarkEventPublished(ctx context.Context, eventID int64, publishedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, MarkOutboxEventPublishedStatement, publishedAt, eventID)
+
+	return err
+}
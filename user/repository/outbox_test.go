@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDomainEvent(t *testing.T) {
+	c := require.New(t)
+
+	ctx := sharedLib.ContextWithActor(context.Background(), "admin")
+	ctx = sharedLib.ContextWithRequestID(ctx, "REQ123")
+
+	event, err := newDomainEvent(ctx, sharedLib.EventUserAuthenticated, "USR123", sharedLib.UserAuthenticatedPayload{ID: "USR123"})
+	c.NoError(err)
+	c.Equal(sharedLib.EventUserAuthenticated, event.Type)
+	c.Equal("USR123", event.AggregateID)
+	c.Equal("admin", event.Actor)
+	c.Equal("REQ123", event.RequestID)
+	c.JSONEq(`{"id":"USR123"}`, string(event.Payload))
+	c.False(event.OccurredAt.IsZero())
+}
+
+// capturedArg matches any argument and keeps it for later assertions
+type capturedArg struct {
+	value driver.Value
+}
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
+func TestInsertUserOutboxEvent(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	user := sharedLib.User{ID: "USR123", Name: "test", Password: "secretHash"}
+
+	payload := &capturedArg{}
+	mock.ExpectExec(regexp.QuoteMeta(InsertOutboxEventStatement)).WithArgs(sharedLib.EventUserCreated, user.ID, sqlmock.AnyArg(), sharedLib.AnonymousActor, "", payload).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := insertUserOutboxEvent(context.Background(), db, sharedLib.EventUserCreated, user)
+	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
+
+	c.Contains(payload.value, `"name":"test"`)
+	c.NotContains(payload.value, "secretHash")
+	c.NotContains(payload.value, "password")
+}
+
+func TestListPendingEvents(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(1, sharedLib.EventUserCreated, "USR123", occurredAt, "admin", "REQ123", `{"id":"USR123"}`).
+		AddRow(2, sharedLib.EventUserDeleted, "USR123", occurredAt, "admin", "", `{"id":"USR123"}`)
+
+	mock.ExpectQuery(regexp.QuoteMeta(PendingOutboxEventsQuery)).WithArgs(10).WillReturnRows(rows)
+
+	events, err := userRepo.ListPendingEvents(context.Background(), 10)
+	c.NoError(err)
+	c.Equal([]sharedLib.Event{
+		{ID: 1, Type: sharedLib.EventUserCreated, AggregateID: "USR123", OccurredAt: occurredAt, Actor: "admin", RequestID: "REQ123", Payload: json.RawMessage(`{"id":"USR123"}`)},
+		{ID: 2, Type: sharedLib.EventUserDeleted, AggregateID: "USR123", OccurredAt: occurredAt, Actor: "admin", Payload: json.RawMessage(`{"id":"USR123"}`)},
+	}, events)
+
+	mock.ExpectQuery(regexp.QuoteMeta(PendingOutboxEventsQuery)).WithArgs(10).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListPendingEvents(context.Background(), 10)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestMarkEventPublished(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	publishedAt := time.Now().UTC()
+
+	mock.ExpectExec(regexp.QuoteMeta(MarkOutboxEventPublishedStatement)).WithArgs(publishedAt, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := userRepo.MarkEventPublished(context.Background(), 1, publishedAt)
+	c.NoError(err)
+
+	mock.ExpectExec(regexp.QuoteMeta(MarkOutboxEventPublishedStatement)).WithArgs(publishedAt, int64(2)).WillReturnError(config.ErrMockFails)
+
+	err = userRepo.MarkEventPublished(context.Background(), 2, publishedAt)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListEvents(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(3, sharedLib.EventUserUpdated, "USR123", occurredAt, "admin", "REQ123", `{"id":"USR123"}`)
+
+	sqlString := regexp.QuoteMeta(ListEventsQuery + " AND aggregate_id = ? AND event_type IN (?, ?) ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(int64(2), "USR123", sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, DefaultPageSize).WillReturnRows(rows)
+
+	events, err := userRepo.ListEvents(context.Background(), sharedLib.EventFilter{
+		AfterID:     2,
+		AggregateID: "USR123",
+		Types:       []string{sharedLib.EventUserUpdated, sharedLib.EventUserDeleted},
+	})
+	c.NoError(err)
+	c.Equal([]sharedLib.Event{
+		{ID: 3, Type: sharedLib.EventUserUpdated, AggregateID: "USR123", OccurredAt: occurredAt, Actor: "admin", RequestID: "REQ123", Payload: json.RawMessage(`{"id":"USR123"}`)},
+	}, events)
+
+	mock.ExpectQuery(regexp.QuoteMeta(ListEventsQuery+" ORDER BY id LIMIT ?")).WithArgs(int64(0), 10).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListEvents(context.Background(), sharedLib.EventFilter{Limit: 10})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestLatestEventID(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	mock.ExpectQuery(regexp.QuoteMeta(LatestEventIDQuery)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	id, err := userRepo.LatestEventID(context.Background())
+	c.NoError(err)
+	c.Equal(int64(42), id)
+
+	mock.ExpectQuery(regexp.QuoteMeta(LatestEventIDQuery)).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.LatestEventID(context.Background())
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
@@ -4,25 +4,51 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-sql-driver/mysql"
 
 	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 	"github.com/jumaroar-globant/go-bootcamp/user/shared"
 )
 
-var (
-	ErrUserNotFound  = errors.New("user not found")
-	ErrWrongPassword = errors.New("wrong password")
-)
+// unknownUserPasswordHash is the bcrypt hash, with the cost of HashPassword, the password of an unknown username is checked against
+const unknownUserPasswordHash = "$2a$14$dJ6KjVsy.YPy9AsL3WLlDer11hQJoppKKK0TrOVy2LLW7tXa4eutO"
+
+// errWrongPassword is the audited cause of a failed authentication of a known username, the caller only sees ErrInvalidCredentials
+var errWrongPassword = errors.New("wrong password")
 
 // UserRepository defines a user repository
 type UserRepository interface {
 	Authenticate(ctx context.Context, username string, password string) error
-	CreateUser(ctx context.Context, user sharedLib.User) error
+	CreateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error)
 	UpdateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error)
 	GetUser(ctx context.Context, userID string) (sharedLib.User, error)
 	DeleteUser(ctx context.Context, userID string) error
+	RestoreUser(ctx context.Context, userID string) (sharedLib.User, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListUsers(ctx context.Context, filter sharedLib.UserFilter) (sharedLib.UserPage, error)
+	ListAuditEvents(ctx context.Context, filter sharedLib.AuditEventFilter) (sharedLib.AuditEventPage, error)
+	ListPendingEvents(ctx context.Context, limit int) ([]sharedLib.Event, error)
+	MarkEventPublished(ctx context.Context, eventID int64, publishedAt time.Time) error
+	ListEvents(ctx context.Context, filter sharedLib.EventFilter) ([]sharedLib.Event, error)
+	LatestEventID(ctx context.Context) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, subscription sharedLib.WebhookSubscription) (sharedLib.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]sharedLib.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error
+	EnqueueWebhookDeliveries(ctx context.Context, event sharedLib.Event, now time.Time) (int64, error)
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]DueWebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery sharedLib.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, filter sharedLib.WebhookDeliveryFilter) (sharedLib.WebhookDeliveryPage, error)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 type userRepository struct {
@@ -38,107 +64,449 @@ func NewUserRepository(db *sql.DB, logger log.Logger) UserRepository {
 	}
 }
 
-// Authenticate is the userRepository method to authenticate a user
+// Authenticate is the userRepository method to authenticate a user, it returns ErrInvalidCredentials for unknown usernames too
 func (r *userRepository) Authenticate(ctx context.Context, username string, password string) error {
 	user := sharedLib.User{}
 
-	err := r.db.QueryRowContext(ctx, PasswordHashQuery, username).Scan(&user.Password)
+	err := r.db.QueryRowContext(ctx, PasswordHashQuery, username).Scan(&user.ID, &user.Password)
 	if err == sql.ErrNoRows {
-		return ErrUserNotFound
+		// the password is still checked so an unknown username takes as long to reject as a wrong password
+		shared.CheckPasswordHash(password, unknownUserPasswordHash)
+		r.recordFailure(ctx, sharedLib.AuditActionAuthenticate, username, sharedLib.ErrInvalidCredentials.Wrap(sharedLib.ErrUserNotFound))
+		return sharedLib.ErrInvalidCredentials
 	}
 
 	if err != nil {
+		r.recordFailure(ctx, sharedLib.AuditActionAuthenticate, username, err)
 		return err
 	}
 
 	if !shared.CheckPasswordHash(password, user.Password) {
-		return ErrWrongPassword
+		r.recordFailure(ctx, sharedLib.AuditActionAuthenticate, user.ID, sharedLib.ErrInvalidCredentials.Wrap(errWrongPassword))
+		return sharedLib.ErrInvalidCredentials
 	}
 
-	return nil
+	return r.withAudit(ctx, sharedLib.AuditActionAuthenticate, user.ID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		return nil, insertOutboxEvent(ctx, tx, sharedLib.EventUserAuthenticated, user.ID, sharedLib.UserAuthenticatedPayload{ID: user.ID})
+	})
 }
 
 // CreateUser is the userRepository method to create a user
-func (r *userRepository) CreateUser(ctx context.Context, user sharedLib.User) error {
-	_, err := r.db.ExecContext(ctx, InsertUserStatement, user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation)
-	if err != nil {
-		return err
-	}
+func (r *userRepository) CreateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error) {
+	actor := sharedLib.ActorFromContext(ctx)
+	now := time.Now().UTC()
+
+	user.CreatedAt, user.UpdatedAt = now, now
+	user.CreatedBy, user.UpdatedBy = actor, actor
 
-	for _, parent := range user.Parents {
-		_, err := r.db.ExecContext(ctx, InsertParentStatement, user.ID, parent)
+	err := r.withAudit(ctx, sharedLib.AuditActionCreateUser, user.ID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		_, err := tx.ExecContext(ctx, InsertUserStatement, user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, dateValue(user.DateOfBirth), user.DateOfBirthEstimated)
+		if isDuplicateEntry(err) {
+			return nil, sharedLib.ErrUserAlreadyExists
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
+
+		for _, parent := range user.Parents {
+			_, err := tx.ExecContext(ctx, InsertParentStatement, user.ID, parent)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := insertUserOutboxEvent(ctx, tx, sharedLib.EventUserCreated, user); err != nil {
+			return nil, err
+		}
+
+		return diffUsers(sharedLib.User{}, user), nil
+	})
+	if err != nil {
+		return sharedLib.User{}, err
 	}
 
-	return err
+	return user, nil
 }
 
 // UpdateUser is the userRepository method to update a user
 func (r *userRepository) UpdateUser(ctx context.Context, user sharedLib.User) (sharedLib.User, error) {
-	_, err := r.db.ExecContext(ctx, UpdateUserStatement, user.Name, user.Age, user.AdditionalInformation, user.ID)
-	if err != nil {
-		return sharedLib.User{}, err
-	}
+	var updated sharedLib.User
 
-	_, err = r.db.ExecContext(ctx, DeleteUserParentsStatement, user.ID)
-	if err != nil {
-		return sharedLib.User{}, err
-	}
+	err := r.withAudit(ctx, sharedLib.AuditActionUpdateUser, user.ID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		before, err := getUser(ctx, tx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, UpdateUserStatement, user.Name, user.Age, dateValue(user.DateOfBirth), user.DateOfBirthEstimated, user.AdditionalInformation, time.Now().UTC(), sharedLib.ActorFromContext(ctx), user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, DeleteUserParentsStatement, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, parent := range user.Parents {
+			_, err := tx.ExecContext(ctx, InsertParentStatement, user.ID, parent)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	for _, parent := range user.Parents {
-		_, err := r.db.ExecContext(ctx, InsertParentStatement, user.ID, parent)
+		updated, err = getUser(ctx, tx, user.ID)
 		if err != nil {
-			return sharedLib.User{}, err
+			return nil, err
 		}
+
+		if err := insertUserOutboxEvent(ctx, tx, sharedLib.EventUserUpdated, updated); err != nil {
+			return nil, err
+		}
+
+		return diffUsers(before, updated), nil
+	})
+	if err != nil {
+		return sharedLib.User{}, err
 	}
 
-	return r.GetUser(ctx, user.ID)
+	return updated, nil
 }
 
 // GetUser is the userRepository method to get a user
 func (r *userRepository) GetUser(ctx context.Context, userID string) (sharedLib.User, error) {
-	user := sharedLib.User{}
+	return getUser(ctx, r.db, userID)
+}
 
-	err := r.db.QueryRowContext(ctx, UserDataQuery, userID).Scan(&user.ID, &user.Name, &user.Age, &user.AdditionalInformation)
+func getUser(ctx context.Context, q querier, userID string) (sharedLib.User, error) {
+	user, err := scanUser(q.QueryRowContext(ctx, UserDataQuery, userID))
 	if err == sql.ErrNoRows {
-		return sharedLib.User{}, ErrUserNotFound
+		return sharedLib.User{}, sharedLib.ErrUserNotFound
 	}
 
 	if err != nil {
 		return sharedLib.User{}, err
 	}
 
-	rows, err := r.db.QueryContext(ctx, UserParentsQuery, userID)
+	user.Parents, err = getUserParents(ctx, q, userID)
+	if err != nil {
+		return sharedLib.User{}, err
+	}
+
+	return user, nil
+}
+
+// scanUser reads a row of UserDataQuery or ListUsersQuery, the age of the users with a date of birth is computed from it
+func scanUser(row interface{ Scan(dest ...interface{}) error }) (sharedLib.User, error) {
+	user := sharedLib.User{}
+	var dateOfBirth sql.NullTime
+
+	err := row.Scan(&user.ID, &user.Name, &user.Age, &user.AdditionalInformation, &user.CreatedAt, &user.UpdatedAt, &user.CreatedBy, &user.UpdatedBy, &dateOfBirth, &user.DateOfBirthEstimated)
 	if err != nil {
 		return sharedLib.User{}, err
 	}
+
+	if dateOfBirth.Valid {
+		birth := sharedLib.NewDate(dateOfBirth.Time)
+		user.DateOfBirth = &birth
+		user.Age = birth.AgeOn(time.Now())
+	}
+
+	return user, nil
+}
+
+// dateValue returns the value stored in a DATE column, nil for unknown dates
+func dateValue(d *sharedLib.Date) interface{} {
+	if d == nil {
+		return nil
+	}
+
+	return d.Time()
+}
+
+func getUserParents(ctx context.Context, q querier, userID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, UserParentsQuery, userID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	var parents []string
 	for rows.Next() {
 		var parent sharedLib.Parent
 		if err := rows.Scan(&parent.Name); err != nil {
-			return user, err
+			return nil, err
 		}
 
-		user.Parents = append(user.Parents, parent.Name)
+		parents = append(parents, parent.Name)
 	}
 
-	return user, rows.Err()
+	return parents, rows.Err()
 }
 
-// DeleteUser is the userRepository method to delete a user
+// DeleteUser is the userRepository method to soft delete a user
 func (r *userRepository) DeleteUser(ctx context.Context, userID string) error {
-	_, err := r.db.ExecContext(ctx, DeleteUserParentsStatement, userID)
+	return r.withAudit(ctx, sharedLib.AuditActionDeleteUser, userID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		before, err := getUser(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now().UTC()
+		actor := sharedLib.ActorFromContext(ctx)
+
+		result, err := tx.ExecContext(ctx, DeleteUserStatement, now, now, actor, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if affected == 0 {
+			return nil, sharedLib.ErrUserNotFound
+		}
+
+		if err := insertOutboxEvent(ctx, tx, sharedLib.EventUserDeleted, userID, sharedLib.UserDeletedPayload{ID: userID, DeletedAt: now, DeletedBy: actor}); err != nil {
+			return nil, err
+		}
+
+		return diffUsers(before, sharedLib.User{}), nil
+	})
+}
+
+// RestoreUser is the userRepository method to restore a soft deleted user
+func (r *userRepository) RestoreUser(ctx context.Context, userID string) (sharedLib.User, error) {
+	var user sharedLib.User
+
+	err := r.withAudit(ctx, sharedLib.AuditActionRestoreUser, userID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		// the name of a deleted user can be taken by a new one, restoring it would make the name ambiguous
+		var namesakes int
+		if err := tx.QueryRowContext(ctx, LiveUserNameCountQuery, userID).Scan(&namesakes); err != nil {
+			return nil, err
+		}
+
+		if namesakes > 0 {
+			return nil, sharedLib.ErrUserAlreadyExists
+		}
+
+		result, err := tx.ExecContext(ctx, RestoreUserStatement, time.Now().UTC(), sharedLib.ActorFromContext(ctx), userID)
+		if err != nil {
+			return nil, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if affected == 0 {
+			return nil, sharedLib.ErrUserNotFound
+		}
+
+		user, err = getUser(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := insertUserOutboxEvent(ctx, tx, sharedLib.EventUserRestored, user); err != nil {
+			return nil, err
+		}
+
+		return diffUsers(sharedLib.User{}, user), nil
+	})
 	if err != nil {
-		return err
+		return sharedLib.User{}, err
 	}
 
-	_, err = r.db.ExecContext(ctx, DeleteUserStatement, userID)
+	return user, nil
+}
+
+// PurgeDeletedUsers is the userRepository method to permanently delete the users soft deleted before the given time
+func (r *userRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	purged, err := r.purgeDeletedUsers(ctx, deletedBefore)
 	if err != nil {
-		return err
+		r.recordFailure(ctx, sharedLib.AuditActionPurgeUser, "", err)
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func (r *userRepository) purgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	userIDs, err := purgeableUserIDs(ctx, tx, deletedBefore)
+	if err != nil || len(userIDs) == 0 {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, PurgeUserParentsStatement, deletedBefore)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, PurgeUsersStatement, deletedBefore)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		err = insertAuditEvent(ctx, tx, newAuditEvent(ctx, sharedLib.AuditActionPurgeUser, userID, nil, nil))
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return purged, tx.Commit()
+}
+
+func purgeableUserIDs(ctx context.Context, q querier, deletedBefore time.Time) ([]string, error) {
+	rows, err := q.QueryContext(ctx, PurgeableUsersQuery, deletedBefore)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ListUsers is the userRepository method to list the users matching a filter, one page at a time
+func (r *userRepository) ListUsers(ctx context.Context, filter sharedLib.UserFilter) (sharedLib.UserPage, error) {
+	pageSize := normalizePageSize(filter.PageSize)
+
+	query, args := buildListUsersQuery(filter, pageSize+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return sharedLib.UserPage{}, err
+	}
+	defer rows.Close()
+
+	page := sharedLib.UserPage{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return sharedLib.UserPage{}, err
+		}
+
+		page.Users = append(page.Users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return sharedLib.UserPage{}, err
+	}
+
+	if len(page.Users) > pageSize {
+		page.Users = page.Users[:pageSize]
+		page.NextPageToken = page.Users[pageSize-1].ID
+	}
+
+	for i := range page.Users {
+		page.Users[i].Parents, err = getUserParents(ctx, r.db, page.Users[i].ID)
+		if err != nil {
+			return sharedLib.UserPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// isDuplicateEntry reports whether err is a MySQL unique key violation
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+func normalizePageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+
+	if pageSize > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return pageSize
+}
+
+func buildListUsersQuery(filter sharedLib.UserFilter, limit int) (string, []interface{}) {
+	var query strings.Builder
+	var args []interface{}
+
+	query.WriteString(ListUsersQuery)
+
+	addCondition := func(condition string, arg interface{}) {
+		query.WriteString(" AND ")
+		query.WriteString(condition)
+		args = append(args, arg)
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		addCondition("created_at >= ?", filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		addCondition("created_at < ?", filter.CreatedBefore)
+	}
+
+	if !filter.UpdatedAfter.IsZero() {
+		addCondition("updated_at >= ?", filter.UpdatedAfter)
+	}
+
+	if !filter.UpdatedBefore.IsZero() {
+		addCondition("updated_at < ?", filter.UpdatedBefore)
+	}
+
+	if filter.CreatedBy != "" {
+		addCondition("created_by = ?", filter.CreatedBy)
+	}
+
+	if filter.UpdatedBy != "" {
+		addCondition("updated_by = ?", filter.UpdatedBy)
+	}
+
+	if len(filter.IDs) > 0 {
+		query.WriteString(" AND id IN (?")
+		query.WriteString(strings.Repeat(", ?", len(filter.IDs)-1))
+		query.WriteString(")")
+
+		for _, id := range filter.IDs {
+			args = append(args, id)
+		}
+	}
+
+	if filter.PageToken != "" {
+		addCondition("id > ?", filter.PageToken)
+	}
+
+	query.WriteString(" ORDER BY id LIMIT ?")
+	args = append(args, limit)
 
-	return err
+	return query.String(), args
 }
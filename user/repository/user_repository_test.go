@@ -6,9 +6,11 @@ import (
 	"os"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
+	"github.com/go-sql-driver/mysql"
 
 	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 	"github.com/jumaroar-globant/go-bootcamp/user/config"
@@ -28,13 +30,23 @@ func TestAuthenticate(t *testing.T) {
 
 	c.NoError(err)
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
 
+	mock.ExpectBegin()
+
+	outboxSQLString := regexp.QuoteMeta(InsertOutboxEventStatement)
+	mock.ExpectExec(outboxSQLString).WithArgs(sharedLib.EventUserAuthenticated, "USR123", sqlmock.AnyArg(), sharedLib.AnonymousActor, "", `{"id":"USR123"}`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionAuthenticate, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
 	err = userRepo.Authenticate(context.Background(), username, "testPassword")
 	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestAuthenticateFails(t *testing.T) {
@@ -49,23 +61,29 @@ func TestAuthenticateFails(t *testing.T) {
 
 	c.NoError(err)
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
 
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionAuthenticate, "USR123", "null", "", "", sharedLib.AuditOutcomeFailure, "invalid credentials: wrong password").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	err = userRepo.Authenticate(context.Background(), username, "testPassWord")
-	c.Equal(ErrWrongPassword, err)
+	c.Equal(sharedLib.ErrInvalidCredentials, err)
 
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnError(config.ErrMockFails)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionAuthenticate, username, "null", "", "", sharedLib.AuditOutcomeFailure, config.ErrMockFails.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = userRepo.Authenticate(context.Background(), username, "testpassWord")
 	c.Equal(config.ErrMockFails, err)
 
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionAuthenticate, username, "null", "", "", sharedLib.AuditOutcomeFailure, "invalid credentials: user not found").WillReturnResult(sqlmock.NewResult(2, 1))
 
 	err = userRepo.Authenticate(context.Background(), username, "testpassWord")
-	c.Equal(ErrUserNotFound, err)
+	c.Equal(sharedLib.ErrInvalidCredentials, err, "an unknown username can not be told apart from a wrong password")
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestCreateUser(t *testing.T) {
@@ -84,15 +102,28 @@ func TestCreateUser(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
+	mock.ExpectBegin()
+
 	sqlString := regexp.QuoteMeta(InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AnonymousActor, sqlmock.AnyArg(), false).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	parentSSQLString := regexp.QuoteMeta(InsertParentStatement)
 	mock.ExpectExec(parentSSQLString).WithArgs(user.ID, user.Parents[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentSSQLString).WithArgs(user.ID, user.Parents[1]).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := userRepo.CreateUser(context.Background(), user)
+	outboxSQLString := regexp.QuoteMeta(InsertOutboxEventStatement)
+	mock.ExpectExec(outboxSQLString).WithArgs(sharedLib.EventUserCreated, user.ID, sqlmock.AnyArg(), sharedLib.AnonymousActor, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionCreateUser, user.ID, sqlmock.AnyArg(), "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	createdUser, err := userRepo.CreateUser(context.Background(), user)
 	c.NoError(err)
+	c.Equal(user.ID, createdUser.ID)
+	c.Equal(sharedLib.AnonymousActor, createdUser.CreatedBy)
+	c.False(createdUser.CreatedAt.IsZero())
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestCreateUserFails(t *testing.T) {
@@ -111,19 +142,71 @@ func TestCreateUserFails(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
+	mock.ExpectBegin().WillReturnError(config.ErrMockFails)
+
+	_, err := userRepo.CreateUser(context.Background(), user)
+	c.Equal(config.ErrMockFails, err)
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+
+	mock.ExpectBegin()
+
 	sqlString := regexp.QuoteMeta(InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation).WillReturnError(config.ErrMockFails)
+	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AnonymousActor, sqlmock.AnyArg(), false).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionCreateUser, user.ID, "null", "", "", sharedLib.AuditOutcomeFailure, config.ErrMockFails.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := userRepo.CreateUser(context.Background(), user)
+	_, err = userRepo.CreateUser(context.Background(), user)
 	c.Equal(config.ErrMockFails, err)
 
-	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AnonymousActor, sqlmock.AnyArg(), false).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	parentSSQLString := regexp.QuoteMeta(InsertParentStatement)
 	mock.ExpectExec(parentSSQLString).WithArgs(user.ID, user.Parents[0]).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnResult(sqlmock.NewResult(2, 1))
 
-	err = userRepo.CreateUser(context.Background(), user)
+	_, err = userRepo.CreateUser(context.Background(), user)
 	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlString).WithArgs(user.ID, user.Name, user.Password, user.Age, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AnonymousActor, sqlmock.AnyArg(), false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(parentSSQLString).WithArgs(user.ID, user.Parents[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(parentSSQLString).WithArgs(user.ID, user.Parents[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(auditSQLString).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+
+	_, err = userRepo.CreateUser(context.Background(), user)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestCreateUserAlreadyExists(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	user := sharedLib.User{
+		ID:       "USR123",
+		Name:     "test",
+		Password: "clave123",
+		Age:      99,
+	}
+
+	duplicateErr := &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'USR123' for key 'PRIMARY'"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(InsertUserStatement)).WillReturnError(duplicateErr)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(InsertAuditEventStatement)).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionCreateUser, user.ID, "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrUserAlreadyExists.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err := userRepo.CreateUser(context.Background(), user)
+	c.Equal(sharedLib.ErrUserAlreadyExists, err)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestGetUser(t *testing.T) {
@@ -139,9 +222,13 @@ func TestGetUser(t *testing.T) {
 		Age:                   99,
 		AdditionalInformation: "not much",
 		Parents:               []string{"John Doe", "Jane Doe"},
+		CreatedAt:             time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:             time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC),
+		CreatedBy:             "admin",
+		UpdatedBy:             "support",
 	}
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
 
 	sqlString := regexp.QuoteMeta(UserDataQuery)
 	mock.ExpectQuery(sqlString).WithArgs(user.ID).WillReturnRows(row)
@@ -157,6 +244,25 @@ func TestGetUser(t *testing.T) {
 	c.NoError(err)
 }
 
+func TestGetUserWithDateOfBirth(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	dateOfBirth := time.Now().UTC().AddDate(-30, 0, 0)
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "test", 12, "", time.Time{}, time.Time{}, "", "", dateOfBirth, false)
+	mock.ExpectQuery(regexp.QuoteMeta(UserDataQuery)).WithArgs("USR123").WillReturnRows(row)
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	foundUser, err := userRepo.GetUser(context.Background(), "USR123")
+	c.NoError(err)
+	c.Equal(30, foundUser.Age)
+	c.Equal(sharedLib.NewDate(dateOfBirth), *foundUser.DateOfBirth)
+}
+
 func TestGetUserFails(t *testing.T) {
 	c := require.New(t)
 
@@ -176,14 +282,14 @@ func TestGetUserFails(t *testing.T) {
 	mock.ExpectQuery(sqlString).WithArgs("USR123").WillReturnError(sql.ErrNoRows)
 
 	_, err := userRepo.GetUser(context.Background(), "USR123")
-	c.Equal(ErrUserNotFound, err)
+	c.Equal(sharedLib.ErrUserNotFound, err)
 
 	mock.ExpectQuery(sqlString).WithArgs("USR123").WillReturnError(config.ErrMockFails)
 
 	_, err = userRepo.GetUser(context.Background(), "USR123")
 	c.Equal(config.ErrMockFails, err)
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
 
 	mock.ExpectQuery(sqlString).WithArgs(user.ID).WillReturnRows(row)
 
@@ -210,9 +316,18 @@ func TestUpdateUser(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
+	mock.ExpectBegin()
+
+	sqlSelectString := regexp.QuoteMeta(UserDataQuery)
+	parentSSQLString := regexp.QuoteMeta(UserParentsQuery)
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, "old name", user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+	mock.ExpectQuery(sqlSelectString).WithArgs(user.ID).WillReturnRows(beforeRow)
+	mock.ExpectQuery(parentSSQLString).WithArgs(user.ID).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(user.Parents[0]).AddRow(user.Parents[1]))
+
 	sqlUpdateString := regexp.QuoteMeta(UpdateUserStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, user.AdditionalInformation, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, sqlmock.AnyArg(), false, user.AdditionalInformation, sqlmock.AnyArg(), sharedLib.AnonymousActor, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	sqlDeleteString := regexp.QuoteMeta(DeleteUserParentsStatement)
 
@@ -223,21 +338,25 @@ func TestUpdateUser(t *testing.T) {
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.ID, user.Parents[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.ID, user.Parents[1]).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation)
-
-	sqlSelectString := regexp.QuoteMeta(UserDataQuery)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
 
 	mock.ExpectQuery(sqlSelectString).WithArgs(user.ID).WillReturnRows(row)
 
-	parentSSQLString := regexp.QuoteMeta(UserParentsQuery)
-
 	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parents[0]).AddRow(user.Parents[1])
 
 	mock.ExpectQuery(parentSSQLString).WithArgs(user.ID).WillReturnRows(rows)
 
+	outboxSQLString := regexp.QuoteMeta(InsertOutboxEventStatement)
+	mock.ExpectExec(outboxSQLString).WithArgs(sharedLib.EventUserUpdated, user.ID, sqlmock.AnyArg(), sharedLib.AnonymousActor, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionUpdateUser, user.ID, `{"name":{"before":"old name","after":"test"}}`, "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
 	foundUser, err := userRepo.UpdateUser(context.Background(), user)
 	c.Equal(user, foundUser)
 	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestUpdateUserFails(t *testing.T) {
@@ -255,31 +374,59 @@ func TestUpdateUserFails(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
-	sqlUpdateString := regexp.QuoteMeta(UpdateUserStatement)
+	sqlSelectString := regexp.QuoteMeta(UserDataQuery)
+	parentSSQLString := regexp.QuoteMeta(UserParentsQuery)
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, user.AdditionalInformation, user.ID).WillReturnError(config.ErrMockFails)
+	expectBefore := func() {
+		mock.ExpectBegin()
+
+		row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+		mock.ExpectQuery(sqlSelectString).WithArgs(user.ID).WillReturnRows(row)
+		mock.ExpectQuery(parentSSQLString).WithArgs(user.ID).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectString).WithArgs(user.ID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionUpdateUser, user.ID, "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrUserNotFound.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	_, err := userRepo.UpdateUser(context.Background(), user)
-	c.Equal(config.ErrMockFails, err)
+	c.Equal(sharedLib.ErrUserNotFound, err)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, user.AdditionalInformation, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlUpdateString := regexp.QuoteMeta(UpdateUserStatement)
+
+	expectBefore()
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, sqlmock.AnyArg(), false, user.AdditionalInformation, sqlmock.AnyArg(), sharedLib.AnonymousActor, user.ID).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnResult(sqlmock.NewResult(2, 1))
+
+	_, err = userRepo.UpdateUser(context.Background(), user)
+	c.Equal(config.ErrMockFails, err)
 
 	sqlDeleteString := regexp.QuoteMeta(DeleteUserParentsStatement)
 
+	expectBefore()
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, sqlmock.AnyArg(), false, user.AdditionalInformation, sqlmock.AnyArg(), sharedLib.AnonymousActor, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(sqlDeleteString).WithArgs(user.ID).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnResult(sqlmock.NewResult(3, 1))
 
 	_, err = userRepo.UpdateUser(context.Background(), user)
 	c.Equal(config.ErrMockFails, err)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, user.AdditionalInformation, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
-
-	mock.ExpectExec(sqlDeleteString).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
-
 	parentsSQLInsertString := regexp.QuoteMeta(InsertParentStatement)
 
+	expectBefore()
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, user.Age, sqlmock.AnyArg(), false, user.AdditionalInformation, sqlmock.AnyArg(), sharedLib.AnonymousActor, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlDeleteString).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.ID, user.Parents[0]).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnResult(sqlmock.NewResult(4, 1))
+
 	_, err = userRepo.UpdateUser(context.Background(), user)
 	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestDeleteUser(t *testing.T) {
@@ -289,14 +436,25 @@ func TestDeleteUser(t *testing.T) {
 
 	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
 
-	sqlString := regexp.QuoteMeta(DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "test", 99, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(UserDataQuery)).WithArgs("USR123").WillReturnRows(row)
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	sqlString := regexp.QuoteMeta(DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
 
-	parentSSQLString := regexp.QuoteMeta(DeleteUserStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	outboxSQLString := regexp.QuoteMeta(InsertOutboxEventStatement)
+	mock.ExpectExec(outboxSQLString).WithArgs(sharedLib.EventUserDeleted, "USR123", sqlmock.AnyArg(), sharedLib.AnonymousActor, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionDeleteUser, "USR123", `{"age":{"before":"99"},"name":{"before":"test"}}`, "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	err := userRepo.DeleteUser(context.Background(), "USR123")
 	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestDeleteUserFails(t *testing.T) {
@@ -306,17 +464,282 @@ func TestDeleteUserFails(t *testing.T) {
 
 	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
 
-	sqlString := regexp.QuoteMeta(DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnError(config.ErrMockFails)
+	sqlSelectString := regexp.QuoteMeta(UserDataQuery)
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectString).WithArgs("USR123").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionDeleteUser, "USR123", "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrUserNotFound.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err := userRepo.DeleteUser(context.Background(), "USR123")
-	c.Equal(config.ErrMockFails, err)
+	c.Equal(sharedLib.ErrUserNotFound, err)
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "test", 99, "", time.Time{}, time.Time{}, "", "", nil, false)
 
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectString).WithArgs("USR123").WillReturnRows(row)
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
 
-	parentSSQLString := regexp.QuoteMeta(DeleteUserStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs("USR123").WillReturnError(config.ErrMockFails)
+	sqlString := regexp.QuoteMeta(DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor, "USR123").WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnError(config.ErrMockFails)
 
 	err = userRepo.DeleteUser(context.Background(), "USR123")
 	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRestoreUser(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	user := sharedLib.User{
+		ID:                    "USR123",
+		Name:                  "test",
+		Age:                   99,
+		AdditionalInformation: "not much",
+		Parents:               []string{"John Doe", "Jane Doe"},
+	}
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(LiveUserNameCountQuery)).WithArgs(user.ID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(UserDataQuery)).WithArgs(user.ID).WillReturnRows(row)
+
+	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parents[0]).AddRow(user.Parents[1])
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs(user.ID).WillReturnRows(rows)
+
+	outboxSQLString := regexp.QuoteMeta(InsertOutboxEventStatement)
+	mock.ExpectExec(outboxSQLString).WithArgs(sharedLib.EventUserRestored, user.ID, sqlmock.AnyArg(), sharedLib.AnonymousActor, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionRestoreUser, user.ID, sqlmock.AnyArg(), "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	restoredUser, err := userRepo.RestoreUser(context.Background(), user.ID)
+	c.Equal(user, restoredUser)
+	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRestoreUserFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(LiveUserNameCountQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, "USR123").WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err := userRepo.RestoreUser(context.Background(), "USR123")
+	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(LiveUserNameCountQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, "USR123").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionRestoreUser, "USR123", "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrUserNotFound.Error()).WillReturnResult(sqlmock.NewResult(2, 1))
+
+	_, err = userRepo.RestoreUser(context.Background(), "USR123")
+	c.Equal(sharedLib.ErrUserNotFound, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(LiveUserNameCountQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionRestoreUser, "USR123", "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrUserAlreadyExists.Error()).WillReturnResult(sqlmock.NewResult(3, 1))
+
+	_, err = userRepo.RestoreUser(context.Background(), "USR123")
+	c.Equal(sharedLib.ErrUserAlreadyExists, err, "a user restored next to a namesake would make authentication ambiguous")
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	deletedBefore := time.Now().UTC()
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(PurgeableUsersQuery)).WithArgs(deletedBefore).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("USR1").AddRow("USR2"))
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUserParentsStatement)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUsersStatement)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.SystemActor, sharedLib.AuditActionPurgeUser, "USR1", "null", "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.SystemActor, sharedLib.AuditActionPurgeUser, "USR2", "null", "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	purged, err := userRepo.PurgeDeletedUsers(sharedLib.ContextWithActor(context.Background(), sharedLib.SystemActor), deletedBefore)
+	c.Equal(int64(2), purged)
+	c.NoError(err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(PurgeableUsersQuery)).WithArgs(deletedBefore).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	purged, err = userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(int64(0), purged)
+	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedUsersFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	deletedBefore := time.Now().UTC()
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+	expectFailureAudit := func() {
+		mock.ExpectExec(auditSQLString).WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionPurgeUser, "", "null", "", "", sharedLib.AuditOutcomeFailure, config.ErrMockFails.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	expectPurgeable := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(PurgeableUsersQuery)).WithArgs(deletedBefore).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("USR1"))
+	}
+
+	mock.ExpectBegin().WillReturnError(config.ErrMockFails)
+	expectFailureAudit()
+
+	_, err := userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(PurgeableUsersQuery)).WithArgs(deletedBefore).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	expectFailureAudit()
+
+	_, err = userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(config.ErrMockFails, err)
+
+	expectPurgeable()
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUserParentsStatement)).WithArgs(deletedBefore).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	expectFailureAudit()
+
+	_, err = userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(config.ErrMockFails, err)
+
+	expectPurgeable()
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUserParentsStatement)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUsersStatement)).WithArgs(deletedBefore).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	expectFailureAudit()
+
+	_, err = userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(config.ErrMockFails, err)
+
+	expectPurgeable()
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUserParentsStatement)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(PurgeUsersStatement)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(auditSQLString).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	expectFailureAudit()
+
+	_, err = userRepo.PurgeDeletedUsers(context.Background(), deletedBefore)
+	c.Equal(config.ErrMockFails, err, "the users are not purged when their audit events can not be written")
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	createdAfter := time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	filter := sharedLib.UserFilter{
+		CreatedAfter: createdAfter,
+		CreatedBy:    "admin",
+		PageSize:     1,
+		PageToken:    "USR000",
+	}
+
+	users := []sharedLib.User{
+		{ID: "USR123", Name: "test", Age: 99, CreatedAt: createdAfter, UpdatedAt: createdAfter, CreatedBy: "admin", UpdatedBy: "admin", Parents: []string{"John Doe"}},
+		{ID: "USR456", Name: "other", Age: 42, CreatedAt: createdAfter, UpdatedAt: createdAfter, CreatedBy: "admin", UpdatedBy: "admin"},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"})
+	for _, user := range users {
+		rows.AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+	}
+
+	sqlString := regexp.QuoteMeta(ListUsersQuery + " AND created_at >= ? AND created_by = ? AND id > ? ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(createdAfter, "admin", "USR000", 2).WillReturnRows(rows)
+
+	parentRows := sqlmock.NewRows([]string{"name"}).AddRow("John Doe")
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnRows(parentRows)
+
+	page, err := userRepo.ListUsers(context.Background(), filter)
+	c.NoError(err)
+	c.Equal([]sharedLib.User{users[0]}, page.Users)
+	c.Equal("USR123", page.NextPageToken)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListUsersByIDs(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).
+		AddRow("USR123", "test", 99, "", time.Time{}, time.Time{}, "", "", nil, false)
+
+	sqlString := regexp.QuoteMeta(ListUsersQuery + " AND id IN (?, ?) ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", "USR404", 3).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	page, err := userRepo.ListUsers(context.Background(), sharedLib.UserFilter{IDs: []string{"USR123", "USR404"}, PageSize: 2})
+	c.NoError(err)
+	c.Len(page.Users, 1)
+	c.Equal("USR123", page.Users[0].ID)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListUsersFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	sqlString := regexp.QuoteMeta(ListUsersQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(DefaultPageSize + 1).WillReturnError(config.ErrMockFails)
+
+	_, err := userRepo.ListUsers(context.Background(), sharedLib.UserFilter{})
+	c.Equal(config.ErrMockFails, err)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).
+		AddRow("USR123", "test", 99, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(sqlString).WithArgs(MaxPageSize + 1).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(UserParentsQuery)).WithArgs("USR123").WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListUsers(context.Background(), sharedLib.UserFilter{PageSize: MaxPageSize + 10})
+	c.Equal(config.ErrMockFails, err)
 }
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// DueWebhookDelivery is a pending webhook delivery together with the event to post and where to post it
+type DueWebhookDelivery struct {
+	Delivery sharedLib.WebhookDelivery
+	Payload  json.RawMessage
+	URL      string
+	Secret   string
+}
+
+// CreateWebhookSubscription is the userRepository method to create a webhook subscription
+func (r *userRepository) CreateWebhookSubscription(ctx context.Context, subscription sharedLib.WebhookSubscription) (sharedLib.WebhookSubscription, error) {
+	subscription.CreatedAt = time.Now().UTC()
+	subscription.CreatedBy = sharedLib.ActorFromContext(ctx)
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return sharedLib.WebhookSubscription{}, err
+	}
+
+	err = r.withAudit(ctx, sharedLib.AuditActionCreateWebhook, subscription.ID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		_, err := tx.ExecContext(ctx, InsertWebhookSubscriptionStatement, subscription.ID, subscription.URL, string(eventTypes), subscription.Secret, subscription.CreatedAt, subscription.CreatedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]sharedLib.AuditChange{
+			"url":         {After: subscription.URL},
+			"event_types": {After: strings.Join(subscription.EventTypes, ", ")},
+			"secret":      {After: sharedLib.RedactedValue},
+		}, nil
+	})
+	if err != nil {
+		return sharedLib.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions is the userRepository method to list the webhook subscriptions, their secrets are left out
+func (r *userRepository) ListWebhookSubscriptions(ctx context.Context) ([]sharedLib.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, ListWebhookSubscriptionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []sharedLib.WebhookSubscription{}
+	for rows.Next() {
+		subscription := sharedLib.WebhookSubscription{}
+
+		var eventTypes string
+		err := rows.Scan(&subscription.ID, &subscription.URL, &eventTypes, &subscription.CreatedAt, &subscription.CreatedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// DeleteWebhookSubscription is the userRepository method to delete a webhook subscription, its pending deliveries are cancelled
+func (r *userRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	return r.withAudit(ctx, sharedLib.AuditActionDeleteWebhook, subscriptionID, func(tx *sql.Tx) (map[string]sharedLib.AuditChange, error) {
+		result, err := tx.ExecContext(ctx, DeleteWebhookSubscriptionStatement, time.Now().UTC(), subscriptionID)
+		if err != nil {
+			return nil, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if affected == 0 {
+			return nil, sharedLib.ErrWebhookSubscriptionNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, CancelWebhookDeliveriesStatement, sharedLib.WebhookDeliveryCancelled, subscriptionID, sharedLib.WebhookDeliveryPending)
+
+		return nil, err
+	})
+}
+
+// EnqueueWebhookDeliveries is the userRepository method to add a delivery of the event for every subscription to its type
+func (r *userRepository) EnqueueWebhookDeliveries(ctx context.Context, event sharedLib.Event, now time.Time) (int64, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, EnqueueWebhookDeliveriesStatement, event.ID, event.Type, string(payload), sharedLib.WebhookDeliveryPending, now, now, event.Type)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ListDueWebhookDeliveries is the userRepository method to obtain the pending webhook deliveries due at the given time
+func (r *userRepository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]DueWebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, DueWebhookDeliveriesQuery, sharedLib.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []DueWebhookDelivery
+	for rows.Next() {
+		delivery := DueWebhookDelivery{
+			Delivery: sharedLib.WebhookDelivery{Status: sharedLib.WebhookDeliveryPending},
+		}
+
+		var payload string
+		err := rows.Scan(&delivery.Delivery.ID, &delivery.Delivery.SubscriptionID, &delivery.Delivery.EventID, &delivery.Delivery.EventType, &payload, &delivery.Delivery.Attempts, &delivery.Delivery.CreatedAt, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Payload = json.RawMessage(payload)
+		due = append(due, delivery)
+	}
+
+	return due, rows.Err()
+}
+
+// UpdateWebhookDelivery is the userRepository method to record the outcome of a webhook delivery attempt
+func (r *userRepository) UpdateWebhookDelivery(ctx context.Context, delivery sharedLib.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, UpdateWebhookDeliveryStatement, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt, delivery.ResponseStatus, delivery.LastError, delivery.ID)
+
+	return err
+}
+
+// ListWebhookDeliveries is the userRepository method to list the deliveries of a webhook subscription, oldest first
+func (r *userRepository) ListWebhookDeliveries(ctx context.Context, filter sharedLib.WebhookDeliveryFilter) (sharedLib.WebhookDeliveryPage, error) {
+	pageSize := normalizePageSize(filter.PageSize)
+
+	query := ListWebhookDeliveriesQuery
+	args := []interface{}{filter.SubscriptionID}
+
+	if filter.PageToken != "" {
+		lastID, err := strconv.ParseInt(filter.PageToken, 10, 64)
+		if err != nil {
+			return sharedLib.WebhookDeliveryPage{}, sharedLib.ErrBadPageToken
+		}
+
+		query += " AND id > ?"
+		args = append(args, lastID)
+	}
+
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, pageSize+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return sharedLib.WebhookDeliveryPage{}, err
+	}
+	defer rows.Close()
+
+	page := sharedLib.WebhookDeliveryPage{}
+	for rows.Next() {
+		delivery := sharedLib.WebhookDelivery{}
+
+		var lastAttemptAt sql.NullTime
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &lastAttemptAt, &delivery.ResponseStatus, &delivery.LastError, &delivery.CreatedAt)
+		if err != nil {
+			return sharedLib.WebhookDeliveryPage{}, err
+		}
+
+		delivery.LastAttemptAt = lastAttemptAt.Time
+		page.Deliveries = append(page.Deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return sharedLib.WebhookDeliveryPage{}, err
+	}
+
+	if len(page.Deliveries) > pageSize {
+		page.Deliveries = page.Deliveries[:pageSize]
+		page.NextPageToken = strconv.FormatInt(page.Deliveries[pageSize-1].ID, 10)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	subscription := sharedLib.WebhookSubscription{
+		ID:         "WHK123",
+		URL:        "https://example.com/hook",
+		EventTypes: []string{sharedLib.EventUserCreated, sharedLib.EventUserDeleted},
+		Secret:     "secret",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(InsertWebhookSubscriptionStatement)).
+		WithArgs("WHK123", "https://example.com/hook", `["user.created","user.deleted"]`, "secret", sqlmock.AnyArg(), "admin").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(InsertAuditEventStatement)).
+		WithArgs(sqlmock.AnyArg(), "admin", sharedLib.AuditActionCreateWebhook, "WHK123", `{"event_types":{"after":"user.created, user.deleted"},"secret":{"after":"[REDACTED]"},"url":{"after":"https://example.com/hook"}}`, "", "", sharedLib.AuditOutcomeSuccess, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := userRepo.CreateWebhookSubscription(sharedLib.ContextWithActor(context.Background(), "admin"), subscription)
+	c.NoError(err)
+	c.Equal("admin", created.CreatedBy)
+	c.False(created.CreatedAt.IsZero())
+	c.Equal("secret", created.Secret)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(InsertWebhookSubscriptionStatement)).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(InsertAuditEventStatement)).
+		WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionCreateWebhook, "WHK123", "null", "", "", sharedLib.AuditOutcomeFailure, config.ErrMockFails.Error()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = userRepo.CreateWebhookSubscription(context.Background(), subscription)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	createdAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "created_at", "created_by"}).
+		AddRow("WHK123", "https://example.com/hook", `["user.created"]`, createdAt, "admin")
+
+	mock.ExpectQuery(regexp.QuoteMeta(ListWebhookSubscriptionsQuery)).WillReturnRows(rows)
+
+	subscriptions, err := userRepo.ListWebhookSubscriptions(context.Background())
+	c.NoError(err)
+	c.Equal([]sharedLib.WebhookSubscription{
+		{ID: "WHK123", URL: "https://example.com/hook", EventTypes: []string{sharedLib.EventUserCreated}, CreatedAt: createdAt, CreatedBy: "admin"},
+	}, subscriptions)
+
+	mock.ExpectQuery(regexp.QuoteMeta(ListWebhookSubscriptionsQuery)).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListWebhookSubscriptions(context.Background())
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	auditSQLString := regexp.QuoteMeta(InsertAuditEventStatement)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(DeleteWebhookSubscriptionStatement)).WithArgs(sqlmock.AnyArg(), "WHK123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(CancelWebhookDeliveriesStatement)).
+		WithArgs(sharedLib.WebhookDeliveryCancelled, "WHK123", sharedLib.WebhookDeliveryPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(auditSQLString).
+		WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionDeleteWebhook, "WHK123", "null", "", "", sharedLib.AuditOutcomeSuccess, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := userRepo.DeleteWebhookSubscription(context.Background(), "WHK123")
+	c.NoError(err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(DeleteWebhookSubscriptionStatement)).WithArgs(sqlmock.AnyArg(), "WHK404").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectExec(auditSQLString).
+		WithArgs(sqlmock.AnyArg(), sharedLib.AnonymousActor, sharedLib.AuditActionDeleteWebhook, "WHK404", "null", "", "", sharedLib.AuditOutcomeFailure, sharedLib.ErrWebhookSubscriptionNotFound.Error()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = userRepo.DeleteWebhookSubscription(context.Background(), "WHK404")
+	c.Equal(sharedLib.ErrWebhookSubscriptionNotFound, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestEnqueueWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	now := time.Now().UTC()
+	event := sharedLib.Event{
+		ID:          7,
+		Type:        sharedLib.EventUserCreated,
+		AggregateID: "USR123",
+		OccurredAt:  time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC),
+		Actor:       "admin",
+		Payload:     json.RawMessage(`{"id":"USR123"}`),
+	}
+
+	payload := `{"id":7,"type":"user.created","aggregate_id":"USR123","occurred_at":"2021-10-01T10:00:00Z","actor":"admin","payload":{"id":"USR123"}}`
+
+	mock.ExpectExec(regexp.QuoteMeta(EnqueueWebhookDeliveriesStatement)).
+		WithArgs(int64(7), sharedLib.EventUserCreated, payload, sharedLib.WebhookDeliveryPending, now, now, sharedLib.EventUserCreated).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	enqueued, err := userRepo.EnqueueWebhookDeliveries(context.Background(), event, now)
+	c.NoError(err)
+	c.Equal(int64(2), enqueued)
+
+	mock.ExpectExec(regexp.QuoteMeta(EnqueueWebhookDeliveriesStatement)).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.EnqueueWebhookDeliveries(context.Background(), event, now)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListDueWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	now := time.Now().UTC()
+	createdAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "payload", "attempts", "created_at", "url", "secret"}).
+		AddRow(1, "WHK123", 7, sharedLib.EventUserCreated, `{"id":7}`, 2, createdAt, "https://example.com/hook", "secret")
+
+	mock.ExpectQuery(regexp.QuoteMeta(DueWebhookDeliveriesQuery)).WithArgs(sharedLib.WebhookDeliveryPending, now, 10).WillReturnRows(rows)
+
+	due, err := userRepo.ListDueWebhookDeliveries(context.Background(), now, 10)
+	c.NoError(err)
+	c.Equal([]DueWebhookDelivery{
+		{
+			Delivery: sharedLib.WebhookDelivery{
+				ID:             1,
+				SubscriptionID: "WHK123",
+				EventID:        7,
+				EventType:      sharedLib.EventUserCreated,
+				Status:         sharedLib.WebhookDeliveryPending,
+				Attempts:       2,
+				CreatedAt:      createdAt,
+			},
+			Payload: json.RawMessage(`{"id":7}`),
+			URL:     "https://example.com/hook",
+			Secret:  "secret",
+		},
+	}, due)
+
+	mock.ExpectQuery(regexp.QuoteMeta(DueWebhookDeliveriesQuery)).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListDueWebhookDeliveries(context.Background(), now, 10)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestUpdateWebhookDelivery(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	delivery := sharedLib.WebhookDelivery{
+		ID:             1,
+		Status:         sharedLib.WebhookDeliveryPending,
+		Attempts:       1,
+		NextAttemptAt:  time.Now().UTC(),
+		LastAttemptAt:  time.Now().UTC(),
+		ResponseStatus: 500,
+		LastError:      "500 Internal Server Error",
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(UpdateWebhookDeliveryStatement)).
+		WithArgs(delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt, delivery.ResponseStatus, delivery.LastError, delivery.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := userRepo.UpdateWebhookDelivery(context.Background(), delivery)
+	c.NoError(err)
+
+	mock.ExpectExec(regexp.QuoteMeta(UpdateWebhookDeliveryStatement)).WillReturnError(config.ErrMockFails)
+
+	err = userRepo.UpdateWebhookDelivery(context.Background(), delivery)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	userRepo := NewUserRepository(db, log.NewJSONLogger(os.Stdout))
+
+	createdAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at"}
+
+	rows := sqlmock.NewRows(columns).
+		AddRow(4, "WHK123", 7, sharedLib.EventUserCreated, sharedLib.WebhookDeliverySucceeded, 1, createdAt, createdAt, 204, "", createdAt).
+		AddRow(5, "WHK123", 8, sharedLib.EventUserDeleted, sharedLib.WebhookDeliveryPending, 0, createdAt, nil, 0, "", createdAt)
+
+	sqlString := regexp.QuoteMeta(ListWebhookDeliveriesQuery + " AND id > ? ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("WHK123", int64(3), 2).WillReturnRows(rows)
+
+	page, err := userRepo.ListWebhookDeliveries(context.Background(), sharedLib.WebhookDeliveryFilter{SubscriptionID: "WHK123", PageSize: 1, PageToken: "3"})
+	c.NoError(err)
+	c.Equal(sharedLib.WebhookDeliveryPage{
+		Deliveries: []sharedLib.WebhookDelivery{
+			{
+				ID:             4,
+				SubscriptionID: "WHK123",
+				EventID:        7,
+				EventType:      sharedLib.EventUserCreated,
+				Status:         sharedLib.WebhookDeliverySucceeded,
+				Attempts:       1,
+				NextAttemptAt:  createdAt,
+				LastAttemptAt:  createdAt,
+				ResponseStatus: 204,
+				CreatedAt:      createdAt,
+			},
+		},
+		NextPageToken: "4",
+	}, page)
+
+	_, err = userRepo.ListWebhookDeliveries(context.Background(), sharedLib.WebhookDeliveryFilter{SubscriptionID: "WHK123", PageToken: "bad"})
+	c.Equal(sharedLib.ErrBadPageToken, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(ListWebhookDeliveriesQuery+" ORDER BY id LIMIT ?")).WithArgs("WHK123", DefaultPageSize+1).WillReturnError(config.ErrMockFails)
+
+	_, err = userRepo.ListWebhookDeliveries(context.Background(), sharedLib.WebhookDeliveryFilter{SubscriptionID: "WHK123"})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
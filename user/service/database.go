@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// WaitForDatabase pings the database until it answers or the policy runs out of attempts
+func WaitForDatabase(ctx context.Context, db Pinger, policy RetryPolicy, timeout time.Duration, logger log.Logger) error {
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			if attempt > 1 {
+				level.Info(logger).Log("msg", "the database is available", "attempts", attempt)
+			}
+
+			return nil
+		}
+
+		if attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		delay := policy.Delay(attempt)
+		level.Warn(logger).Log("msg", "the database is not available yet", "attempt", attempt, "retry_in", delay, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailablePingerMock fails its first pings, like a database that is still starting
+type unavailablePingerMock struct {
+	failures int
+	pings    int
+}
+
+func (p *unavailablePingerMock) PingContext(ctx context.Context) error {
+	p.pings++
+	if p.pings <= p.failures {
+		return config.ErrMockFails
+	}
+
+	return nil
+}
+
+var testStartupPolicy = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestWaitForDatabase(t *testing.T) {
+	c := require.New(t)
+
+	db := &unavailablePingerMock{failures: 2}
+	c.NoError(WaitForDatabase(context.Background(), db, testStartupPolicy, time.Second, log.NewJSONLogger(os.Stdout)))
+	c.Equal(3, db.pings)
+}
+
+func TestWaitForDatabaseGivesUp(t *testing.T) {
+	c := require.New(t)
+
+	db := &unavailablePingerMock{failures: 5}
+	err := WaitForDatabase(context.Background(), db, testStartupPolicy, time.Second, log.NewJSONLogger(os.Stdout))
+	c.Equal(config.ErrMockFails, err)
+	c.Equal(3, db.pings)
+}
+
+func TestWaitForDatabaseCanceled(t *testing.T) {
+	c := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db := &unavailablePingerMock{failures: 5}
+	err := WaitForDatabase(ctx, db, RetryPolicy{MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}, time.Second, log.NewJSONLogger(os.Stdout))
+	c.Equal(config.ErrMockFails, err)
+	c.Equal(1, db.pings)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Pinger checks the reachability of a dependency, *sql.DB implements it
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthStatusSetter records the serving status of the gRPC services, *health.Server implements it
+type HealthStatusSetter interface {
+	SetServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus)
+}
+
+// HealthChecker reports the gRPC services as serving only while the database answers its pings
+type HealthChecker struct {
+	db       Pinger
+	statuses HealthStatusSetter
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+	logger   log.Logger
+
+	status healthpb.HealthCheckResponse_ServingStatus
+}
+
+// NewHealthChecker is the HealthChecker constructor, the whole server ("") is always reported
+func NewHealthChecker(db Pinger, statuses HealthStatusSetter, services []string, interval time.Duration, timeout time.Duration, logger log.Logger) *HealthChecker {
+	return &HealthChecker{
+		db:       db,
+		statuses: statuses,
+		services: append([]string{""}, services...),
+		interval: interval,
+		timeout:  timeout,
+		logger:   log.With(logger, "job", "health_check"),
+		status:   healthpb.HealthCheckResponse_UNKNOWN,
+	}
+}
+
+// Check pings the database and updates the serving status of the services, it returns the new status
+func (h *HealthChecker) Check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+
+	err := h.db.PingContext(ctx)
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	if status != h.status {
+		level.Info(h.logger).Log("health_status", status, "err", err)
+	}
+
+	h.status = status
+
+	for _, service := range h.services {
+		h.statuses.SetServingStatus(service, status)
+	}
+
+	return status
+}
+
+// Run checks the health every interval until the context is done
+func (h *HealthChecker) Run(ctx context.Context) {
+	h.Check(ctx)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
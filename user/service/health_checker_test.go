@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type pingerMock struct {
+	err error
+}
+
+func (p *pingerMock) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestHealthChecker(t *testing.T) {
+	c := require.New(t)
+
+	db := &pingerMock{}
+	server := health.NewServer()
+
+	checker := NewHealthChecker(db, server, []string{"user.UserService"}, time.Minute, time.Second, log.NewJSONLogger(os.Stdout))
+
+	c.Equal(healthpb.HealthCheckResponse_SERVING, checker.Check(context.Background()))
+
+	for _, service := range []string{"", "user.UserService"} {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		c.NoError(err)
+		c.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	db.err = config.ErrMockFails
+	c.Equal(healthpb.HealthCheckResponse_NOT_SERVING, checker.Check(context.Background()))
+
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "user.UserService"})
+	c.NoError(err)
+	c.Equal(healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
+
+func TestHealthCheckerRun(t *testing.T) {
+	c := require.New(t)
+
+	server := health.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	checker := NewHealthChecker(&pingerMock{}, server, nil, time.Hour, time.Second, log.NewJSONLogger(os.Stdout))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checker.Run(ctx)
+
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	c.NoError(err)
+	c.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
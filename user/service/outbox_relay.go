@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/events"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+)
+
+// OutboxRelay publishes the domain events written to the outbox in order and at least once
+type OutboxRelay struct {
+	repository repository.UserRepository
+	publisher  events.Publisher
+	batchSize  int
+	interval   time.Duration
+	logger     log.Logger
+}
+
+// NewOutboxRelay is the OutboxRelay constructor
+func NewOutboxRelay(userRep repository.UserRepository, publisher events.Publisher, batchSize int, interval time.Duration, logger log.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		repository: userRep,
+		publisher:  publisher,
+		batchSize:  batchSize,
+		interval:   interval,
+		logger:     log.With(logger, "job", "outbox_relay"),
+	}
+}
+
+// Relay publishes one batch of pending events and returns how many were published
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	pending, err := r.repository.ListPendingEvents(ctx, r.batchSize)
+	if err != nil {
+		level.Error(r.logger).Log("error_listing_pending_events", err)
+
+		return 0, err
+	}
+
+	for i, event := range pending {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			level.Error(r.logger).Log("error_publishing_event", err, "event_id", event.ID)
+
+			return i, err
+		}
+
+		if err := r.repository.MarkEventPublished(ctx, event.ID, time.Now().UTC()); err != nil {
+			level.Error(r.logger).Log("error_marking_event_published", err, "event_id", event.ID)
+
+			return i, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// Flush relays batches of pending events until the outbox is empty, the first failure or the context is done
+func (r *OutboxRelay) Flush(ctx context.Context) error {
+	for {
+		published, err := r.Relay(ctx)
+		if err != nil {
+			return err
+		}
+
+		if published < r.batchSize {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// Run flushes the outbox every interval until the context is done, so a backlog drains without waiting for the ticker
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/events"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/stretchr/testify/require"
+)
+
+var errPublishFails = errors.New("publish fails")
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, sharedLib.Event) error {
+	return errPublishFails
+}
+
+// recordingPublisher keeps the events it publishes
+type recordingPublisher struct {
+	published []sharedLib.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event sharedLib.Event) error {
+	p.published = append(p.published, event)
+	return nil
+}
+
+func pendingEventRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(1, sharedLib.EventUserCreated, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`).
+		AddRow(2, sharedLib.EventUserDeleted, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+}
+
+func TestRelay(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	publisher := &recordingPublisher{}
+
+	relay := NewOutboxRelay(repository.NewUserRepository(db, logger), publisher, 10, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(10).WillReturnRows(pendingEventRows())
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err := relay.Relay(context.Background())
+	c.NoError(err)
+	c.Equal(2, count)
+	c.Len(publisher.published, 2)
+	c.Equal(sharedLib.EventUserCreated, publisher.published[0].Type)
+	c.Equal(sharedLib.EventUserDeleted, publisher.published[1].Type)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRelayFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	relay := NewOutboxRelay(repository.NewUserRepository(db, logger), failingPublisher{}, 10, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(10).WillReturnError(config.ErrMockFails)
+
+	count, err := relay.Relay(context.Background())
+	c.Zero(count)
+	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(10).WillReturnRows(pendingEventRows())
+
+	count, err = relay.Relay(context.Background())
+	c.Zero(count)
+	c.Equal(errPublishFails, err)
+
+	relay = NewOutboxRelay(repository.NewUserRepository(db, logger), events.NewLogPublisher(logger), 10, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(10).WillReturnRows(pendingEventRows())
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(2)).WillReturnError(config.ErrMockFails)
+
+	count, err = relay.Relay(context.Background())
+	c.Equal(1, count)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestOutboxRelayRun(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	relay := NewOutboxRelay(repository.NewUserRepository(db, logger), events.NewLogPublisher(logger), 2, 10*time.Millisecond, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(2).WillReturnRows(pendingEventRows())
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	c.Eventually(func() bool {
+		return mock.ExpectationsWereMet() == nil
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestOutboxRelayFlush(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	relay := NewOutboxRelay(repository.NewUserRepository(db, logger), events.NewLogPublisher(logger), 2, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(2).WillReturnRows(pendingEventRows())
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.MarkOutboxEventPublishedStatement)).WithArgs(sqlmock.AnyArg(), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}))
+
+	c.NoError(relay.Flush(context.Background()))
+	c.NoError(mock.ExpectationsWereMet())
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PendingOutboxEventsQuery)).WithArgs(2).WillReturnError(config.ErrMockFails)
+
+	c.Equal(config.ErrMockFails, relay.Flush(context.Background()))
+	c.NoError(mock.ExpectationsWereMet())
+}
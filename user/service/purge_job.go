@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// PurgeJob permanently removes the users that have been soft deleted for longer than the retention period
+type PurgeJob struct {
+	repository repository.UserRepository
+	retention  time.Duration
+	interval   time.Duration
+	logger     log.Logger
+}
+
+// NewPurgeJob is the PurgeJob constructor
+func NewPurgeJob(userRep repository.UserRepository, retention time.Duration, interval time.Duration, logger log.Logger) *PurgeJob {
+	return &PurgeJob{
+		repository: userRep,
+		retention:  retention,
+		interval:   interval,
+		logger:     log.With(logger, "job", "purge"),
+	}
+}
+
+// Purge permanently deletes the users soft deleted before the retention period and returns how many were removed
+func (j *PurgeJob) Purge(ctx context.Context) (int64, error) {
+	purged, err := j.repository.PurgeDeletedUsers(sharedLib.ContextWithActor(ctx, sharedLib.SystemActor), time.Now().UTC().Add(-j.retention))
+	if err != nil {
+		level.Error(j.logger).Log("error_purging_deleted_users", err)
+
+		return 0, err
+	}
+
+	level.Info(j.logger).Log("purged_users", purged)
+
+	return purged, nil
+}
+
+// Run purges deleted users every interval until the context is done
+func (j *PurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.Purge(ctx)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/stretchr/testify/require"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+func TestPurge(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	job := NewPurgeJob(repository.NewUserRepository(db, logger), time.Hour, time.Minute, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PurgeableUsersQuery)).WithArgs(sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("USR123"))
+	mock.ExpectExec(regexp.QuoteMeta(repository.PurgeUserParentsStatement)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.PurgeUsersStatement)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WithArgs(sqlmock.AnyArg(), sharedLib.SystemActor, sharedLib.AuditActionPurgeUser, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	purged, err := job.Purge(context.Background())
+	c.Equal(int64(1), purged)
+	c.NoError(err)
+}
+
+func TestPurgeFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	job := NewPurgeJob(repository.NewUserRepository(db, logger), time.Hour, time.Minute, logger)
+
+	mock.ExpectBegin().WillReturnError(config.ErrMockFails)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WithArgs(sqlmock.AnyArg(), sharedLib.SystemActor, sharedLib.AuditActionPurgeUser, "", "null", "", "", sharedLib.AuditOutcomeFailure, config.ErrMockFails.Error()).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	purged, err := job.Purge(context.Background())
+	c.Zero(purged)
+	c.Equal(config.ErrMockFails, err)
+}
+
+func TestPurgeJobRun(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	job := NewPurgeJob(repository.NewUserRepository(db, logger), time.Hour, 10*time.Millisecond, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(repository.PurgeableUsersQuery)).WithArgs(sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		job.Run(ctx)
+		close(done)
+	}()
+
+	c.Eventually(func() bool {
+		return mock.ExpectationsWereMet() == nil
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
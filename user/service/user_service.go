@@ -2,8 +2,9 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
 	"github.com/jumaroar-globant/go-bootcamp/user/repository"
@@ -13,17 +14,29 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"google.golang.org/genproto/googleapis/type/date"
 )
 
 const (
 	userDeletedString = "user deleted successfully"
 )
 
-var (
-	ErrMissingUserName = errors.New("missing username")
-	ErrMissingPassword = errors.New("missing password")
-	ErrMissingUserID   = errors.New("missing user id")
-)
+// watchPollInterval is how often WatchUsers looks for new events
+var watchPollInterval = 500 * time.Millisecond
+
+// watchBatchSize is the number of events WatchUsers reads at once
+var watchBatchSize = 50
+
+// watchCommitGrace is how long after OccurredAt WatchUsers waits for an event to commit, later commits are missed
+var watchCommitGrace = 10 * time.Second
+
+// watchedEventTypes are the domain events streamed by WatchUsers
+var watchedEventTypes = []string{
+	sharedLib.EventUserCreated,
+	sharedLib.EventUserUpdated,
+	sharedLib.EventUserDeleted,
+	sharedLib.EventUserRestored,
+}
 
 type userService struct {
 	repository repository.UserRepository
@@ -35,8 +48,17 @@ type UserService interface {
 	Authenticate(ctx context.Context, authenticationRequest *pb.UserAuthRequest) (string, error)
 	CreateUser(ctx context.Context, userRequest *pb.CreateUserRequest) (sharedLib.User, error)
 	UpdateUser(context.Context, *pb.UpdateUserRequest) (sharedLib.User, error)
+	PatchUser(ctx context.Context, patch sharedLib.User, paths []string) (sharedLib.User, error)
 	GetUser(context.Context, *pb.GetUserRequest) (sharedLib.User, error)
 	DeleteUser(context.Context, *pb.DeleteUserRequest) (string, error)
+	RestoreUser(context.Context, *pb.RestoreUserRequest) (sharedLib.User, error)
+	ListUsers(context.Context, *pb.ListUsersRequest) (sharedLib.UserPage, error)
+	ListAuditEvents(context.Context, *pb.ListAuditEventsRequest) (sharedLib.AuditEventPage, error)
+	WatchUsers(ctx context.Context, watchUsersRequest *pb.WatchUsersRequest, send func(sharedLib.Event) error) error
+	CreateWebhookSubscription(context.Context, *pb.CreateWebhookSubscriptionRequest) (sharedLib.WebhookSubscription, error)
+	ListWebhookSubscriptions(context.Context, *pb.ListWebhookSubscriptionsRequest) ([]sharedLib.WebhookSubscription, error)
+	DeleteWebhookSubscription(context.Context, *pb.DeleteWebhookSubscriptionRequest) (string, error)
+	ListWebhookDeliveries(context.Context, *pb.ListWebhookDeliveriesRequest) (sharedLib.WebhookDeliveryPage, error)
 }
 
 // NewService returns a Service with all of the expected dependencies
@@ -49,7 +71,7 @@ func NewUserService(userRep repository.UserRepository, logger log.Logger) UserSe
 
 // Authenticate is the userService method to authenticate
 func (s *userService) Authenticate(ctx context.Context, authenticationRequest *pb.UserAuthRequest) (string, error) {
-	logger := log.With(s.logger, "method", "Authenticate")
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "Authenticate")
 
 	err := s.repository.Authenticate(ctx, authenticationRequest.Username, authenticationRequest.Password)
 	if err != nil {
@@ -63,17 +85,19 @@ func (s *userService) Authenticate(ctx context.Context, authenticationRequest *p
 
 // CreateUser is the userService method to create a user
 func (s *userService) CreateUser(ctx context.Context, createUserRequest *pb.CreateUserRequest) (sharedLib.User, error) {
-	logger := log.With(s.logger, "method", "CreateUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "CreateUser")
 
 	if createUserRequest.Name == "" {
-		return sharedLib.User{}, ErrMissingUserName
+		return sharedLib.User{}, sharedLib.ErrMissingUserName
 	}
 
 	if createUserRequest.Password == "" {
-		return sharedLib.User{}, ErrMissingPassword
+		return sharedLib.User{}, sharedLib.ErrMissingPassword
 	}
 
-	age, err := strconv.Atoi(createUserRequest.Age)
+	now := time.Now()
+
+	dateOfBirth, estimated, err := birthDate(createUserRequest.DateOfBirth, createUserRequest.Age, now)
 	if err != nil {
 		level.Error(logger).Log("error_converting_age_to_integer", err)
 
@@ -91,40 +115,57 @@ func (s *userService) CreateUser(ctx context.Context, createUserRequest *pb.Crea
 		ID:                    shared.GenerateID("USR"),
 		Name:                  createUserRequest.Name,
 		Password:              passwordHash,
-		Age:                   age,
+		Age:                   dateOfBirth.AgeOn(now),
+		DateOfBirth:           &dateOfBirth,
+		DateOfBirthEstimated:  estimated,
 		AdditionalInformation: createUserRequest.AdditionalInformation,
 		Parents:               createUserRequest.Parent,
 	}
 
-	err = s.repository.CreateUser(ctx, user)
+	createdUser, err := s.repository.CreateUser(ctx, user)
 	if err != nil {
 		level.Error(logger).Log("error_creating_user_in_database", err)
 
 		return sharedLib.User{}, err
 	}
 
-	return user, nil
+	return createdUser, nil
 }
 
 // UpdateUser is the userService method to update a user
 func (s *userService) UpdateUser(ctx context.Context, updateUserRequest *pb.UpdateUserRequest) (sharedLib.User, error) {
-	logger := log.With(s.logger, "method", "UpdateUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "UpdateUser")
 
 	if updateUserRequest.Id == "" {
-		return sharedLib.User{}, ErrMissingUserID
+		return sharedLib.User{}, sharedLib.ErrMissingUserID
 	}
 
-	age, err := strconv.Atoi(updateUserRequest.Age)
+	now := time.Now()
+
+	dateOfBirth, estimated, err := birthDate(updateUserRequest.DateOfBirth, updateUserRequest.Age, now)
 	if err != nil {
 		level.Error(logger).Log("error_converting_age_to_integer", err)
 
 		return sharedLib.User{}, err
 	}
 
+	if estimated {
+		stored, err := s.repository.GetUser(ctx, updateUserRequest.Id)
+		if err != nil {
+			level.Error(logger).Log("error_getting_user_from_database", err)
+
+			return sharedLib.User{}, err
+		}
+
+		dateOfBirth, estimated = keepStoredDateOfBirth(dateOfBirth, stored, now)
+	}
+
 	user := sharedLib.User{
 		ID:                    updateUserRequest.Id,
 		Name:                  updateUserRequest.Name,
-		Age:                   age,
+		Age:                   dateOfBirth.AgeOn(now),
+		DateOfBirth:           &dateOfBirth,
+		DateOfBirthEstimated:  estimated,
 		AdditionalInformation: updateUserRequest.AdditionalInformation,
 		Parents:               updateUserRequest.Parent,
 	}
@@ -139,12 +180,59 @@ func (s *userService) UpdateUser(ctx context.Context, updateUserRequest *pb.Upda
 	return updatedUser, nil
 }
 
+// PatchUser is the userService method to update the fields of a user listed in paths, the other fields are kept
+func (s *userService) PatchUser(ctx context.Context, patch sharedLib.User, paths []string) (sharedLib.User, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "PatchUser")
+
+	if patch.ID == "" {
+		return sharedLib.User{}, sharedLib.ErrMissingUserID
+	}
+
+	user, err := s.repository.GetUser(ctx, patch.ID)
+	if err != nil {
+		level.Error(logger).Log("error_getting_user_from_database", err)
+
+		return sharedLib.User{}, err
+	}
+
+	for _, path := range paths {
+		switch path {
+		case sharedLib.UserPathName:
+			user.Name = patch.Name
+		case sharedLib.UserPathDateOfBirth:
+			user.DateOfBirth = patch.DateOfBirth
+			user.DateOfBirthEstimated = false
+		case sharedLib.UserPathAdditionalInformation:
+			user.AdditionalInformation = patch.AdditionalInformation
+		case sharedLib.UserPathParents:
+			user.Parents = patch.Parents
+		default:
+			return sharedLib.User{}, sharedLib.ErrInvalidRequest.WithViolations([]sharedLib.FieldViolation{
+				{Field: "update_mask", Message: fmt.Sprintf("%q is not an updatable field", path)},
+			})
+		}
+	}
+
+	if user.DateOfBirth != nil {
+		user.Age = user.DateOfBirth.AgeOn(time.Now())
+	}
+
+	updatedUser, err := s.repository.UpdateUser(ctx, user)
+	if err != nil {
+		level.Error(logger).Log("error_updating_user_in_database", err)
+
+		return sharedLib.User{}, err
+	}
+
+	return updatedUser, nil
+}
+
 // GetUser is the userService method to get a user
 func (s *userService) GetUser(ctx context.Context, getUserRequest *pb.GetUserRequest) (sharedLib.User, error) {
-	logger := log.With(s.logger, "method", "GetUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "GetUser")
 
 	if getUserRequest.Id == "" {
-		return sharedLib.User{}, ErrMissingUserID
+		return sharedLib.User{}, sharedLib.ErrMissingUserID
 	}
 
 	user, err := s.repository.GetUser(ctx, getUserRequest.Id)
@@ -159,10 +247,10 @@ func (s *userService) GetUser(ctx context.Context, getUserRequest *pb.GetUserReq
 
 // DeleteUser is the userService method to delete a user
 func (s *userService) DeleteUser(ctx context.Context, deleteUserRequest *pb.DeleteUserRequest) (string, error) {
-	logger := log.With(s.logger, "method", "DeleteUser")
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "DeleteUser")
 
 	if deleteUserRequest.Id == "" {
-		return "", ErrMissingUserID
+		return "", sharedLib.ErrMissingUserID
 	}
 
 	err := s.repository.DeleteUser(ctx, deleteUserRequest.Id)
@@ -174,3 +262,213 @@ func (s *userService) DeleteUser(ctx context.Context, deleteUserRequest *pb.Dele
 
 	return userDeletedString, nil
 }
+
+// RestoreUser is the userService method to restore a deleted user
+func (s *userService) RestoreUser(ctx context.Context, restoreUserRequest *pb.RestoreUserRequest) (sharedLib.User, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "RestoreUser")
+
+	if restoreUserRequest.Id == "" {
+		return sharedLib.User{}, sharedLib.ErrMissingUserID
+	}
+
+	user, err := s.repository.RestoreUser(ctx, restoreUserRequest.Id)
+	if err != nil {
+		level.Error(logger).Log("error_restoring_user_in_database", err)
+
+		return sharedLib.User{}, err
+	}
+
+	return user, nil
+}
+
+// ListUsers is the userService method to list users
+func (s *userService) ListUsers(ctx context.Context, listUsersRequest *pb.ListUsersRequest) (sharedLib.UserPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "ListUsers")
+
+	if listUsersRequest.PageSize < 0 {
+		return sharedLib.UserPage{}, sharedLib.ErrBadPageSize
+	}
+
+	filter := sharedLib.UserFilter{
+		CreatedBy: listUsersRequest.CreatedBy,
+		UpdatedBy: listUsersRequest.UpdatedBy,
+		IDs:       listUsersRequest.Ids,
+		PageSize:  int(listUsersRequest.PageSize),
+		PageToken: listUsersRequest.PageToken,
+	}
+
+	if listUsersRequest.CreatedAfter != nil {
+		filter.CreatedAfter = listUsersRequest.CreatedAfter.AsTime()
+	}
+
+	if listUsersRequest.CreatedBefore != nil {
+		filter.CreatedBefore = listUsersRequest.CreatedBefore.AsTime()
+	}
+
+	if listUsersRequest.UpdatedAfter != nil {
+		filter.UpdatedAfter = listUsersRequest.UpdatedAfter.AsTime()
+	}
+
+	if listUsersRequest.UpdatedBefore != nil {
+		filter.UpdatedBefore = listUsersRequest.UpdatedBefore.AsTime()
+	}
+
+	page, err := s.repository.ListUsers(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("error_listing_users_from_database", err)
+
+		return sharedLib.UserPage{}, err
+	}
+
+	return page, nil
+}
+
+// ListAuditEvents is the userService method to list the audit events of a user
+func (s *userService) ListAuditEvents(ctx context.Context, listAuditEventsRequest *pb.ListAuditEventsRequest) (sharedLib.AuditEventPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "ListAuditEvents")
+
+	if listAuditEventsRequest.Target == "" && len(listAuditEventsRequest.Targets) == 0 {
+		return sharedLib.AuditEventPage{}, sharedLib.ErrMissingUserID
+	}
+
+	if listAuditEventsRequest.PageSize < 0 {
+		return sharedLib.AuditEventPage{}, sharedLib.ErrBadPageSize
+	}
+
+	// only the first page of several targets is listed at once
+	if len(listAuditEventsRequest.Targets) > 0 && listAuditEventsRequest.PageToken != "" {
+		return sharedLib.AuditEventPage{}, sharedLib.ErrBadPageToken
+	}
+
+	filter := sharedLib.AuditEventFilter{
+		Target:    listAuditEventsRequest.Target,
+		Targets:   listAuditEventsRequest.Targets,
+		PageSize:  int(listAuditEventsRequest.PageSize),
+		PageToken: listAuditEventsRequest.PageToken,
+	}
+
+	page, err := s.repository.ListAuditEvents(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("error_listing_audit_events_from_database", err)
+
+		return sharedLib.AuditEventPage{}, err
+	}
+
+	return page, nil
+}
+
+// WatchUsers is the userService method to stream the user changes after a cursor, or from now without one, until ctx is done
+func (s *userService) WatchUsers(ctx context.Context, watchUsersRequest *pb.WatchUsersRequest, send func(sharedLib.Event) error) error {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "WatchUsers")
+
+	cursor, err := s.watchCursor(ctx, watchUsersRequest.Cursor)
+	if err != nil {
+		level.Error(logger).Log("error_reading_cursor", err)
+
+		return err
+	}
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	// sent are the events after the cursor that were already sent
+	sent := map[int64]bool{}
+	for _, id := range cursor.Sent {
+		sent[id] = true
+	}
+
+	for {
+		after := cursor.After
+		settled := true
+		for {
+			events, err := s.repository.ListEvents(ctx, sharedLib.EventFilter{
+				AfterID:     after,
+				AggregateID: watchUsersRequest.UserId,
+				Types:       watchedEventTypes,
+				Limit:       watchBatchSize,
+			})
+			if err != nil {
+				level.Error(logger).Log("error_listing_events_from_database", err)
+
+				return err
+			}
+
+			graceStart := time.Now().UTC().Add(-watchCommitGrace)
+			for _, event := range events {
+				wasSent := sent[event.ID]
+
+				settled = settled && event.OccurredAt.Before(graceStart)
+				if settled {
+					cursor.After = event.ID
+					delete(sent, event.ID)
+				} else {
+					sent[event.ID] = true
+				}
+
+				if !wasSent {
+					event.Cursor = resumeCursor(cursor.After, sent)
+
+					err = send(event)
+					if err != nil {
+						return err
+					}
+				}
+
+				after = event.ID
+			}
+
+			if len(events) < watchBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// birthDate returns the date of birth of a create or update request, and whether it was estimated from the legacy age
+func birthDate(dateOfBirth *date.Date, age string, now time.Time) (sharedLib.Date, bool, error) {
+	if d := sharedLib.DateFromProto(dateOfBirth); d != nil {
+		return *d, false, nil
+	}
+
+	years, err := strconv.Atoi(age)
+	if err != nil {
+		return sharedLib.Date{}, false, sharedLib.ErrBadAge.Wrap(err)
+	}
+
+	return sharedLib.EstimateDateOfBirth(years, now), true, nil
+}
+
+// keepStoredDateOfBirth returns the stored date of birth instead of an estimate when they give the same age
+func keepStoredDateOfBirth(estimate sharedLib.Date, stored sharedLib.User, now time.Time) (sharedLib.Date, bool) {
+	if stored.DateOfBirth != nil && stored.DateOfBirth.AgeOn(now) == estimate.AgeOn(now) {
+		return *stored.DateOfBirth, stored.DateOfBirthEstimated
+	}
+
+	return estimate, true
+}
+
+func (s *userService) watchCursor(ctx context.Context, cursor string) (sharedLib.WatchCursor, error) {
+	if cursor == "" {
+		id, err := s.repository.LatestEventID(ctx)
+
+		return sharedLib.WatchCursor{After: id}, err
+	}
+
+	return sharedLib.ParseWatchCursor(cursor)
+}
+
+// resumeCursor returns the cursor of a watch that settled the events up to after and sent the later ones in sent
+func resumeCursor(after int64, sent map[int64]bool) string {
+	cursor := sharedLib.WatchCursor{After: after}
+	for id := range sent {
+		cursor.Sent = append(cursor.Sent, id)
+	}
+
+	return cursor.String()
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"os"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
@@ -15,6 +17,8 @@ import (
 	"github.com/jumaroar-globant/go-bootcamp/user/repository"
 	"github.com/jumaroar-globant/go-bootcamp/user/shared"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestAuthenticate(t *testing.T) {
@@ -36,10 +40,14 @@ func TestAuthenticate(t *testing.T) {
 		Password: "testPassword",
 	}
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(repository.PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	message, err := service.Authenticate(context.Background(), req)
 	c.Equal("User authenticated!", message)
@@ -90,18 +98,67 @@ func TestCreateUser(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
+	mock.ExpectBegin()
+
 	sqlString := regexp.QuoteMeta(repository.InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), user.Name, sqlmock.AnyArg(), intAge, user.AdditionalInformation).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), user.Name, sqlmock.AnyArg(), intAge, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	parentSSQLString := regexp.QuoteMeta(repository.InsertParentStatement)
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), user.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), user.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	savedUser, err := service.CreateUser(context.Background(), user)
 	c.Equal(user.Name, savedUser.Name)
 	c.NoError(err)
 }
 
+func TestBirthDate(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	dateOfBirth, estimated, err := birthDate(&date.Date{Year: 1990, Month: 6, Day: 5}, "12", now)
+	c.NoError(err)
+	c.Equal(sharedLib.Date{Year: 1990, Month: time.June, Day: 5}, dateOfBirth)
+	c.False(estimated)
+
+	dateOfBirth, estimated, err = birthDate(nil, "99", now)
+	c.NoError(err)
+	c.Equal(sharedLib.Date{Year: 1922, Month: time.October, Day: 1}, dateOfBirth)
+	c.Equal(99, dateOfBirth.AgeOn(now))
+	c.True(estimated)
+
+	_, _, err = birthDate(nil, "old", now)
+	c.True(errors.Is(err, sharedLib.ErrBadAge))
+}
+
+func TestKeepStoredDateOfBirth(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	stored := sharedLib.User{DateOfBirth: &sharedLib.Date{Year: 1990, Month: time.June, Day: 5}}
+
+	dateOfBirth, estimated := keepStoredDateOfBirth(sharedLib.EstimateDateOfBirth(31, now), stored, now)
+	c.Equal(*stored.DateOfBirth, dateOfBirth, "the stored date of birth is kept when the age matches it")
+	c.False(estimated)
+
+	stored.DateOfBirthEstimated = true
+	_, estimated = keepStoredDateOfBirth(sharedLib.EstimateDateOfBirth(31, now), stored, now)
+	c.True(estimated, "a kept estimate is still an estimate")
+
+	dateOfBirth, estimated = keepStoredDateOfBirth(sharedLib.EstimateDateOfBirth(40, now), stored, now)
+	c.Equal(sharedLib.Date{Year: 1981, Month: time.October, Day: 1}, dateOfBirth)
+	c.True(estimated)
+
+	dateOfBirth, estimated = keepStoredDateOfBirth(sharedLib.EstimateDateOfBirth(40, now), sharedLib.User{}, now)
+	c.Equal(sharedLib.Date{Year: 1981, Month: time.October, Day: 1}, dateOfBirth)
+	c.True(estimated)
+}
+
 func TestCreateUserValidationsFails(t *testing.T) {
 	c := require.New(t)
 
@@ -113,20 +170,23 @@ func TestCreateUserValidationsFails(t *testing.T) {
 
 	savedUser, err := service.CreateUser(context.Background(), user)
 	c.Empty(savedUser)
-	c.Equal(ErrMissingUserName, err)
+	c.Equal(sharedLib.ErrMissingUserName, err)
 
 	user.Name = "test"
 
 	savedUser, err = service.CreateUser(context.Background(), user)
 	c.Empty(savedUser)
-	c.Equal(ErrMissingPassword, err)
+	c.Equal(sharedLib.ErrMissingPassword, err)
 
 	user.Password = "testPwd"
 	user.Age = "badAge"
 
 	savedUser, err = service.CreateUser(context.Background(), user)
 	c.Empty(savedUser)
-	c.IsType(&strconv.NumError{}, err)
+	c.True(errors.Is(err, sharedLib.ErrBadAge))
+
+	var numErr *strconv.NumError
+	c.True(errors.As(err, &numErr))
 }
 
 func TestCreateUserDatabaseFails(t *testing.T) {
@@ -149,12 +209,12 @@ func TestCreateUserDatabaseFails(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
-	sqlString := regexp.QuoteMeta(repository.InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), user.Name, sqlmock.AnyArg(), intAge, user.AdditionalInformation).WillReturnError(config.ErrMockFails)
+	mock.ExpectBegin()
 
-	parentSSQLString := regexp.QuoteMeta(repository.InsertParentStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), user.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), user.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlString := regexp.QuoteMeta(repository.InsertUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), user.Name, sqlmock.AnyArg(), intAge, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	savedUser, err := service.CreateUser(context.Background(), user)
 	c.Empty(savedUser)
@@ -181,9 +241,19 @@ func TestUpdateUser(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
+	storedRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(storedRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
 	sqlUpdateString := regexp.QuoteMeta(repository.UpdateUserStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, user.AdditionalInformation, user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, sqlmock.AnyArg(), true, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	sqlDeleteString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
 
@@ -194,7 +264,7 @@ func TestUpdateUser(t *testing.T) {
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation, time.Time{}, time.Time{}, "", "", nil, false)
 
 	sqlSelectString := regexp.QuoteMeta(repository.UserDataQuery)
 
@@ -205,6 +275,9 @@ func TestUpdateUser(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parent[0]).AddRow(user.Parent[1])
 
 	mock.ExpectQuery(parentSSQLString).WithArgs(user.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	savedUser, err := service.UpdateUser(context.Background(), user)
 	c.Equal(user.Name, savedUser.Name)
@@ -222,7 +295,7 @@ func TestUpdateUserValidationsFails(t *testing.T) {
 
 	savedUser, err := service.UpdateUser(context.Background(), user)
 	c.Empty(savedUser)
-	c.Equal(ErrMissingUserID, err)
+	c.Equal(sharedLib.ErrMissingUserID, err)
 
 	user.Id = "USR123"
 
@@ -230,7 +303,55 @@ func TestUpdateUserValidationsFails(t *testing.T) {
 
 	savedUser, err = service.UpdateUser(context.Background(), user)
 	c.Empty(savedUser)
-	c.IsType(&strconv.NumError{}, err)
+	c.True(errors.Is(err, sharedLib.ErrBadAge))
+
+	var numErr *strconv.NumError
+	c.True(errors.As(err, &numErr))
+}
+
+func TestUpdateUserKeepsStoredDateOfBirth(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	user := &pb.UpdateUserRequest{
+		Id:   "USR123",
+		Name: "test",
+		Age:  "30",
+	}
+
+	dateOfBirth := sharedLib.NewDate(time.Now().AddDate(-30, -1, 0))
+	columns := []string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}
+
+	storedRow := sqlmock.NewRows(columns).AddRow(user.Id, "old name", 30, "", time.Time{}, time.Time{}, "", "", dateOfBirth.Time(), false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(storedRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows(columns).AddRow(user.Id, "old name", 30, "", time.Time{}, time.Time{}, "", "", dateOfBirth.Time(), false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectExec(regexp.QuoteMeta(repository.UpdateUserStatement)).WithArgs(user.Name, 30, dateOfBirth.Time(), false, "", sqlmock.AnyArg(), sqlmock.AnyArg(), user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteUserParentsStatement)).WithArgs(user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	row := sqlmock.NewRows(columns).AddRow(user.Id, user.Name, 30, "", time.Time{}, time.Time{}, "", "", dateOfBirth.Time(), false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(row)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	savedUser, err := service.UpdateUser(context.Background(), user)
+	c.NoError(err)
+	c.Equal(&dateOfBirth, savedUser.DateOfBirth)
+	c.False(savedUser.DateOfBirthEstimated)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestUpdateUserDbFails(t *testing.T) {
@@ -253,9 +374,21 @@ func TestUpdateUserDbFails(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
+	storedRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(storedRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
 	sqlUpdateString := regexp.QuoteMeta(repository.UpdateUserStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, user.AdditionalInformation, user.Id).WillReturnError(config.ErrMockFails)
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, sqlmock.AnyArg(), true, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), user.Id).WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	savedUser, err := service.UpdateUser(context.Background(), user)
 	c.Empty(savedUser)
@@ -283,7 +416,7 @@ func TestGetUser(t *testing.T) {
 		Parents:               []string{"John Doe", "Jane Doe"},
 	}
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
 
 	sqlString := regexp.QuoteMeta(repository.UserDataQuery)
 	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
@@ -323,7 +456,7 @@ func TestGetUserFails(t *testing.T) {
 
 	foundUser, err = service.GetUser(context.Background(), req)
 	c.Empty(foundUser)
-	c.Equal(ErrMissingUserID, err)
+	c.Equal(sharedLib.ErrMissingUserID, err)
 }
 
 func TestDeleteUser(t *testing.T) {
@@ -339,11 +472,17 @@ func TestDeleteUser(t *testing.T) {
 		Id: "USR123",
 	}
 
-	sqlString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
 
-	parentSSQLString := regexp.QuoteMeta(repository.DeleteUserStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	sqlString := regexp.QuoteMeta(repository.DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	message, err := service.DeleteUser(context.Background(), req)
 	c.Equal(userDeletedString, message)
@@ -363,8 +502,16 @@ func TestDeleteUserFails(t *testing.T) {
 		Id: "USR123",
 	}
 
-	sqlString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnError(config.ErrMockFails)
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	sqlString := regexp.QuoteMeta(repository.DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "USR123").WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	message, err := service.DeleteUser(context.Background(), req)
 	c.Equal("", message)
@@ -374,5 +521,479 @@ func TestDeleteUserFails(t *testing.T) {
 
 	message, err = service.DeleteUser(context.Background(), req)
 	c.Equal("", message)
-	c.Equal(ErrMissingUserID, err)
+	c.Equal(sharedLib.ErrMissingUserID, err)
+}
+
+func TestRestoreUser(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	req := &pb.RestoreUserRequest{
+		Id: "USR123",
+	}
+
+	user := sharedLib.User{
+		ID:                    "USR123",
+		Name:                  "test",
+		Age:                   99,
+		AdditionalInformation: "not much",
+		Parents:               []string{"John Doe", "Jane Doe"},
+	}
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(repository.RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LiveUserNameCountQuery)).WithArgs(req.Id).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), req.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.ID, user.Name, user.Age, user.AdditionalInformation, user.CreatedAt, user.UpdatedAt, user.CreatedBy, user.UpdatedBy, nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.UserDataQuery)
+	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+
+	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parents[0]).AddRow(user.Parents[1])
+
+	mock.ExpectQuery(parentSSQLString).WithArgs(req.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	restoredUser, err := service.RestoreUser(context.Background(), req)
+	c.Equal(user, restoredUser)
+	c.NoError(err)
+}
+
+func TestRestoreUserFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	req := &pb.RestoreUserRequest{
+		Id: "USR123",
+	}
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(repository.RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LiveUserNameCountQuery)).WithArgs(req.Id).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), req.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	restoredUser, err := service.RestoreUser(context.Background(), req)
+	c.Empty(restoredUser)
+	c.Equal(sharedLib.ErrUserNotFound, err)
+
+	req.Id = ""
+
+	restoredUser, err = service.RestoreUser(context.Background(), req)
+	c.Empty(restoredUser)
+	c.Equal(sharedLib.ErrMissingUserID, err)
+}
+
+func TestListUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	createdAfter := time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC)
+	updatedBefore := time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	req := &pb.ListUsersRequest{
+		CreatedAfter:  timestamppb.New(createdAfter),
+		UpdatedBefore: timestamppb.New(updatedBefore),
+		UpdatedBy:     "admin",
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).
+		AddRow("USR123", "test", 99, "not much", createdAfter, createdAfter, "admin", "admin", nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.ListUsersQuery + " AND created_at >= ? AND updated_at < ? AND updated_by = ? ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(createdAfter, updatedBefore, "admin", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+	mock.ExpectQuery(parentSSQLString).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	page, err := service.ListUsers(context.Background(), req)
+	c.NoError(err)
+	c.Len(page.Users, 1)
+	c.Equal("USR123", page.Users[0].ID)
+	c.Empty(page.NextPageToken)
+}
+
+func TestListUsersFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	req := &pb.ListUsersRequest{
+		PageSize: -1,
+	}
+
+	page, err := service.ListUsers(context.Background(), req)
+	c.Empty(page)
+	c.Equal(sharedLib.ErrBadPageSize, err)
+
+	req.PageSize = 10
+
+	sqlString := regexp.QuoteMeta(repository.ListUsersQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(11).WillReturnError(config.ErrMockFails)
+
+	page, err = service.ListUsers(context.Background(), req)
+	c.Empty(page)
+	c.Equal(config.ErrMockFails, err)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target", "changes", "request_id", "client_ip", "outcome", "reason"}).
+		AddRow(1, occurredAt, "admin", sharedLib.AuditActionDeleteUser, "USR123", "null", "", "", sharedLib.AuditOutcomeSuccess, "")
+
+	sqlString := regexp.QuoteMeta(repository.ListAuditEventsQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", 11).WillReturnRows(rows)
+
+	page, err := service.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Target: "USR123", PageSize: 10})
+	c.NoError(err)
+	c.Len(page.Events, 1)
+	c.Equal(sharedLib.AuditActionDeleteUser, page.Events[0].Action)
+}
+
+func TestListAuditEventsFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	page, err := service.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{})
+	c.Empty(page)
+	c.Equal(sharedLib.ErrMissingUserID, err)
+
+	page, err = service.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Target: "USR123", PageSize: -1})
+	c.Empty(page)
+	c.Equal(sharedLib.ErrBadPageSize, err)
+
+	page, err = service.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Targets: []string{"USR123", "USR456"}, PageToken: "10"})
+	c.Empty(page)
+	c.Equal(sharedLib.ErrBadPageToken, err, "only the first pages of several targets are listed at once")
+
+	sqlString := regexp.QuoteMeta(repository.ListAuditEventsQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", repository.DefaultPageSize+1).WillReturnError(config.ErrMockFails)
+
+	page, err = service.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Target: "USR123"})
+	c.Empty(page)
+	c.Equal(config.ErrMockFails, err)
+}
+
+func watchEventsSQLString(userID string) string {
+	query := repository.ListEventsQuery
+	if userID != "" {
+		query += " AND aggregate_id = ?"
+	}
+
+	return regexp.QuoteMeta(query + " AND event_type IN (?, ?, ?, ?) ORDER BY id LIMIT ?")
+}
+
+func TestWatchUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(6, sharedLib.EventUserCreated, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`).
+		AddRow(7, sharedLib.EventUserUpdated, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LatestEventIDQuery)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(watchEventsSQLString("USR123")).
+		WithArgs(int64(5), "USR123", sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, watchBatchSize).
+		WillReturnRows(rows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []int64
+	err := service.WatchUsers(ctx, &pb.WatchUsersRequest{UserId: "USR123"}, func(event sharedLib.Event) error {
+		received = append(received, event.ID)
+		if len(received) == 2 {
+			cancel()
+		}
+
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]int64{6, 7}, received)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWatchUsersResumesFromCursor(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	interval := watchPollInterval
+	watchPollInterval = time.Millisecond
+	defer func() { watchPollInterval = interval }()
+
+	firstRows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(11, sharedLib.EventUserDeleted, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+	secondRows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(12, sharedLib.EventUserRestored, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+
+	mock.ExpectQuery(watchEventsSQLString("")).
+		WithArgs(int64(10), sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, watchBatchSize).
+		WillReturnRows(firstRows)
+	mock.ExpectQuery(watchEventsSQLString("")).
+		WithArgs(int64(11), sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, watchBatchSize).
+		WillReturnRows(secondRows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []int64
+	err := service.WatchUsers(ctx, &pb.WatchUsersRequest{Cursor: "10"}, func(event sharedLib.Event) error {
+		received = append(received, event.ID)
+		if event.ID == 12 {
+			cancel()
+		}
+
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]int64{11, 12}, received)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWatchUsersSendsLateCommits(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	interval := watchPollInterval
+	watchPollInterval = time.Millisecond
+	defer func() { watchPollInterval = interval }()
+
+	columns := []string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}
+	recent := time.Now().UTC()
+
+	expectEvents := func(after int64, rows *sqlmock.Rows) {
+		mock.ExpectQuery(watchEventsSQLString("")).
+			WithArgs(after, sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, watchBatchSize).
+			WillReturnRows(rows)
+	}
+
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", recent, "admin", "", `{"id":"USR8"}`))
+	// the transaction of event 7 commits after event 8 was sent
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(7, sharedLib.EventUserCreated, "USR7", recent, "admin", "", `{"id":"USR7"}`).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", recent, "admin", "", `{"id":"USR8"}`))
+	// once they are older than the grace the cursor moves past them
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(7, sharedLib.EventUserCreated, "USR7", time.Time{}, "admin", "", `{"id":"USR7"}`).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", time.Time{}, "admin", "", `{"id":"USR8"}`))
+	expectEvents(8, sqlmock.NewRows(columns).
+		AddRow(9, sharedLib.EventUserDeleted, "USR7", recent, "admin", "", `{"id":"USR7"}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []int64
+	err := service.WatchUsers(ctx, &pb.WatchUsersRequest{Cursor: "5"}, func(event sharedLib.Event) error {
+		received = append(received, event.ID)
+		if event.ID == 9 {
+			cancel()
+		}
+
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]int64{8, 7, 9}, received, "every event is sent once, in the order it committed")
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWatchUsersResumesAfterLateCommits(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	columns := []string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}
+	recent := time.Now().UTC()
+
+	expectEvents := func(after int64, rows *sqlmock.Rows) {
+		mock.ExpectQuery(watchEventsSQLString("")).
+			WithArgs(after, sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, watchBatchSize).
+			WillReturnRows(rows)
+	}
+
+	watch := func(cursor string) []sharedLib.Event {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var received []sharedLib.Event
+		err := service.WatchUsers(ctx, &pb.WatchUsersRequest{Cursor: cursor}, func(event sharedLib.Event) error {
+			received = append(received, event)
+			cancel()
+
+			return nil
+		})
+		c.NoError(err)
+
+		return received
+	}
+
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", recent, "admin", "", `{"id":"USR8"}`))
+
+	received := watch("5")
+	c.Len(received, 1)
+	c.Equal(int64(8), received[0].ID)
+	c.Equal("5:8", received[0].Cursor, "the cursor stays before the unsettled event")
+
+	// the client reconnects before the transaction of event 7 commits, and event 7 commits while it is away
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(7, sharedLib.EventUserCreated, "USR7", recent, "admin", "", `{"id":"USR7"}`).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", recent, "admin", "", `{"id":"USR8"}`))
+
+	received = watch(received[0].Cursor)
+	c.Len(received, 1, "event 8 is not sent again")
+	c.Equal(int64(7), received[0].ID)
+	c.Equal("5:7,8", received[0].Cursor)
+
+	expectEvents(5, sqlmock.NewRows(columns).
+		AddRow(7, sharedLib.EventUserCreated, "USR7", time.Time{}, "admin", "", `{"id":"USR7"}`).
+		AddRow(8, sharedLib.EventUserCreated, "USR8", time.Time{}, "admin", "", `{"id":"USR8"}`).
+		AddRow(9, sharedLib.EventUserDeleted, "USR7", time.Time{}, "admin", "", `{"id":"USR7"}`))
+
+	received = watch(received[0].Cursor)
+	c.Len(received, 1)
+	c.Equal(int64(9), received[0].ID)
+	c.Equal("9", received[0].Cursor, "the cursor moves past the settled events")
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWatchUsersReadsEveryBatch(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	batchSize := watchBatchSize
+	watchBatchSize = 2
+	defer func() { watchBatchSize = batchSize }()
+
+	columns := []string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}
+	recent := time.Now().UTC()
+
+	mock.ExpectQuery(watchEventsSQLString("")).
+		WithArgs(int64(0), sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, sharedLib.EventUserCreated, "USR1", recent, "admin", "", `{"id":"USR1"}`).
+			AddRow(2, sharedLib.EventUserCreated, "USR2", recent, "admin", "", `{"id":"USR2"}`))
+	mock.ExpectQuery(watchEventsSQLString("")).
+		WithArgs(int64(2), sharedLib.EventUserCreated, sharedLib.EventUserUpdated, sharedLib.EventUserDeleted, sharedLib.EventUserRestored, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, sharedLib.EventUserCreated, "USR3", recent, "admin", "", `{"id":"USR3"}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []int64
+	err := service.WatchUsers(ctx, &pb.WatchUsersRequest{Cursor: "0"}, func(event sharedLib.Event) error {
+		received = append(received, event.ID)
+		if event.ID == 3 {
+			cancel()
+		}
+
+		return nil
+	})
+	c.NoError(err)
+	c.Equal([]int64{1, 2, 3}, received, "the events past a full batch are read without waiting for them to settle")
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWatchUsersFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	send := func(sharedLib.Event) error { return nil }
+
+	err := service.WatchUsers(context.Background(), &pb.WatchUsersRequest{Cursor: "not a cursor"}, send)
+	c.Equal(sharedLib.ErrBadCursor, err)
+
+	err = service.WatchUsers(context.Background(), &pb.WatchUsersRequest{Cursor: "-1"}, send)
+	c.Equal(sharedLib.ErrBadCursor, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LatestEventIDQuery)).WillReturnError(config.ErrMockFails)
+
+	err = service.WatchUsers(context.Background(), &pb.WatchUsersRequest{}, send)
+	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectQuery(watchEventsSQLString("")).WillReturnError(config.ErrMockFails)
+
+	err = service.WatchUsers(context.Background(), &pb.WatchUsersRequest{Cursor: "1"}, send)
+	c.Equal(config.ErrMockFails, err)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(2, sharedLib.EventUserCreated, "USR123", time.Time{}, "admin", "", `{"id":"USR123"}`)
+	mock.ExpectQuery(watchEventsSQLString("")).WillReturnRows(rows)
+
+	err = service.WatchUsers(context.Background(), &pb.WatchUsersRequest{Cursor: "1"}, func(sharedLib.Event) error {
+		return config.ErrMockFails
+	})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// ErrNonPublicAddress is the error of the webhook deliveries whose host resolves to an address that is not public
+var ErrNonPublicAddress = errors.New("the webhook address is not public")
+
+// lookupIPAddr resolves the hosts of the webhook URLs
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// nonPublicNetworks are the networks webhooks are not sent to besides the ones net.IP classifies
+var nonPublicNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"2001:db8::/32",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// isPublicIP reports whether ip is a public unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkWebhookHost rejects the host of a webhook URL when any of its addresses is not public
+func checkWebhookHost(ctx context.Context, host string) error {
+	addresses, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return sharedLib.ErrBadWebhookURL.Wrap(err)
+	}
+
+	for _, address := range addresses {
+		if !isPublicIP(address.IP) {
+			return sharedLib.ErrNonPublicWebhookURL
+		}
+	}
+
+	return nil
+}
+
+// NewWebhookClient returns the HTTP client of the webhook deliveries, it only connects to public addresses
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrNonPublicAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	c := require.New(t)
+
+	for _, address := range []string{"93.184.216.34", "8.8.8.8", "2606:2800:220:1:248:1893:25c8:1946"} {
+		c.True(isPublicIP(net.ParseIP(address)), address)
+	}
+
+	nonPublic := []string{
+		"127.0.0.1",
+		"10.1.2.3",
+		"172.16.0.1",
+		"192.168.1.1",
+		"169.254.169.254",
+		"100.64.0.1",
+		"0.0.0.0",
+		"224.0.0.1",
+		"255.255.255.255",
+		"::1",
+		"::",
+		"fe80::1",
+		"fd00::1",
+		"ff02::1",
+		"::ffff:127.0.0.1",
+		"64:ff9b::a9fe:a9fe",
+	}
+	for _, address := range nonPublic {
+		c.False(isPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	c := require.New(t)
+
+	resolveWebhookHosts(t, map[string]string{"example.com": "93.184.216.34", "rebound.example.com": "127.0.0.1"})
+
+	c.NoError(checkWebhookHost(context.Background(), "example.com"))
+	c.Equal(sharedLib.ErrNonPublicWebhookURL, checkWebhookHost(context.Background(), "rebound.example.com"))
+	c.True(errors.Is(checkWebhookHost(context.Background(), "unknown.example.com"), sharedLib.ErrBadWebhookURL))
+}
+
+func TestNewWebhookClientRefusesNonPublicAddresses(t *testing.T) {
+	c := require.New(t)
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(time.Second)
+
+	_, err := client.Post(server.URL, "application/json", nil)
+	c.True(errors.Is(err, ErrNonPublicAddress))
+	c.False(called)
+}
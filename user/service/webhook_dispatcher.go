@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// ErrWebhookRejected is the error recorded when a subscriber answers a delivery with a non 2xx status
+var ErrWebhookRejected = errors.New("webhook rejected")
+
+// RetryPolicy decides how many times an operation, like a webhook delivery, is attempted and how long to wait between attempts
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Delay returns how long to wait after the given number of failed attempts
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return delay
+}
+
+// WebhookDispatcher posts user events to the webhook subscriptions
+type WebhookDispatcher struct {
+	repository repository.UserRepository
+	client     *http.Client
+	policy     RetryPolicy
+	batchSize  int
+	interval   time.Duration
+	logger     log.Logger
+}
+
+// NewWebhookDispatcher is the WebhookDispatcher constructor
+func NewWebhookDispatcher(userRep repository.UserRepository, client *http.Client, policy RetryPolicy, batchSize int, interval time.Duration, logger log.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repository: userRep,
+		client:     client,
+		policy:     policy,
+		batchSize:  batchSize,
+		interval:   interval,
+		logger:     log.With(logger, "job", "webhook_dispatcher"),
+	}
+}
+
+// Publish enqueues a delivery of the event for every subscription to its type
+func (d *WebhookDispatcher) Publish(ctx context.Context, event sharedLib.Event) error {
+	_, err := d.repository.EnqueueWebhookDeliveries(ctx, event, time.Now().UTC())
+	if err != nil {
+		level.Error(d.logger).Log("error_enqueuing_webhook_deliveries", err, "event_id", event.ID)
+	}
+
+	return err
+}
+
+// Dispatch attempts one batch of due deliveries and returns how many were attempted
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	due, err := d.repository.ListDueWebhookDeliveries(ctx, time.Now().UTC(), d.batchSize)
+	if err != nil {
+		level.Error(d.logger).Log("error_listing_due_webhook_deliveries", err)
+
+		return 0, err
+	}
+
+	for i, delivery := range due {
+		attempted := d.attempt(ctx, delivery)
+
+		if err := d.repository.UpdateWebhookDelivery(ctx, attempted); err != nil {
+			level.Error(d.logger).Log("error_updating_webhook_delivery", err, "delivery_id", attempted.ID)
+
+			return i, err
+		}
+	}
+
+	return len(due), nil
+}
+
+// Run dispatches due deliveries every interval until the context is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				dispatched, err := d.Dispatch(ctx)
+				if err != nil || dispatched < d.batchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// attempt posts the delivery and returns it with the outcome of the attempt
+func (d *WebhookDispatcher) attempt(ctx context.Context, due repository.DueWebhookDelivery) sharedLib.WebhookDelivery {
+	delivery := due.Delivery
+	now := time.Now().UTC()
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.NextAttemptAt = now
+
+	status, err := d.post(ctx, due, now)
+	delivery.ResponseStatus = status
+
+	switch {
+	case err == nil:
+		delivery.Status = sharedLib.WebhookDeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= d.policy.MaxAttempts:
+		level.Warn(d.logger).Log("webhook_delivery_dead_lettered", err, "delivery_id", delivery.ID)
+
+		delivery.Status = sharedLib.WebhookDeliveryDeadLetter
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = sharedLib.WebhookDeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.policy.Delay(delivery.Attempts))
+	}
+
+	return delivery
+}
+
+// post sends the event to the subscriber and returns the response status, 0 when no response was received
+func (d *WebhookDispatcher) post(ctx context.Context, due repository.DueWebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, due.URL, bytes.NewReader(due.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(sharedLib.WebhookEventHeader, due.Delivery.EventType)
+	req.Header.Set(sharedLib.WebhookDeliveryHeader, strconv.FormatInt(due.Delivery.ID, 10))
+	req.Header.Set(sharedLib.WebhookTimestampHeader, timestamp)
+	req.Header.Set(sharedLib.WebhookSignatureHeader, sharedLib.SignWebhookPayload(due.Secret, timestamp, due.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w: %s", ErrWebhookRejected, resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
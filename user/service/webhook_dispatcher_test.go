@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/stretchr/testify/require"
+)
+
+var testWebhookPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Second,
+	MaxBackoff:  time.Minute,
+}
+
+func dueDeliveryRows(url string, attempts int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "payload", "attempts", "created_at", "url", "secret"}).
+		AddRow(1, "WHK123", 7, sharedLib.EventUserCreated, `{"id":7}`, attempts, time.Time{}, url, "secret")
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(time.Second, testWebhookPolicy.Delay(1))
+	c.Equal(2*time.Second, testWebhookPolicy.Delay(2))
+	c.Equal(8*time.Second, testWebhookPolicy.Delay(4))
+	c.Equal(time.Minute, testWebhookPolicy.Delay(20))
+}
+
+func TestWebhookDispatcherPublish(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	dispatcher := NewWebhookDispatcher(repository.NewUserRepository(db, logger), http.DefaultClient, testWebhookPolicy, 10, time.Minute, logger)
+
+	event := sharedLib.Event{ID: 7, Type: sharedLib.EventUserCreated, AggregateID: "USR123", Payload: json.RawMessage(`{"id":"USR123"}`)}
+
+	sqlString := regexp.QuoteMeta(repository.EnqueueWebhookDeliveriesStatement)
+	mock.ExpectExec(sqlString).WithArgs(int64(7), sharedLib.EventUserCreated, sqlmock.AnyArg(), sharedLib.WebhookDeliveryPending, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.EventUserCreated).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := dispatcher.Publish(context.Background(), event)
+	c.NoError(err)
+
+	mock.ExpectExec(sqlString).WillReturnError(config.ErrMockFails)
+
+	err = dispatcher.Publish(context.Background(), event)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWebhookDispatcherDispatch(t *testing.T) {
+	c := require.New(t)
+
+	received := make(chan *http.Request, 1)
+	receivedBody := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		receivedBody <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	dispatcher := NewWebhookDispatcher(repository.NewUserRepository(db, logger), server.Client(), testWebhookPolicy, 10, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.DueWebhookDeliveriesQuery)).WithArgs(sharedLib.WebhookDeliveryPending, sqlmock.AnyArg(), 10).WillReturnRows(dueDeliveryRows(server.URL, 0))
+	mock.ExpectExec(regexp.QuoteMeta(repository.UpdateWebhookDeliveryStatement)).
+		WithArgs(sharedLib.WebhookDeliverySucceeded, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), http.StatusNoContent, "", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err := dispatcher.Dispatch(context.Background())
+	c.NoError(err)
+	c.Equal(1, count)
+	c.NoError(mock.ExpectationsWereMet())
+
+	req := <-received
+	body := <-receivedBody
+
+	c.Equal(http.MethodPost, req.Method)
+	c.Equal("application/json", req.Header.Get("Content-Type"))
+	c.Equal(sharedLib.EventUserCreated, req.Header.Get(sharedLib.WebhookEventHeader))
+	c.Equal("1", req.Header.Get(sharedLib.WebhookDeliveryHeader))
+	c.Equal(`{"id":7}`, string(body))
+	c.Equal(sharedLib.SignWebhookPayload("secret", req.Header.Get(sharedLib.WebhookTimestampHeader), body), req.Header.Get(sharedLib.WebhookSignatureHeader))
+}
+
+func TestWebhookDispatcherRetries(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	dispatcher := NewWebhookDispatcher(repository.NewUserRepository(db, logger), server.Client(), testWebhookPolicy, 10, time.Minute, logger)
+
+	dueSQLString := regexp.QuoteMeta(repository.DueWebhookDeliveriesQuery)
+	updateSQLString := regexp.QuoteMeta(repository.UpdateWebhookDeliveryStatement)
+
+	mock.ExpectQuery(dueSQLString).WillReturnRows(dueDeliveryRows(server.URL, 1))
+	mock.ExpectExec(updateSQLString).
+		WithArgs(sharedLib.WebhookDeliveryPending, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), http.StatusInternalServerError, "webhook rejected: 500 Internal Server Error", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err := dispatcher.Dispatch(context.Background())
+	c.NoError(err)
+	c.Equal(1, count)
+
+	mock.ExpectQuery(dueSQLString).WillReturnRows(dueDeliveryRows(server.URL, 2))
+	mock.ExpectExec(updateSQLString).
+		WithArgs(sharedLib.WebhookDeliveryDeadLetter, 3, sqlmock.AnyArg(), sqlmock.AnyArg(), http.StatusInternalServerError, "webhook rejected: 500 Internal Server Error", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err = dispatcher.Dispatch(context.Background())
+	c.NoError(err)
+	c.Equal(1, count)
+	c.NoError(mock.ExpectationsWereMet())
+
+	delivery := dispatcher.attempt(context.Background(), repository.DueWebhookDelivery{
+		Delivery: sharedLib.WebhookDelivery{ID: 1, Attempts: 1},
+		URL:      server.URL,
+	})
+	c.Equal(sharedLib.WebhookDeliveryPending, delivery.Status)
+	c.Equal(delivery.LastAttemptAt.Add(testWebhookPolicy.Delay(2)), delivery.NextAttemptAt)
+}
+
+func TestWebhookDispatcherDispatchFails(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	dispatcher := NewWebhookDispatcher(repository.NewUserRepository(db, logger), http.DefaultClient, testWebhookPolicy, 10, time.Minute, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.DueWebhookDeliveriesQuery)).WillReturnError(config.ErrMockFails)
+
+	count, err := dispatcher.Dispatch(context.Background())
+	c.Zero(count)
+	c.Equal(config.ErrMockFails, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.DueWebhookDeliveriesQuery)).WillReturnRows(dueDeliveryRows("http://127.0.0.1:0", 0))
+	mock.ExpectExec(regexp.QuoteMeta(repository.UpdateWebhookDeliveryStatement)).
+		WithArgs(sharedLib.WebhookDeliveryPending, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), 0, sqlmock.AnyArg(), int64(1)).
+		WillReturnError(config.ErrMockFails)
+
+	count, err = dispatcher.Dispatch(context.Background())
+	c.Zero(count)
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWebhookDispatcherRun(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	dispatcher := NewWebhookDispatcher(repository.NewUserRepository(db, logger), http.DefaultClient, testWebhookPolicy, 10, 10*time.Millisecond, logger)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.DueWebhookDeliveriesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "payload", "attempts", "created_at", "url", "secret"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+
+	c.Eventually(func() bool {
+		return mock.ExpectationsWereMet() == nil
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	"github.com/jumaroar-globant/go-bootcamp/user/shared"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+const (
+	webhookSubscriptionDeletedString = "webhook subscription deleted successfully"
+	webhookSecretSize                = 32
+)
+
+// CreateWebhookSubscription is the userService method to subscribe a URL to user events
+func (s *userService) CreateWebhookSubscription(ctx context.Context, createRequest *pb.CreateWebhookSubscriptionRequest) (sharedLib.WebhookSubscription, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "CreateWebhookSubscription")
+
+	if !sharedLib.AdminFromContext(ctx) {
+		return sharedLib.WebhookSubscription{}, sharedLib.ErrAdminRequired
+	}
+
+	hookURL, err := url.Parse(createRequest.Url)
+	if err != nil || (hookURL.Scheme != "http" && hookURL.Scheme != "https") || hookURL.Host == "" {
+		return sharedLib.WebhookSubscription{}, sharedLib.ErrBadWebhookURL
+	}
+
+	if len(createRequest.EventTypes) == 0 {
+		return sharedLib.WebhookSubscription{}, sharedLib.ErrMissingEventTypes
+	}
+
+	for _, eventType := range createRequest.EventTypes {
+		if !isUserEventType(eventType) {
+			return sharedLib.WebhookSubscription{}, sharedLib.ErrUnknownEventType
+		}
+	}
+
+	if err := checkWebhookHost(ctx, hookURL.Hostname()); err != nil {
+		return sharedLib.WebhookSubscription{}, err
+	}
+
+	subscription := sharedLib.WebhookSubscription{
+		ID:         shared.GenerateID("WHK"),
+		URL:        createRequest.Url,
+		EventTypes: createRequest.EventTypes,
+		Secret:     createRequest.Secret,
+	}
+
+	if subscription.Secret == "" {
+		subscription.Secret = shared.GenerateRandomHexString(webhookSecretSize)
+	}
+
+	created, err := s.repository.CreateWebhookSubscription(ctx, subscription)
+	if err != nil {
+		level.Error(logger).Log("error_creating_webhook_subscription_in_database", err)
+
+		return sharedLib.WebhookSubscription{}, err
+	}
+
+	return created, nil
+}
+
+// ListWebhookSubscriptions is the userService method to list the webhook subscriptions, only the administrators can list them
+func (s *userService) ListWebhookSubscriptions(ctx context.Context, _ *pb.ListWebhookSubscriptionsRequest) ([]sharedLib.WebhookSubscription, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "ListWebhookSubscriptions")
+
+	if !sharedLib.AdminFromContext(ctx) {
+		return nil, sharedLib.ErrAdminRequired
+	}
+
+	subscriptions, err := s.repository.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		level.Error(logger).Log("error_listing_webhook_subscriptions_from_database", err)
+
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription is the userService method to delete a webhook subscription, only the administrators can delete them
+func (s *userService) DeleteWebhookSubscription(ctx context.Context, deleteRequest *pb.DeleteWebhookSubscriptionRequest) (string, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "DeleteWebhookSubscription")
+
+	if !sharedLib.AdminFromContext(ctx) {
+		return "", sharedLib.ErrAdminRequired
+	}
+
+	if deleteRequest.Id == "" {
+		return "", sharedLib.ErrMissingSubscriptionID
+	}
+
+	err := s.repository.DeleteWebhookSubscription(ctx, deleteRequest.Id)
+	if err != nil {
+		level.Error(logger).Log("error_deleting_webhook_subscription_in_database", err)
+
+		return "", err
+	}
+
+	return webhookSubscriptionDeletedString, nil
+}
+
+// ListWebhookDeliveries is the userService method to list the deliveries of a webhook subscription, only the administrators can list them
+func (s *userService) ListWebhookDeliveries(ctx context.Context, listRequest *pb.ListWebhookDeliveriesRequest) (sharedLib.WebhookDeliveryPage, error) {
+	logger := log.With(sharedLib.ContextLogger(ctx, s.logger), "method", "ListWebhookDeliveries")
+
+	if !sharedLib.AdminFromContext(ctx) {
+		return sharedLib.WebhookDeliveryPage{}, sharedLib.ErrAdminRequired
+	}
+
+	if listRequest.SubscriptionId == "" {
+		return sharedLib.WebhookDeliveryPage{}, sharedLib.ErrMissingSubscriptionID
+	}
+
+	if listRequest.PageSize < 0 {
+		return sharedLib.WebhookDeliveryPage{}, sharedLib.ErrBadPageSize
+	}
+
+	filter := sharedLib.WebhookDeliveryFilter{
+		SubscriptionID: listRequest.SubscriptionId,
+		PageSize:       int(listRequest.PageSize),
+		PageToken:      listRequest.PageToken,
+	}
+
+	page, err := s.repository.ListWebhookDeliveries(ctx, filter)
+	if err != nil {
+		level.Error(logger).Log("error_listing_webhook_deliveries_from_database", err)
+
+		return sharedLib.WebhookDeliveryPage{}, err
+	}
+
+	return page, nil
+}
+
+func isUserEventType(eventType string) bool {
+	for _, known := range sharedLib.UserEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+
+	return false
+}
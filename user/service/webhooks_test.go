@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// adminContext is the context of the calls made by an administrator
+var adminContext = sharedLib.ContextWithAdmin(context.Background(), true)
+
+// resolveWebhookHosts makes the hosts of the webhook URLs resolve to the given addresses during a test
+func resolveWebhookHosts(t *testing.T, hosts map[string]string) {
+	lookup := lookupIPAddr
+	lookupIPAddr = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+
+		address, ok := hosts[host]
+		if !ok {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+
+		return []net.IPAddr{{IP: net.ParseIP(address)}}, nil
+	}
+
+	t.Cleanup(func() { lookupIPAddr = lookup })
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	resolveWebhookHosts(t, map[string]string{"example.com": "93.184.216.34"})
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	req := &pb.CreateWebhookSubscriptionRequest{
+		Url:        "https://example.com/hook",
+		EventTypes: []string{sharedLib.EventUserCreated},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertWebhookSubscriptionStatement)).
+		WithArgs(sqlmock.AnyArg(), req.Url, `["user.created"]`, sqlmock.AnyArg(), sqlmock.AnyArg(), sharedLib.AnonymousActor).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	subscription, err := service.CreateWebhookSubscription(adminContext, req)
+	c.NoError(err)
+	c.Regexp("^WHK", subscription.ID)
+	c.Len(subscription.Secret, 2*webhookSecretSize)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestCreateWebhookSubscriptionValidationsFails(t *testing.T) {
+	c := require.New(t)
+
+	db, _ := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	for _, url := range []string{"", "example.com/hook", "ftp://example.com/hook", "https://"} {
+		_, err := service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{Url: url, EventTypes: []string{sharedLib.EventUserCreated}})
+		c.Equal(sharedLib.ErrBadWebhookURL, err, url)
+	}
+
+	nonPublic := []string{
+		"http://127.0.0.1/hook",
+		"http://localhost.:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://100.64.0.1/hook",
+		"https://internal.example.com/hook",
+	}
+	resolveWebhookHosts(t, map[string]string{"example.com": "93.184.216.34", "internal.example.com": "10.1.2.3", "localhost.": "127.0.0.1"})
+	for _, url := range nonPublic {
+		_, err := service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{Url: url, EventTypes: []string{sharedLib.EventUserCreated}})
+		c.Equal(sharedLib.ErrNonPublicWebhookURL, err, url)
+	}
+
+	_, err := service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{Url: "https://unknown.example.com/hook", EventTypes: []string{sharedLib.EventUserCreated}})
+	c.True(errors.Is(err, sharedLib.ErrBadWebhookURL))
+
+	_, err = service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{Url: "https://example.com/hook"})
+	c.Equal(sharedLib.ErrMissingEventTypes, err)
+
+	_, err = service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{Url: "https://example.com/hook", EventTypes: []string{"user.renamed"}})
+	c.Equal(sharedLib.ErrUnknownEventType, err)
+}
+
+func TestCreateWebhookSubscriptionDatabaseFails(t *testing.T) {
+	c := require.New(t)
+
+	resolveWebhookHosts(t, map[string]string{"example.com": "93.184.216.34"})
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertWebhookSubscriptionStatement)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "my secret", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(config.ErrMockFails)
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err := service.CreateWebhookSubscription(adminContext, &pb.CreateWebhookSubscriptionRequest{
+		Url:        "http://example.com/hook",
+		EventTypes: []string{sharedLib.EventUserDeleted},
+		Secret:     "my secret",
+	})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "created_at", "created_by"}).
+		AddRow("WHK123", "https://example.com/hook", `["user.created"]`, time.Time{}, "admin")
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.ListWebhookSubscriptionsQuery)).WillReturnRows(rows)
+
+	subscriptions, err := service.ListWebhookSubscriptions(adminContext, &pb.ListWebhookSubscriptionsRequest{})
+	c.NoError(err)
+	c.Len(subscriptions, 1)
+	c.Equal("WHK123", subscriptions[0].ID)
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.ListWebhookSubscriptionsQuery)).WillReturnError(config.ErrMockFails)
+
+	_, err = service.ListWebhookSubscriptions(adminContext, &pb.ListWebhookSubscriptionsRequest{})
+	c.Equal(config.ErrMockFails, err)
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteWebhookSubscriptionStatement)).WithArgs(sqlmock.AnyArg(), "WHK123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.CancelWebhookDeliveriesStatement)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	message, err := service.DeleteWebhookSubscription(adminContext, &pb.DeleteWebhookSubscriptionRequest{Id: "WHK123"})
+	c.NoError(err)
+	c.Equal(webhookSubscriptionDeletedString, message)
+
+	_, err = service.DeleteWebhookSubscription(adminContext, &pb.DeleteWebhookSubscriptionRequest{})
+	c.Equal(sharedLib.ErrMissingSubscriptionID, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteWebhookSubscriptionStatement)).WithArgs(sqlmock.AnyArg(), "WHK404").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = service.DeleteWebhookSubscription(adminContext, &pb.DeleteWebhookSubscriptionRequest{Id: "WHK404"})
+	c.Equal(sharedLib.ErrWebhookSubscriptionNotFound, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at"}).
+		AddRow(1, "WHK123", 7, sharedLib.EventUserCreated, sharedLib.WebhookDeliveryDeadLetter, 8, time.Time{}, time.Time{}, 500, "webhook rejected: 500 Internal Server Error", time.Time{})
+
+	sqlString := regexp.QuoteMeta(repository.ListWebhookDeliveriesQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("WHK123", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	page, err := service.ListWebhookDeliveries(adminContext, &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123"})
+	c.NoError(err)
+	c.Equal(sharedLib.WebhookDeliveryDeadLetter, page.Deliveries[0].Status)
+
+	_, err = service.ListWebhookDeliveries(adminContext, &pb.ListWebhookDeliveriesRequest{})
+	c.Equal(sharedLib.ErrMissingSubscriptionID, err)
+
+	_, err = service.ListWebhookDeliveries(adminContext, &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123", PageSize: -1})
+	c.Equal(sharedLib.ErrBadPageSize, err)
+
+	mock.ExpectQuery(sqlString).WithArgs("WHK123", repository.DefaultPageSize+1).WillReturnError(config.ErrMockFails)
+
+	_, err = service.ListWebhookDeliveries(adminContext, &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123"})
+	c.Equal(config.ErrMockFails, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWebhooksRequireAdmin(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	service := NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	ctx := sharedLib.ContextWithActor(context.Background(), "USR123")
+
+	_, err := service.CreateWebhookSubscription(ctx, &pb.CreateWebhookSubscriptionRequest{Url: "https://example.com/hook", EventTypes: []string{sharedLib.EventUserCreated}})
+	c.Equal(sharedLib.ErrAdminRequired, err)
+
+	_, err = service.ListWebhookSubscriptions(ctx, &pb.ListWebhookSubscriptionsRequest{})
+	c.Equal(sharedLib.ErrAdminRequired, err)
+
+	_, err = service.DeleteWebhookSubscription(ctx, &pb.DeleteWebhookSubscriptionRequest{Id: "WHK123"})
+	c.Equal(sharedLib.ErrAdminRequired, err)
+
+	_, err = service.ListWebhookDeliveries(ctx, &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123"})
+	c.Equal(sharedLib.ErrAdminRequired, err)
+	c.NoError(mock.ExpectationsWereMet())
+}
@@ -2,6 +2,9 @@ package shared
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // GetStringEnvVar gets the env var as a string
@@ -13,3 +16,69 @@ func GetStringEnvVar(varName string, defaultValue string) string {
 
 	return val
 }
+
+// GetDurationEnvVar gets the env var as a duration, falling back to the default when it is missing or malformed
+func GetDurationEnvVar(varName string, defaultValue time.Duration) time.Duration {
+	val, _ := os.LookupEnv(varName)
+
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return duration
+}
+
+// GetIntEnvVar gets the env var as an int, falling back to the default when it is missing or malformed
+func GetIntEnvVar(varName string, defaultValue int) int {
+	val, _ := os.LookupEnv(varName)
+
+	number, err := strconv.Atoi(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return number
+}
+
+// GetFloatEnvVar gets the env var as a float, falling back to the default when it is missing or malformed
+func GetFloatEnvVar(varName string, defaultValue float64) float64 {
+	val, _ := os.LookupEnv(varName)
+
+	number, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return number
+}
+
+// GetBoolEnvVar gets the env var as a bool, falling back to the default when it is missing or malformed
+func GetBoolEnvVar(varName string, defaultValue bool) bool {
+	val, _ := os.LookupEnv(varName)
+
+	flag, err := strconv.ParseBool(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return flag
+}
+
+// GetStringSliceEnvVar gets the env var as a comma separated list, the blank items are skipped
+func GetStringSliceEnvVar(varName string, defaultValue []string) []string {
+	val, _ := os.LookupEnv(varName)
+
+	items := []string{}
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) == 0 {
+		return defaultValue
+	}
+
+	return items
+}
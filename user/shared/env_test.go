@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,6 +26,96 @@ func TestGetStringEnvVarCustomValue(t *testing.T) {
 	})
 }
 
+func TestGetDurationEnvVarDefaultValue(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(time.Hour, GetDurationEnvVar("GET_DURATION", time.Hour))
+
+	withTestEnv("not a duration", func(varName string) {
+		c.Equal(time.Hour, GetDurationEnvVar(varName, time.Hour))
+	})
+}
+
+func TestGetDurationEnvVarCustomValue(t *testing.T) {
+	c := require.New(t)
+
+	withTestEnv("90m", func(varName string) {
+		c.Equal(90*time.Minute, GetDurationEnvVar(varName, time.Hour))
+	})
+}
+
+func TestGetIntEnvVarDefaultValue(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(100, GetIntEnvVar("GET_INT", 100))
+
+	withTestEnv("not a number", func(varName string) {
+		c.Equal(100, GetIntEnvVar(varName, 100))
+	})
+}
+
+func TestGetIntEnvVarCustomValue(t *testing.T) {
+	c := require.New(t)
+
+	withTestEnv(25, func(varName string) {
+		c.Equal(25, GetIntEnvVar(varName, 100))
+	})
+}
+
+func TestGetFloatEnvVarDefaultValue(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(0.5, GetFloatEnvVar("GET_FLOAT", 0.5))
+
+	withTestEnv("half", func(varName string) {
+		c.Equal(0.5, GetFloatEnvVar(varName, 0.5))
+	})
+}
+
+func TestGetFloatEnvVarCustomValue(t *testing.T) {
+	c := require.New(t)
+
+	withTestEnv(0.25, func(varName string) {
+		c.Equal(0.25, GetFloatEnvVar(varName, 0.5))
+	})
+}
+
+func TestGetBoolEnvVarDefaultValue(t *testing.T) {
+	c := require.New(t)
+
+	c.True(GetBoolEnvVar("GET_BOOL", true))
+
+	withTestEnv("not a bool", func(varName string) {
+		c.True(GetBoolEnvVar(varName, true))
+	})
+}
+
+func TestGetBoolEnvVarCustomValue(t *testing.T) {
+	c := require.New(t)
+
+	withTestEnv(false, func(varName string) {
+		c.False(GetBoolEnvVar(varName, true))
+	})
+}
+
+func TestGetStringSliceEnvVarDefaultValue(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal([]string{"default"}, GetStringSliceEnvVar("GET_STRING_SLICE", []string{"default"}))
+
+	withTestEnv(" , ", func(varName string) {
+		c.Nil(GetStringSliceEnvVar(varName, nil))
+	})
+}
+
+func TestGetStringSliceEnvVarCustomValue(t *testing.T) {
+	c := require.New(t)
+
+	withTestEnv("http-gateway, spiffe://cluster/gateway,", func(varName string) {
+		c.Equal([]string{"http-gateway", "spiffe://cluster/gateway"}, GetStringSliceEnvVar(varName, nil))
+	})
+}
+
 func withTestEnv(val interface{}, cb func(varName string)) {
 	varName := fmt.Sprintf("TEST_%d_%d", rand.Intn(math.MaxInt32), rand.Intn(math.MaxInt32))
 	_ = os.Setenv(varName, fmt.Sprintf("%v", val))
@@ -1,28 +1,42 @@
 package shared
 
 import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
 	"golang.org/x/crypto/bcrypt"
 
 	"crypto/rand"
 	"encoding/hex"
+	"time"
 )
 
 const (
 	_attemptsToReadRandomData = 2
 )
 
+// PasswordHashDuration observes how long hashing and checking passwords takes in seconds, labelled by operation
+var PasswordHashDuration metrics.Histogram = discard.NewHistogram()
+
 // HashPassword is a function to has a password
 func HashPassword(password string) (string, error) {
+	defer observePasswordHash("hash", time.Now())
+
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
 }
 
 // CheckPasswordHash is a function to compare a hash to a password string
 func CheckPasswordHash(password, hash string) bool {
+	defer observePasswordHash("check", time.Now())
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
 
+func observePasswordHash(operation string, begin time.Time) {
+	PasswordHashDuration.With("operation", operation).Observe(time.Since(begin).Seconds())
+}
+
 // GenerateRandomData returns secure random data with the given size
 func GenerateRandomData(bitSize int) []byte {
 	buffer := make([]byte, bitSize)
@@ -3,6 +3,10 @@ package shared
 import (
 	"testing"
 
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,6 +28,24 @@ func TestCheckPasswordHash(t *testing.T) {
 	c.True(isValid)
 }
 
+func TestPasswordHashDuration(t *testing.T) {
+	c := require.New(t)
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "password_hash_duration_seconds"}, []string{"operation"})
+
+	defer func(previous metrics.Histogram) {
+		PasswordHashDuration = previous
+	}(PasswordHashDuration)
+	PasswordHashDuration = kitprometheus.NewHistogram(duration)
+
+	hash, err := HashPassword("test")
+	c.NoError(err)
+	c.True(CheckPasswordHash("test", hash))
+
+	c.Equal(1, testutil.CollectAndCount(duration.WithLabelValues("hash").(prometheus.Histogram)))
+	c.Equal(2, testutil.CollectAndCount(duration))
+}
+
 func TestGenerateRandomData(t *testing.T) {
 	c := require.New(t)
 
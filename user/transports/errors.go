@@ -0,0 +1,14 @@
+package transports
+
+import (
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// encodeError converts an error returned by an endpoint into a gRPC status error
+func encodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return sharedLib.ToGRPCStatus(err).Err()
+}
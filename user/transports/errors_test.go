@@ -0,0 +1,82 @@
+package transports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+)
+
+func TestEncodeError(t *testing.T) {
+	_, numErr := strconv.Atoi("old")
+
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		reason  string
+		message string
+		field   string
+	}{
+		{name: "user not found", err: shared.ErrUserNotFound, code: codes.NotFound, reason: "USER_NOT_FOUND", message: "user not found"},
+		{name: "wrapped user not found", err: fmt.Errorf("get user: %w", shared.ErrUserNotFound), code: codes.NotFound, reason: "USER_NOT_FOUND", message: "user not found"},
+		{name: "user already exists", err: shared.ErrUserAlreadyExists, code: codes.AlreadyExists, reason: "USER_ALREADY_EXISTS", message: "user already exists"},
+		{name: "invalid credentials", err: shared.ErrInvalidCredentials, code: codes.Unauthenticated, reason: "INVALID_CREDENTIALS", message: "invalid credentials"},
+		{name: "bad page token", err: shared.ErrBadPageToken, code: codes.InvalidArgument, reason: "BAD_PAGE_TOKEN", message: "bad page token", field: "page_token"},
+		{name: "missing user id", err: shared.ErrMissingUserID, code: codes.InvalidArgument, reason: "MISSING_USER_ID", message: "missing user id", field: "id"},
+		{name: "bad age", err: shared.ErrBadAge.Wrap(numErr), code: codes.InvalidArgument, reason: "BAD_AGE", message: "age is not a number", field: "age"},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, code: codes.DeadlineExceeded, reason: "DEADLINE_EXCEEDED", message: "deadline exceeded"},
+		{name: "unknown", err: config.ErrMockFails, code: codes.Internal, reason: "INTERNAL", message: "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := require.New(t)
+
+			st := status.Convert(encodeError(tt.err))
+			c.Equal(tt.code, st.Code())
+			c.Equal(tt.message, st.Message())
+
+			var info *errdetails.ErrorInfo
+			var badRequest *errdetails.BadRequest
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				}
+			}
+
+			c.NotNil(info)
+			c.Equal(tt.reason, info.Reason)
+			c.Equal(shared.ErrorDomain, info.Domain)
+
+			if tt.field == "" {
+				c.Nil(badRequest)
+				return
+			}
+
+			c.NotNil(badRequest)
+			c.Len(badRequest.FieldViolations, 1)
+			c.Equal(tt.field, badRequest.FieldViolations[0].Field)
+		})
+	}
+}
+
+func TestEncodeErrorKeepsStatus(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(encodeError(nil))
+
+	err := status.Error(codes.PermissionDenied, "denied")
+	c.Equal(err, encodeError(err))
+}
@@ -0,0 +1,55 @@
+package transports
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// NewGatewayHandler serves the v2 API as REST through grpc-gateway, calling server in process
+func NewGatewayHandler(ctx context.Context, server pbv2.UserServiceServer, authenticator TokenAuthenticator, proxies sharedLib.TrustedProxies) (http.Handler, error) {
+	marshaler := &runtime.JSONPb{
+		MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler),
+	)
+
+	if err := pbv2.RegisterUserServiceHandlerServer(ctx, mux, server); err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := sharedLib.RequestIDOrNew(r.Header.Get(sharedLib.RequestIDHeader))
+		r.Header.Set(sharedLib.RequestIDHeader, requestID)
+		w.Header().Set(sharedLib.RequestIDHeader, requestID)
+
+		caller, err := authenticator.authenticate(r.Header.Get("Authorization"))
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, marshaler, w, r, err)
+			return
+		}
+
+		ctx := authenticator.callerContext(r.Context(), caller, proxies.ClientIP(r), r.Header.Get(sharedLib.ActorHeader), "")
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	}), nil
+}
+
+// gatewayHeaderMatcher forwards the request ID header as the metadata key the HTTP tier uses
+func gatewayHeaderMatcher(header string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(header) == textproto.CanonicalMIMEHeaderKey(sharedLib.RequestIDHeader) {
+		return sharedLib.RequestIDMetadataKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(header)
+}
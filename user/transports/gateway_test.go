@@ -0,0 +1,148 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/jumaroar-globant/go-bootcamp/user/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayGetUser(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	gateway, err := NewGatewayHandler(context.Background(), NewGRPCServerV2(endpoints.MakeEndpoints(svc), logger), NewTokenAuthenticator(shared.Credentials{}, nil, nil), shared.TrustedProxies{})
+	c.NoError(err)
+
+	columns := []string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "", "", time.Date(1990, time.June, 5, 0, 0, 0, 0, time.UTC), false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/users/USR123", nil)
+	r.Header.Set(shared.RequestIDHeader, "REQ123")
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	c.Equal(http.StatusOK, w.Code)
+	c.Equal("REQ123", w.Header().Get(shared.RequestIDHeader))
+
+	var user map[string]interface{}
+	c.NoError(json.NewDecoder(w.Body).Decode(&user))
+	c.Equal("test", user["name"])
+	c.Equal("not much", user["additional_information"])
+	c.Equal(map[string]interface{}{"year": float64(1990), "month": float64(6), "day": float64(5)}, user["date_of_birth"])
+	c.NoError(mock.ExpectationsWereMet())
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR404").WillReturnRows(sqlmock.NewRows(columns))
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/users/USR404", nil))
+	c.Equal(http.StatusNotFound, w.Code)
+	c.Len(w.Header().Get(shared.RequestIDHeader), 32)
+}
+
+func TestGatewayUpdateUserInfersUpdateMask(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	credentials, err := shared.ParseCredentials([]string{"gateway:secret"})
+	c.NoError(err)
+
+	gateway, err := NewGatewayHandler(context.Background(), NewGRPCServerV2(endpoints.MakeEndpoints(svc), logger), NewTokenAuthenticator(credentials, []string{"gateway"}, nil), shared.TrustedProxies{})
+	c.NoError(err)
+
+	columns := []string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "", "", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "", "", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+	mock.ExpectExec(regexp.QuoteMeta(repository.UpdateUserStatement)).WithArgs("test", 99, nil, false, "a lot", sqlmock.AnyArg(), "admin", "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteUserParentsStatement)).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertParentStatement)).WithArgs("USR123", "John Doe").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "a lot", time.Time{}, time.Time{}, "", "admin", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r := httptest.NewRequest(http.MethodPatch, "/v2/users/USR123", strings.NewReader(`{"additional_information": "a lot"}`))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set(shared.ActorHeader, "admin")
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	c.Equal(http.StatusOK, w.Code)
+
+	var user map[string]interface{}
+	c.NoError(json.NewDecoder(w.Body).Decode(&user))
+	c.Equal("a lot", user["additional_information"])
+	c.Equal("admin", user["updated_by"])
+	c.NoError(mock.ExpectationsWereMet())
+
+	r = httptest.NewRequest(http.MethodGet, "/v2/users:watch", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	c.Equal(http.StatusNotImplemented, w.Code)
+}
+
+func TestGatewayRequiresToken(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	credentials, err := shared.ParseCredentials([]string{"gateway:secret"})
+	c.NoError(err)
+
+	gateway, err := NewGatewayHandler(context.Background(), NewGRPCServerV2(endpoints.MakeEndpoints(svc), logger), NewTokenAuthenticator(credentials, nil, nil), shared.TrustedProxies{})
+	c.NoError(err)
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/users/USR404", nil))
+	c.Equal(http.StatusUnauthorized, w.Code)
+
+	columns := []string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR404").WillReturnRows(sqlmock.NewRows(columns))
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/users/USR404", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	c.Equal(http.StatusNotFound, w.Code)
+	c.NoError(mock.ExpectationsWereMet())
+}
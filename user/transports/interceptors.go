@@ -0,0 +1,290 @@
+package transports
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+// unauthenticatedMethodPrefixes are the services that can be called without a token, so probes and grpcurl keep working
+var unauthenticatedMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+	"/grpc.reflection.v1.ServerReflection/",
+}
+
+// UnaryServerInterceptors returns the interceptors of the unary calls in the order they run
+func UnaryServerInterceptors(logger log.Logger, authenticator TokenAuthenticator) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		otelgrpc.UnaryServerInterceptor(),
+		RequestIDUnaryInterceptor,
+		AccessLogUnaryInterceptor(logger),
+		RecoveryUnaryInterceptor(logger),
+		authenticator.UnaryServerInterceptor,
+	}
+}
+
+// StreamServerInterceptors is the streaming counterpart of UnaryServerInterceptors
+func StreamServerInterceptors(logger log.Logger, authenticator TokenAuthenticator, streams *sharedLib.Streams) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(),
+		RequestIDStreamInterceptor,
+		AccessLogStreamInterceptor(logger),
+		RecoveryStreamInterceptor(logger),
+		authenticator.StreamServerInterceptor,
+		ShutdownStreamInterceptor(streams),
+	}
+}
+
+// ShutdownStreamInterceptor serves the streams with a context canceled by streams
+func ShutdownStreamInterceptor(streams *sharedLib.Streams) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := streams.Context(stream.Context())
+		defer cancel()
+
+		return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// RequestIDUnaryInterceptor keeps the request ID sent by the caller or generates one, and sends it back in the response header
+func RequestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(contextWithRequestID(ctx), req)
+}
+
+// RequestIDStreamInterceptor is the streaming counterpart of RequestIDUnaryInterceptor
+func RequestIDStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextServerStream{ServerStream: stream, ctx: contextWithRequestID(stream.Context())})
+}
+
+func contextWithRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	sent := firstMetadataValue(md, sharedLib.RequestIDMetadataKey)
+	requestID := sharedLib.RequestIDOrNew(sent)
+	if requestID != sent {
+		md = md.Copy()
+		md.Set(sharedLib.RequestIDMetadataKey, requestID)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(sharedLib.RequestIDMetadataKey, requestID))
+
+	return sharedLib.ContextWithRequestID(ctx, requestID)
+}
+
+// AccessLogUnaryInterceptor logs every call with its code and duration
+func AccessLogUnaryInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		begin := time.Now()
+
+		resp, err := handler(ctx, req)
+		logAccess(ctx, logger, info.FullMethod, begin, err)
+
+		return resp, err
+	}
+}
+
+// AccessLogStreamInterceptor is the streaming counterpart of AccessLogUnaryInterceptor, the stream is logged when it ends
+func AccessLogStreamInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		begin := time.Now()
+
+		err := handler(srv, stream)
+		logAccess(stream.Context(), logger, info.FullMethod, begin, err)
+
+		return err
+	}
+}
+
+func logAccess(ctx context.Context, logger log.Logger, method string, begin time.Time, err error) {
+	code := status.Code(err)
+
+	var peerAddress string
+	if p, ok := peer.FromContext(ctx); ok {
+		peerAddress = p.Addr.String()
+	}
+
+	logLevel := level.Info
+	if serverErrorCodes[code] {
+		logLevel = level.Error
+	}
+
+	logLevel(logger).Log(
+		"grpc_method", method,
+		"grpc_code", code.String(),
+		"took", time.Since(begin),
+		"request_id", sharedLib.RequestIDFromContext(ctx),
+		"peer", peerAddress,
+	)
+}
+
+// serverErrorCodes are the codes logged as errors, the other ones are caused by the caller
+var serverErrorCodes = map[codes.Code]bool{
+	codes.Unknown:       true,
+	codes.Internal:      true,
+	codes.Unavailable:   true,
+	codes.DataLoss:      true,
+	codes.Unimplemented: true,
+}
+
+// RecoveryUnaryInterceptor turns a panic of a handler into an Internal error instead of crashing the process
+func RecoveryUnaryInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ctx, logger, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor is the streaming counterpart of RecoveryUnaryInterceptor
+func RecoveryStreamInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(stream.Context(), logger, info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+func recoverPanic(ctx context.Context, logger log.Logger, method string, r interface{}) error {
+	level.Error(logger).Log(
+		"panic", fmt.Sprint(r),
+		"grpc_method", method,
+		"request_id", sharedLib.RequestIDFromContext(ctx),
+		"stack", string(debug.Stack()),
+	)
+
+	return encodeError(sharedLib.ErrInternal)
+}
+
+// TokenAuthenticator only lets through the calls carrying one of its bearer tokens
+type TokenAuthenticator struct {
+	credentials    sharedLib.Credentials
+	trustedCallers map[string]bool
+	admins         map[string]bool
+}
+
+// NewTokenAuthenticator returns an authenticator accepting the given credentials
+func NewTokenAuthenticator(credentials sharedLib.Credentials, trustedCallers []string, admins []string) TokenAuthenticator {
+	authenticator := TokenAuthenticator{credentials: credentials, trustedCallers: map[string]bool{}, admins: map[string]bool{}}
+	for _, caller := range trustedCallers {
+		authenticator.trustedCallers[caller] = true
+	}
+
+	for _, admin := range admins {
+		authenticator.admins[admin] = true
+	}
+
+	return authenticator
+}
+
+// Enabled reports whether the authenticator checks the tokens
+func (a TokenAuthenticator) Enabled() bool {
+	return a.credentials.Enabled()
+}
+
+// UnaryServerInterceptor rejects the unary calls without a valid token as Unauthenticated
+func (a TokenAuthenticator) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticateCall(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func (a TokenAuthenticator) StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticateCall(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+}
+
+func (a TokenAuthenticator) authenticateCall(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range unauthenticatedMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	caller, err := a.authenticate(firstMetadataValue(md, sharedLib.AuthorizationMetadataKey))
+	if err != nil {
+		return ctx, err
+	}
+
+	var remoteIP string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteIP, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+
+	return a.callerContext(ctx, caller, remoteIP, firstMetadataValue(md, sharedLib.ActorMetadataKey), firstMetadataValue(md, sharedLib.ClientIPMetadataKey)), nil
+}
+
+// authenticate returns the actor identified by the authorization, it is empty when the authenticator is disabled
+func (a TokenAuthenticator) authenticate(authorization string) (string, error) {
+	if !a.Enabled() {
+		return "", nil
+	}
+
+	caller, err := a.credentials.Authenticate(authorization)
+	if err != nil {
+		return "", encodeError(err)
+	}
+
+	return caller, nil
+}
+
+// callerContext puts the actor, whether it is an administrator, and the client IP of a call into the context
+func (a TokenAuthenticator) callerContext(ctx context.Context, caller, remoteIP, sentActor, sentClientIP string) context.Context {
+	actor, clientIP := caller, remoteIP
+	if caller != "" && a.trustedCallers[caller] {
+		if sentActor != "" {
+			actor = sentActor
+		}
+
+		if sentClientIP != "" {
+			clientIP = sentClientIP
+		}
+	}
+
+	ctx = sharedLib.ContextWithAdmin(ctx, actor != "" && a.admins[actor])
+
+	return sharedLib.ContextWithClientIP(sharedLib.ContextWithActor(ctx, actor), clientIP)
+}
+
+// contextServerStream is a server stream whose context was replaced by an interceptor
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the replaced context
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
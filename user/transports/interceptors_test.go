@@ -0,0 +1,208 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+var userInfo = &grpc.UnaryServerInfo{FullMethod: "/user.v2.UserService/GetUser"}
+
+// streamMock is a server stream that only has a context
+type streamMock struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamMock) Context() context.Context {
+	return s.ctx
+}
+
+func TestRequestIDUnaryInterceptor(t *testing.T) {
+	c := require.New(t)
+
+	var requestID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		c.Equal([]string{sharedLib.RequestIDFromContext(ctx)}, md.Get(sharedLib.RequestIDMetadataKey))
+
+		requestID = sharedLib.RequestIDFromContext(ctx)
+		return nil, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(sharedLib.RequestIDMetadataKey, "REQ123"))
+	_, err := RequestIDUnaryInterceptor(ctx, nil, userInfo, handler)
+	c.NoError(err)
+	c.Equal("REQ123", requestID)
+
+	_, err = RequestIDUnaryInterceptor(context.Background(), nil, userInfo, handler)
+	c.NoError(err)
+	c.Len(requestID, 32)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(sharedLib.RequestIDMetadataKey, "REQ123\nlevel=error"))
+	_, err = RequestIDUnaryInterceptor(ctx, nil, userInfo, handler)
+	c.NoError(err)
+	c.Len(requestID, 32)
+}
+
+func TestAccessLogUnaryInterceptor(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	interceptor := AccessLogUnaryInterceptor(log.NewJSONLogger(&buffer))
+
+	ctx := sharedLib.ContextWithRequestID(context.Background(), "REQ123")
+	_, err := interceptor(ctx, nil, userInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, encodeError(sharedLib.ErrUserNotFound)
+	})
+	c.Equal(codes.NotFound, status.Code(err))
+
+	var line map[string]interface{}
+	c.NoError(json.Unmarshal(buffer.Bytes(), &line))
+	c.Equal("info", line["level"])
+	c.Equal(userInfo.FullMethod, line["grpc_method"])
+	c.Equal("NotFound", line["grpc_code"])
+	c.Equal("REQ123", line["request_id"])
+	c.Contains(line, "took")
+}
+
+func TestRecoveryInterceptors(t *testing.T) {
+	c := require.New(t)
+
+	var buffer bytes.Buffer
+	logger := log.NewJSONLogger(&buffer)
+
+	_, err := RecoveryUnaryInterceptor(logger)(context.Background(), nil, userInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("nil map")
+	})
+	c.Equal(codes.Internal, status.Code(err))
+	c.Equal("internal error", status.Convert(err).Message())
+	c.Contains(buffer.String(), "nil map")
+
+	stream := &streamMock{ctx: context.Background()}
+	err = RecoveryStreamInterceptor(logger)(nil, stream, &grpc.StreamServerInfo{FullMethod: "/user.v2.UserService/WatchUsers"}, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("closed channel")
+	})
+	c.Equal(codes.Internal, status.Code(err))
+	c.Contains(buffer.String(), "closed channel")
+}
+
+func TestShutdownStreamInterceptor(t *testing.T) {
+	c := require.New(t)
+
+	var streams sharedLib.Streams
+
+	stream := &streamMock{ctx: context.Background()}
+	err := ShutdownStreamInterceptor(&streams)(nil, stream, &grpc.StreamServerInfo{FullMethod: "/user.v2.UserService/WatchUsers"}, func(srv interface{}, stream grpc.ServerStream) error {
+		streams.Cancel()
+		<-stream.Context().Done()
+		return nil
+	})
+	c.NoError(err)
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	c := require.New(t)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	credentials, err := sharedLib.ParseCredentials([]string{"gateway:old secret", "gateway:new secret"})
+	c.NoError(err)
+
+	authenticator := NewTokenAuthenticator(credentials, nil, nil)
+	c.True(authenticator.Enabled())
+
+	_, err = authenticator.UnaryServerInterceptor(context.Background(), nil, userInfo, handler)
+	c.Equal(codes.Unauthenticated, status.Code(err))
+	c.True(errors.Is(sharedLib.FromGRPCError(err), sharedLib.ErrInvalidToken))
+
+	for _, authorization := range []string{"new secret", "Bearer wrong", "Basic old secret"} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(sharedLib.AuthorizationMetadataKey, authorization))
+		_, err = authenticator.UnaryServerInterceptor(ctx, nil, userInfo, handler)
+		c.Equal(codes.Unauthenticated, status.Code(err), authorization)
+	}
+
+	for _, token := range []string{"old secret", "new secret"} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(sharedLib.AuthorizationMetadataKey, sharedLib.BearerPrefix+token))
+		resp, err := authenticator.UnaryServerInterceptor(ctx, nil, userInfo, handler)
+		c.NoError(err)
+		c.Equal("ok", resp)
+	}
+
+	_, err = authenticator.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	c.NoError(err)
+
+	stream := &streamMock{ctx: context.Background()}
+	err = authenticator.StreamServerInterceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/user.v2.UserService/WatchUsers"}, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	c.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = NewTokenAuthenticator(sharedLib.Credentials{}, nil, nil).UnaryServerInterceptor(context.Background(), nil, userInfo, handler)
+	c.NoError(err)
+}
+
+func TestTokenAuthenticatorCaller(t *testing.T) {
+	c := require.New(t)
+
+	credentials, err := sharedLib.ParseCredentials([]string{"gateway:gateway secret", "batch:batch secret"})
+	c.NoError(err)
+
+	authenticator := NewTokenAuthenticator(credentials, []string{"gateway"}, []string{"admin"})
+
+	call := func(authenticator TokenAuthenticator, token string) context.Context {
+		md := metadata.Pairs(sharedLib.ActorMetadataKey, "admin", sharedLib.ClientIPMetadataKey, "10.0.0.1")
+		if token != "" {
+			md.Set(sharedLib.AuthorizationMetadataKey, sharedLib.BearerPrefix+token)
+		}
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 51234}})
+		ctx = metadata.NewIncomingContext(ctx, md)
+
+		var handled context.Context
+		_, err := authenticator.UnaryServerInterceptor(ctx, nil, userInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled = ctx
+			return nil, nil
+		})
+		c.NoError(err)
+
+		return handled
+	}
+
+	ctx := call(authenticator, "gateway secret")
+	c.Equal("admin", sharedLib.ActorFromContext(ctx), "the trusted callers act for their clients")
+	c.Equal("10.0.0.1", sharedLib.ClientIPFromContext(ctx))
+	c.True(sharedLib.AdminFromContext(ctx))
+
+	ctx = call(authenticator, "batch secret")
+	c.Equal("batch", sharedLib.ActorFromContext(ctx), "the other callers can not choose their actor")
+	c.Equal("10.0.0.2", sharedLib.ClientIPFromContext(ctx))
+	c.False(sharedLib.AdminFromContext(ctx))
+
+	ctx = call(NewTokenAuthenticator(sharedLib.Credentials{}, []string{"gateway"}, []string{"admin", sharedLib.AnonymousActor}), "")
+	c.Equal(sharedLib.AnonymousActor, sharedLib.ActorFromContext(ctx), "nobody is trusted without authentication")
+	c.Equal("10.0.0.2", sharedLib.ClientIPFromContext(ctx))
+	c.False(sharedLib.AdminFromContext(ctx))
+
+	stream := &streamMock{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(sharedLib.AuthorizationMetadataKey, sharedLib.BearerPrefix+"batch secret"))}
+	err = authenticator.StreamServerInterceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/user.v2.UserService/WatchUsers"}, func(srv interface{}, stream grpc.ServerStream) error {
+		c.Equal("batch", sharedLib.ActorFromContext(stream.Context()))
+		return nil
+	})
+	c.NoError(err)
+}
@@ -3,166 +3,464 @@ package transports
 import (
 	"context"
 	"strconv"
+	"time"
 
 	gt "github.com/go-kit/kit/transport/grpc"
 	"github.com/go-kit/log"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
 
 	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
 )
 
+// gRPCServer serves the v1 API, its user calls are translated onto the v2 server
 type gRPCServer struct {
 	pb.UnimplementedUserServiceServer
-	authenticate gt.Handler
-	createUser   gt.Handler
-	getUser      gt.Handler
-	updateUser   gt.Handler
-	deleteUser   gt.Handler
+	v2 pbv2.UserServiceServer
+
+	createUser                gt.Handler
+	updateUser                gt.Handler
+	createWebhookSubscription gt.Handler
+	listWebhookSubscriptions  gt.Handler
+	deleteWebhookSubscription gt.Handler
+	listWebhookDeliveries     gt.Handler
 }
 
-// NewGRPCServer initializes a new gRPC server
+// NewGRPCServer initializes the gRPC server of the v1 API
 func NewGRPCServer(endpoints endpoints.UserEndpoints, logger log.Logger) pb.UserServiceServer {
+	options := []gt.ServerOption{
+		gt.ServerBefore(contextFromMetadata),
+	}
+
 	return &gRPCServer{
-		authenticate: gt.NewServer(
-			endpoints.Authenticate,
-			decodeAuthenticateRequest,
-			encodeAuthenticateResponse,
-		),
+		v2: NewGRPCServerV2(endpoints, logger),
+
 		createUser: gt.NewServer(
 			endpoints.CreateUser,
 			decodeCreateUserRequest,
-			encodeCreateUserResponse,
-		),
-		getUser: gt.NewServer(
-			endpoints.GetUser,
-			decodeGetUserRequest,
-			encodeGetUserResponse,
+			encodeV2UserResponse,
+			options...,
 		),
 		updateUser: gt.NewServer(
 			endpoints.UpdateUser,
 			decodeUpdateUserRequest,
-			encodeUpdateUserResponse,
+			encodeV2UserResponse,
+			options...,
+		),
+		createWebhookSubscription: gt.NewServer(
+			endpoints.CreateWebhookSubscription,
+			decodeCreateWebhookSubscriptionRequest,
+			encodeCreateWebhookSubscriptionResponse,
+			options...,
 		),
-		deleteUser: gt.NewServer(
-			endpoints.DeleteUser,
-			decodeDeleteUserRequest,
-			encodeDeleteUserResponse,
+		listWebhookSubscriptions: gt.NewServer(
+			endpoints.ListWebhookSubscriptions,
+			decodeListWebhookSubscriptionsRequest,
+			encodeListWebhookSubscriptionsResponse,
+			options...,
+		),
+		deleteWebhookSubscription: gt.NewServer(
+			endpoints.DeleteWebhookSubscription,
+			decodeDeleteWebhookSubscriptionRequest,
+			encodeDeleteWebhookSubscriptionResponse,
+			options...,
+		),
+		listWebhookDeliveries: gt.NewServer(
+			endpoints.ListWebhookDeliveries,
+			decodeListWebhookDeliveriesRequest,
+			encodeListWebhookDeliveriesResponse,
+			options...,
 		),
 	}
 }
 
 // Authenticate is the gRPCServer method to authenticate
 func (s *gRPCServer) Authenticate(ctx context.Context, req *pb.UserAuthRequest) (*pb.UserAuthResponse, error) {
-	_, resp, err := s.authenticate.ServeGRPC(ctx, req)
+	resp, err := s.v2.Authenticate(ctx, &pbv2.AuthenticateRequest{
+		Username: req.Username,
+		Password: req.Password,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return resp.(*pb.UserAuthResponse), nil
+
+	return &pb.UserAuthResponse{
+		Message: resp.Message,
+	}, nil
 }
 
 // CreateUser is the gRPCServer method to create a user
 func (s *gRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
 	_, resp, err := s.createUser.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
-	return resp.(*pb.CreateUserResponse), nil
+
+	user := resp.(*pbv2.User)
+
+	return &pb.CreateUserResponse{
+		Id:                    user.Id,
+		Name:                  user.Name,
+		Age:                   legacyAge(user),
+		DateOfBirth:           user.DateOfBirth,
+		DateOfBirthEstimated:  user.DateOfBirthEstimated,
+		AgeYears:              user.Age,
+		AdditionalInformation: user.AdditionalInformation,
+		Parent:                user.Parents,
+		CreatedAt:             user.CreateTime,
+		UpdatedAt:             user.UpdateTime,
+		CreatedBy:             user.CreatedBy,
+		UpdatedBy:             user.UpdatedBy,
+	}, nil
 }
 
 // GetUser is the gRPCServer method to get a user
 func (s *gRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	_, resp, err := s.getUser.ServeGRPC(ctx, req)
+	user, err := s.v2.GetUser(ctx, &pbv2.GetUserRequest{
+		Id: req.Id,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return resp.(*pb.GetUserResponse), nil
+
+	return &pb.GetUserResponse{
+		Id:                    user.Id,
+		Name:                  user.Name,
+		Age:                   legacyAge(user),
+		DateOfBirth:           user.DateOfBirth,
+		DateOfBirthEstimated:  user.DateOfBirthEstimated,
+		AgeYears:              user.Age,
+		AdditionalInformation: user.AdditionalInformation,
+		Parent:                user.Parents,
+		CreatedAt:             user.CreateTime,
+		UpdatedAt:             user.UpdateTime,
+		CreatedBy:             user.CreatedBy,
+		UpdatedBy:             user.UpdatedBy,
+	}, nil
 }
 
-// UpdateUser is the gRPCServer method to update a user
+// UpdateUser is the gRPCServer method to update a user, v1 updates always replace the whole user
 func (s *gRPCServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
 	_, resp, err := s.updateUser.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
-	return resp.(*pb.UpdateUserResponse), nil
+
+	user := resp.(*pbv2.User)
+
+	return &pb.UpdateUserResponse{
+		Id:                    user.Id,
+		Name:                  user.Name,
+		Age:                   legacyAge(user),
+		DateOfBirth:           user.DateOfBirth,
+		DateOfBirthEstimated:  user.DateOfBirthEstimated,
+		AgeYears:              user.Age,
+		AdditionalInformation: user.AdditionalInformation,
+		Parent:                user.Parents,
+		CreatedAt:             user.CreateTime,
+		UpdatedAt:             user.UpdateTime,
+		CreatedBy:             user.CreatedBy,
+		UpdatedBy:             user.UpdatedBy,
+	}, nil
 }
 
 // DeleteUser is the gRPCServer method to delete a user
 func (s *gRPCServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
-	_, resp, err := s.deleteUser.ServeGRPC(ctx, req)
+	resp, err := s.v2.DeleteUser(ctx, &pbv2.DeleteUserRequest{
+		Id: req.Id,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return resp.(*pb.DeleteUserResponse), nil
-}
 
-func decodeCreateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
-	return request.(*pb.CreateUserRequest), nil
+	return &pb.DeleteUserResponse{
+		Message: resp.Message,
+	}, nil
 }
 
-func encodeCreateUserResponse(_ context.Context, response interface{}) (interface{}, error) {
-	resp := response.(sharedLib.User)
+// RestoreUser is the gRPCServer method to restore a deleted user
+func (s *gRPCServer) RestoreUser(ctx context.Context, req *pb.RestoreUserRequest) (*pb.RestoreUserResponse, error) {
+	user, err := s.v2.RestoreUser(ctx, &pbv2.RestoreUserRequest{
+		Id: req.Id,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return &pb.CreateUserResponse{
-		Id:                    resp.ID,
-		Name:                  resp.Name,
-		Age:                   strconv.Itoa(resp.Age),
-		AdditionalInformation: resp.AdditionalInformation,
-		Parent:                resp.Parents,
+	return &pb.RestoreUserResponse{
+		Id:                    user.Id,
+		Name:                  user.Name,
+		Age:                   legacyAge(user),
+		DateOfBirth:           user.DateOfBirth,
+		DateOfBirthEstimated:  user.DateOfBirthEstimated,
+		AgeYears:              user.Age,
+		AdditionalInformation: user.AdditionalInformation,
+		Parent:                user.Parents,
+		CreatedAt:             user.CreateTime,
+		UpdatedAt:             user.UpdateTime,
+		CreatedBy:             user.CreatedBy,
+		UpdatedBy:             user.UpdatedBy,
 	}, nil
 }
 
-func decodeAuthenticateRequest(_ context.Context, request interface{}) (interface{}, error) {
-	return request.(*pb.UserAuthRequest), nil
+// ListUsers is the gRPCServer method to list users
+func (s *gRPCServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	resp, err := s.v2.ListUsers(ctx, &pbv2.ListUsersRequest{
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		UpdatedAfter:  req.UpdatedAfter,
+		UpdatedBefore: req.UpdatedBefore,
+		CreatedBy:     req.CreatedBy,
+		UpdatedBy:     req.UpdatedBy,
+		Ids:           req.Ids,
+		PageSize:      req.PageSize,
+		PageToken:     req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*pb.User, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, &pb.User{
+			Id:                    user.Id,
+			Name:                  user.Name,
+			Age:                   legacyAge(user),
+			DateOfBirth:           user.DateOfBirth,
+			DateOfBirthEstimated:  user.DateOfBirthEstimated,
+			AgeYears:              user.Age,
+			AdditionalInformation: user.AdditionalInformation,
+			Parent:                user.Parents,
+			CreatedAt:             user.CreateTime,
+			UpdatedAt:             user.UpdateTime,
+			CreatedBy:             user.CreatedBy,
+			UpdatedBy:             user.UpdatedBy,
+		})
+	}
+
+	return &pb.ListUsersResponse{
+		Users:         users,
+		NextPageToken: resp.NextPageToken,
+	}, nil
 }
 
-func encodeAuthenticateResponse(_ context.Context, response interface{}) (interface{}, error) {
-	resp := response.(string)
-	return &pb.UserAuthResponse{
-		Message: resp,
+// ListAuditEvents is the gRPCServer method to list the audit events of a user
+func (s *gRPCServer) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	resp, err := s.v2.ListAuditEvents(ctx, &pbv2.ListAuditEventsRequest{
+		Target:    req.Target,
+		Targets:   req.Targets,
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*pb.AuditEvent, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		changes := make(map[string]*pb.AuditChange, len(event.Changes))
+		for field, change := range event.Changes {
+			changes[field] = &pb.AuditChange{
+				Before: change.Before,
+				After:  change.After,
+			}
+		}
+
+		events = append(events, &pb.AuditEvent{
+			Id:         event.Id,
+			OccurredAt: event.OccurTime,
+			Actor:      event.Actor,
+			Action:     event.Action,
+			Target:     event.Target,
+			Changes:    changes,
+			RequestId:  event.RequestId,
+			ClientIp:   event.ClientIp,
+			Outcome:    event.Outcome,
+			Reason:     event.Reason,
+		})
+	}
+
+	return &pb.ListAuditEventsResponse{
+		Events:         events,
+		NextPageToken:  resp.NextPageToken,
+		NextPageTokens: resp.NextPageTokens,
 	}, nil
 }
 
-func decodeGetUserRequest(_ context.Context, request interface{}) (interface{}, error) {
-	return request.(*pb.GetUserRequest), nil
+// WatchUsers is the gRPCServer method to stream the user changes, it is served by the v2 server
+func (s *gRPCServer) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserService_WatchUsersServer) error {
+	return s.v2.WatchUsers(&pbv2.WatchUsersRequest{
+		UserId: req.UserId,
+		Cursor: req.Cursor,
+	}, watchUsersStreamV1{stream})
 }
 
-func encodeGetUserResponse(_ context.Context, response interface{}) (interface{}, error) {
-	resp := response.(sharedLib.User)
+// watchUsersStreamV1 sends the events streamed by the v2 WatchUsers to a v1 stream
+type watchUsersStreamV1 struct {
+	pb.UserService_WatchUsersServer
+}
 
-	return &pb.GetUserResponse{
-		Id:                    resp.ID,
-		Name:                  resp.Name,
-		Age:                   strconv.Itoa(resp.Age),
-		AdditionalInformation: resp.AdditionalInformation,
-		Parent:                resp.Parents,
-	}, nil
+// Send is the watchUsersStreamV1 method to send a v2 event as a v1 one
+func (s watchUsersStreamV1) Send(event *pbv2.UserEvent) error {
+	return s.UserService_WatchUsersServer.Send(&pb.UserEvent{
+		Cursor:     event.Cursor,
+		Type:       event.Type,
+		UserId:     event.UserId,
+		OccurredAt: event.OccurTime,
+		Actor:      event.Actor,
+		RequestId:  event.RequestId,
+		Payload:    event.Payload,
+		Id:         event.Id,
+	})
+}
+
+// legacyAge is the deprecated string age of v1 responses
+func legacyAge(user *pbv2.User) string {
+	return strconv.Itoa(int(user.Age))
+}
+
+// CreateWebhookSubscription is the gRPCServer method to subscribe a URL to user events
+func (s *gRPCServer) CreateWebhookSubscription(ctx context.Context, req *pb.CreateWebhookSubscriptionRequest) (*pb.WebhookSubscription, error) {
+	_, resp, err := s.createWebhookSubscription.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pb.WebhookSubscription), nil
+}
+
+// ListWebhookSubscriptions is the gRPCServer method to list the webhook subscriptions
+func (s *gRPCServer) ListWebhookSubscriptions(ctx context.Context, req *pb.ListWebhookSubscriptionsRequest) (*pb.ListWebhookSubscriptionsResponse, error) {
+	_, resp, err := s.listWebhookSubscriptions.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pb.ListWebhookSubscriptionsResponse), nil
+}
+
+// DeleteWebhookSubscription is the gRPCServer method to delete a webhook subscription
+func (s *gRPCServer) DeleteWebhookSubscription(ctx context.Context, req *pb.DeleteWebhookSubscriptionRequest) (*pb.DeleteWebhookSubscriptionResponse, error) {
+	_, resp, err := s.deleteWebhookSubscription.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pb.DeleteWebhookSubscriptionResponse), nil
+}
+
+// ListWebhookDeliveries is the gRPCServer method to list the deliveries of a webhook subscription
+func (s *gRPCServer) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	_, resp, err := s.listWebhookDeliveries.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pb.ListWebhookDeliveriesResponse), nil
+}
+
+// contextFromMetadata moves the request ID sent by the caller into the context
+func contextFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	return sharedLib.ContextWithRequestID(ctx, firstMetadataValue(md, sharedLib.RequestIDMetadataKey))
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func encodeTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func decodeCreateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(*pb.CreateUserRequest), nil
 }
 
 func decodeUpdateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
 	return request.(*pb.UpdateUserRequest), nil
 }
 
-func encodeUpdateUserResponse(_ context.Context, response interface{}) (interface{}, error) {
-	resp := response.(sharedLib.User)
+func decodeCreateWebhookSubscriptionRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(*pb.CreateWebhookSubscriptionRequest), nil
+}
 
-	return &pb.UpdateUserResponse{
-		Id:                    resp.ID,
-		Name:                  resp.Name,
-		Age:                   strconv.Itoa(resp.Age),
-		AdditionalInformation: resp.AdditionalInformation,
-		Parent:                resp.Parents,
+func encodeCreateWebhookSubscriptionResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return encodeWebhookSubscription(response.(sharedLib.WebhookSubscription)), nil
+}
+
+func decodeListWebhookSubscriptionsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(*pb.ListWebhookSubscriptionsRequest), nil
+}
+
+func encodeListWebhookSubscriptionsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.([]sharedLib.WebhookSubscription)
+
+	subscriptions := make([]*pb.WebhookSubscription, 0, len(resp))
+	for _, subscription := range resp {
+		subscriptions = append(subscriptions, encodeWebhookSubscription(subscription))
+	}
+
+	return &pb.ListWebhookSubscriptionsResponse{
+		Subscriptions: subscriptions,
 	}, nil
 }
 
-func decodeDeleteUserRequest(_ context.Context, request interface{}) (interface{}, error) {
-	return request.(*pb.DeleteUserRequest), nil
+func decodeDeleteWebhookSubscriptionRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(*pb.DeleteWebhookSubscriptionRequest), nil
 }
 
-func encodeDeleteUserResponse(_ context.Context, response interface{}) (interface{}, error) {
-	return &pb.DeleteUserResponse{
+func encodeDeleteWebhookSubscriptionResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return &pb.DeleteWebhookSubscriptionResponse{
 		Message: response.(string),
 	}, nil
 }
+
+func decodeListWebhookDeliveriesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(*pb.ListWebhookDeliveriesRequest), nil
+}
+
+func encodeListWebhookDeliveriesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(sharedLib.WebhookDeliveryPage)
+
+	deliveries := make([]*pb.WebhookDelivery, 0, len(resp.Deliveries))
+	for _, delivery := range resp.Deliveries {
+		deliveries = append(deliveries, &pb.WebhookDelivery{
+			Id:             delivery.ID,
+			SubscriptionId: delivery.SubscriptionID,
+			EventId:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       int32(delivery.Attempts),
+			NextAttemptAt:  encodeTimestamp(delivery.NextAttemptAt),
+			LastAttemptAt:  encodeTimestamp(delivery.LastAttemptAt),
+			ResponseStatus: int32(delivery.ResponseStatus),
+			LastError:      delivery.LastError,
+			CreatedAt:      encodeTimestamp(delivery.CreatedAt),
+		})
+	}
+
+	return &pb.ListWebhookDeliveriesResponse{
+		Deliveries:    deliveries,
+		NextPageToken: resp.NextPageToken,
+	}, nil
+}
+
+func encodeWebhookSubscription(subscription sharedLib.WebhookSubscription) *pb.WebhookSubscription {
+	return &pb.WebhookSubscription{
+		Id:         subscription.ID,
+		Url:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Secret:     subscription.Secret,
+		CreatedAt:  encodeTimestamp(subscription.CreatedAt),
+		CreatedBy:  subscription.CreatedBy,
+	}
+}
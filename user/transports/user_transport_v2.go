@@ -0,0 +1,379 @@
+package transports
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	gt "github.com/go-kit/kit/transport/grpc"
+	"github.com/go-kit/log"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
+	"github.com/jumaroar-globant/go-bootcamp/user/pb"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
+
+	sharedLib "github.com/jumaroar-globant/go-bootcamp/shared"
+)
+
+type gRPCServerV2 struct {
+	pbv2.UnimplementedUserServiceServer
+	authenticate    gt.Handler
+	createUser      gt.Handler
+	getUser         gt.Handler
+	updateUser      gt.Handler
+	patchUser       gt.Handler
+	deleteUser      gt.Handler
+	restoreUser     gt.Handler
+	listUsers       gt.Handler
+	listAuditEvents gt.Handler
+	watchUsers      endpoint.Endpoint
+}
+
+// NewGRPCServerV2 initializes the gRPC server of the v2 API, it translates the v2 messages onto the same endpoints as v1
+func NewGRPCServerV2(endpoints endpoints.UserEndpoints, logger log.Logger) pbv2.UserServiceServer {
+	options := []gt.ServerOption{
+		gt.ServerBefore(contextFromMetadata),
+	}
+
+	return &gRPCServerV2{
+		authenticate: gt.NewServer(
+			endpoints.Authenticate,
+			decodeV2AuthenticateRequest,
+			encodeV2AuthenticateResponse,
+			options...,
+		),
+		createUser: gt.NewServer(
+			endpoints.CreateUser,
+			decodeV2CreateUserRequest,
+			encodeV2UserResponse,
+			options...,
+		),
+		getUser: gt.NewServer(
+			endpoints.GetUser,
+			decodeV2GetUserRequest,
+			encodeV2UserResponse,
+			options...,
+		),
+		updateUser: gt.NewServer(
+			endpoints.UpdateUser,
+			decodeV2UpdateUserRequest,
+			encodeV2UserResponse,
+			options...,
+		),
+		patchUser: gt.NewServer(
+			endpoints.PatchUser,
+			decodeV2PatchUserRequest,
+			encodeV2UserResponse,
+			options...,
+		),
+		deleteUser: gt.NewServer(
+			endpoints.DeleteUser,
+			decodeV2DeleteUserRequest,
+			encodeV2DeleteUserResponse,
+			options...,
+		),
+		restoreUser: gt.NewServer(
+			endpoints.RestoreUser,
+			decodeV2RestoreUserRequest,
+			encodeV2UserResponse,
+			options...,
+		),
+		listUsers: gt.NewServer(
+			endpoints.ListUsers,
+			decodeV2ListUsersRequest,
+			encodeV2ListUsersResponse,
+			options...,
+		),
+		listAuditEvents: gt.NewServer(
+			endpoints.ListAuditEvents,
+			decodeV2ListAuditEventsRequest,
+			encodeV2ListAuditEventsResponse,
+			options...,
+		),
+		watchUsers: endpoints.WatchUsers,
+	}
+}
+
+// Authenticate is the gRPCServerV2 method to authenticate
+func (s *gRPCServerV2) Authenticate(ctx context.Context, req *pbv2.AuthenticateRequest) (*pbv2.AuthenticateResponse, error) {
+	_, resp, err := s.authenticate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.AuthenticateResponse), nil
+}
+
+// CreateUser is the gRPCServerV2 method to create a user
+func (s *gRPCServerV2) CreateUser(ctx context.Context, req *pbv2.CreateUserRequest) (*pbv2.User, error) {
+	_, resp, err := s.createUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.User), nil
+}
+
+// GetUser is the gRPCServerV2 method to get a user
+func (s *gRPCServerV2) GetUser(ctx context.Context, req *pbv2.GetUserRequest) (*pbv2.User, error) {
+	_, resp, err := s.getUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.User), nil
+}
+
+// UpdateUser is the gRPCServerV2 method to update a user, it replaces the whole user when the update mask is empty
+func (s *gRPCServerV2) UpdateUser(ctx context.Context, req *pbv2.UpdateUserRequest) (*pbv2.User, error) {
+	handler := s.updateUser
+	if len(req.GetUpdateMask().GetPaths()) > 0 {
+		handler = s.patchUser
+	}
+
+	_, resp, err := handler.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.User), nil
+}
+
+// DeleteUser is the gRPCServerV2 method to delete a user
+func (s *gRPCServerV2) DeleteUser(ctx context.Context, req *pbv2.DeleteUserRequest) (*pbv2.DeleteUserResponse, error) {
+	_, resp, err := s.deleteUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.DeleteUserResponse), nil
+}
+
+// RestoreUser is the gRPCServerV2 method to restore a deleted user
+func (s *gRPCServerV2) RestoreUser(ctx context.Context, req *pbv2.RestoreUserRequest) (*pbv2.User, error) {
+	_, resp, err := s.restoreUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.User), nil
+}
+
+// ListUsers is the gRPCServerV2 method to list users
+func (s *gRPCServerV2) ListUsers(ctx context.Context, req *pbv2.ListUsersRequest) (*pbv2.ListUsersResponse, error) {
+	_, resp, err := s.listUsers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.ListUsersResponse), nil
+}
+
+// ListAuditEvents is the gRPCServerV2 method to list the audit events of a user
+func (s *gRPCServerV2) ListAuditEvents(ctx context.Context, req *pbv2.ListAuditEventsRequest) (*pbv2.ListAuditEventsResponse, error) {
+	_, resp, err := s.listAuditEvents.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return resp.(*pbv2.ListAuditEventsResponse), nil
+}
+
+// WatchUsers is the gRPCServerV2 method to stream the user changes, see gRPCServer.WatchUsers
+func (s *gRPCServerV2) WatchUsers(req *pbv2.WatchUsersRequest, stream pbv2.UserService_WatchUsersServer) error {
+	ctx := stream.Context()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = contextFromMetadata(ctx, md)
+
+	_, err := s.watchUsers(ctx, endpoints.WatchUsersRequest{
+		Request: &pb.WatchUsersRequest{
+			UserId: req.UserId,
+			Cursor: req.Cursor,
+		},
+		Send: func(event sharedLib.Event) error {
+			return stream.Send(encodeV2UserEvent(event))
+		},
+	})
+
+	return encodeError(err)
+}
+
+func decodeV2AuthenticateRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*pbv2.AuthenticateRequest)
+
+	return &pb.UserAuthRequest{
+		Username: req.Username,
+		Password: req.Password,
+	}, nil
+}
+
+func encodeV2AuthenticateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return &pbv2.AuthenticateResponse{
+		Message: response.(string),
+	}, nil
+}
+
+func decodeV2CreateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*pbv2.CreateUserRequest)
+	user := req.GetUser()
+
+	return &pb.CreateUserRequest{
+		Name:                  user.GetName(),
+		Password:              req.Password,
+		DateOfBirth:           user.GetDateOfBirth(),
+		AdditionalInformation: user.GetAdditionalInformation(),
+		Parent:                user.GetParents(),
+	}, nil
+}
+
+func decodeV2GetUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.GetUserRequest{
+		Id: request.(*pbv2.GetUserRequest).Id,
+	}, nil
+}
+
+func decodeV2UpdateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	user := request.(*pbv2.UpdateUserRequest).GetUser()
+
+	return &pb.UpdateUserRequest{
+		Id:                    user.GetId(),
+		Name:                  user.GetName(),
+		DateOfBirth:           user.GetDateOfBirth(),
+		AdditionalInformation: user.GetAdditionalInformation(),
+		Parent:                user.GetParents(),
+	}, nil
+}
+
+func decodeV2PatchUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*pbv2.UpdateUserRequest)
+	user := req.GetUser()
+
+	return endpoints.PatchUserRequest{
+		User: sharedLib.User{
+			ID:                    user.GetId(),
+			Name:                  user.GetName(),
+			DateOfBirth:           sharedLib.DateFromProto(user.GetDateOfBirth()),
+			AdditionalInformation: user.GetAdditionalInformation(),
+			Parents:               user.GetParents(),
+		},
+		Paths: req.GetUpdateMask().GetPaths(),
+	}, nil
+}
+
+func decodeV2DeleteUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.DeleteUserRequest{
+		Id: request.(*pbv2.DeleteUserRequest).Id,
+	}, nil
+}
+
+func encodeV2DeleteUserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return &pbv2.DeleteUserResponse{
+		Message: response.(string),
+	}, nil
+}
+
+func decodeV2RestoreUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.RestoreUserRequest{
+		Id: request.(*pbv2.RestoreUserRequest).Id,
+	}, nil
+}
+
+func encodeV2UserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return encodeV2User(response.(sharedLib.User)), nil
+}
+
+func decodeV2ListUsersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*pbv2.ListUsersRequest)
+
+	return &pb.ListUsersRequest{
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		UpdatedAfter:  req.UpdatedAfter,
+		UpdatedBefore: req.UpdatedBefore,
+		CreatedBy:     req.CreatedBy,
+		UpdatedBy:     req.UpdatedBy,
+		Ids:           req.Ids,
+		PageSize:      req.PageSize,
+		PageToken:     req.PageToken,
+	}, nil
+}
+
+func encodeV2ListUsersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(sharedLib.UserPage)
+
+	users := make([]*pbv2.User, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, encodeV2User(user))
+	}
+
+	return &pbv2.ListUsersResponse{
+		Users:         users,
+		NextPageToken: resp.NextPageToken,
+	}, nil
+}
+
+func decodeV2ListAuditEventsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*pbv2.ListAuditEventsRequest)
+
+	return &pb.ListAuditEventsRequest{
+		Target:    req.Target,
+		Targets:   req.Targets,
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+	}, nil
+}
+
+func encodeV2ListAuditEventsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(sharedLib.AuditEventPage)
+
+	events := make([]*pbv2.AuditEvent, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		changes := make(map[string]*pbv2.AuditChange, len(event.Changes))
+		for field, change := range event.Changes {
+			changes[field] = &pbv2.AuditChange{
+				Before: change.Before,
+				After:  change.After,
+			}
+		}
+
+		events = append(events, &pbv2.AuditEvent{
+			Id:        event.ID,
+			OccurTime: encodeTimestamp(event.OccurredAt),
+			Actor:     event.Actor,
+			Action:    event.Action,
+			Target:    event.Target,
+			Changes:   changes,
+			RequestId: event.RequestID,
+			ClientIp:  event.ClientIP,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+		})
+	}
+
+	return &pbv2.ListAuditEventsResponse{
+		Events:         events,
+		NextPageToken:  resp.NextPageToken,
+		NextPageTokens: resp.NextPageTokens,
+	}, nil
+}
+
+func encodeV2User(user sharedLib.User) *pbv2.User {
+	return &pbv2.User{
+		Id:                    user.ID,
+		Name:                  user.Name,
+		DateOfBirth:           user.DateOfBirth.Proto(),
+		DateOfBirthEstimated:  user.DateOfBirthEstimated,
+		Age:                   int32(user.Age),
+		AdditionalInformation: user.AdditionalInformation,
+		Parents:               user.Parents,
+		CreateTime:            encodeTimestamp(user.CreatedAt),
+		UpdateTime:            encodeTimestamp(user.UpdatedAt),
+		CreatedBy:             user.CreatedBy,
+		UpdatedBy:             user.UpdatedBy,
+	}
+}
+
+func encodeV2UserEvent(event sharedLib.Event) *pbv2.UserEvent {
+	return &pbv2.UserEvent{
+		Id:        event.ID,
+		Cursor:    event.Cursor,
+		Type:      event.Type,
+		UserId:    event.AggregateID,
+		OccurTime: encodeTimestamp(event.OccurredAt),
+		Actor:     event.Actor,
+		RequestId: event.RequestID,
+		Payload:   string(event.Payload),
+	}
+}
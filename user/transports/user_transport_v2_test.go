@@ -0,0 +1,114 @@
+package transports
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
+	"github.com/jumaroar-globant/go-bootcamp/user/config"
+	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
+	pbv2 "github.com/jumaroar-globant/go-bootcamp/user/pb/v2"
+	"github.com/jumaroar-globant/go-bootcamp/user/repository"
+	"github.com/jumaroar-globant/go-bootcamp/user/service"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestCreateUserV2(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	grpcServer := NewGRPCServerV2(endpoints.MakeEndpoints(svc), logger)
+
+	dateOfBirth := time.Now().UTC().AddDate(-30, 0, 0)
+
+	req := &pbv2.CreateUserRequest{
+		User: &pbv2.User{
+			Name:        "test",
+			DateOfBirth: &date.Date{Year: int32(dateOfBirth.Year()), Month: int32(dateOfBirth.Month()), Day: int32(dateOfBirth.Day())},
+			Parents:     []string{"John Doe"},
+		},
+		Password: "clave123",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertUserStatement)).WithArgs(sqlmock.AnyArg(), "test", sqlmock.AnyArg(), 30, "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), shared.NewDate(dateOfBirth).Time(), false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertParentStatement)).WithArgs(sqlmock.AnyArg(), "John Doe").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	user, err := grpcServer.CreateUser(context.Background(), req)
+	c.NoError(err)
+	c.Equal("test", user.Name)
+	c.Equal(int32(30), user.Age)
+	c.Equal(req.User.DateOfBirth, user.DateOfBirth)
+	c.NotNil(user.CreateTime)
+	c.NoError(mock.ExpectationsWereMet())
+
+	req.User.DateOfBirth = nil
+	_, err = grpcServer.CreateUser(context.Background(), req)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.Equal([]shared.FieldViolation{{Field: "date_of_birth", Message: "is required"}}, shared.AsError(shared.FromGRPCError(err)).FieldViolations())
+}
+
+func TestUpdateUserV2WithUpdateMask(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	grpcServer := NewGRPCServerV2(endpoints.MakeEndpoints(svc), logger)
+
+	columns := []string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "", "", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "not much", time.Time{}, time.Time{}, "", "", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+	mock.ExpectExec(regexp.QuoteMeta(repository.UpdateUserStatement)).WithArgs("test", 99, nil, false, "a lot", sqlmock.AnyArg(), sqlmock.AnyArg(), "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteUserParentsStatement)).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertParentStatement)).WithArgs("USR123", "John Doe").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows(columns).AddRow("USR123", "test", 99, "a lot", time.Time{}, time.Time{}, "", "", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	req := &pbv2.UpdateUserRequest{
+		User:       &pbv2.User{Id: "USR123", AdditionalInformation: "a lot"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{shared.UserPathAdditionalInformation}},
+	}
+
+	user, err := grpcServer.UpdateUser(context.Background(), req)
+	c.NoError(err)
+	c.Equal("test", user.Name)
+	c.Equal("a lot", user.AdditionalInformation)
+	c.Equal([]string{"John Doe"}, user.Parents)
+	c.NoError(mock.ExpectationsWereMet())
+
+	req.UpdateMask.Paths = []string{"password"}
+	_, err = grpcServer.UpdateUser(context.Background(), req)
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+	c.Equal("update_mask", shared.AsError(shared.FromGRPCError(err)).FieldViolations()[0].Field)
+}
@@ -2,13 +2,16 @@ package transports
 
 import (
 	"context"
+	"errors"
 	"os"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
+	"github.com/jumaroar-globant/go-bootcamp/shared"
 	"github.com/jumaroar-globant/go-bootcamp/user/config"
 	"github.com/jumaroar-globant/go-bootcamp/user/endpoints"
 	"github.com/jumaroar-globant/go-bootcamp/user/pb"
@@ -18,6 +21,10 @@ import (
 	sharedLib "github.com/jumaroar-globant/go-bootcamp/user/shared"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestAuthenticate(t *testing.T) {
@@ -43,10 +50,14 @@ func TestAuthenticate(t *testing.T) {
 		Password: "testPassword",
 	}
 
-	row := sqlmock.NewRows([]string{"password_hash"}).AddRow(passwordHash)
+	row := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow("USR123", passwordHash)
 
 	sqlString := regexp.QuoteMeta(repository.PasswordHashQuery)
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnRows(row)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := grpcServer.Authenticate(context.Background(), req)
 
@@ -56,7 +67,7 @@ func TestAuthenticate(t *testing.T) {
 	mock.ExpectQuery(sqlString).WithArgs(username).WillReturnError(config.ErrMockFails)
 
 	_, err = grpcServer.Authenticate(context.Background(), req)
-	c.Equal(config.ErrMockFails, err)
+	c.Equal(codes.Internal, status.Code(err))
 }
 
 func TestCreateUser(t *testing.T) {
@@ -83,12 +94,17 @@ func TestCreateUser(t *testing.T) {
 	intAge, err := strconv.Atoi(req.Age)
 	c.NoError(err)
 
+	mock.ExpectBegin()
+
 	sqlString := regexp.QuoteMeta(repository.InsertUserStatement)
-	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), req.Name, sqlmock.AnyArg(), intAge, req.AdditionalInformation).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), req.Name, sqlmock.AnyArg(), intAge, req.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	parentSSQLString := regexp.QuoteMeta(repository.InsertParentStatement)
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), req.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentSSQLString).WithArgs(sqlmock.AnyArg(), req.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := grpcServer.CreateUser(context.Background(), req)
 
@@ -97,7 +113,8 @@ func TestCreateUser(t *testing.T) {
 
 	req.Name = ""
 	_, err = grpcServer.CreateUser(context.Background(), req)
-	c.Equal(service.ErrMissingUserName, err)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
 }
 
 func TestGetUser(t *testing.T) {
@@ -121,6 +138,7 @@ func TestGetUser(t *testing.T) {
 		Id:                    "USR123",
 		Name:                  "test",
 		Age:                   "99",
+		AgeYears:              99,
 		AdditionalInformation: "not much",
 		Parent:                []string{"John Doe", "Jane Doe"},
 	}
@@ -128,9 +146,9 @@ func TestGetUser(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.Id, user.Name, intAge, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, user.Name, intAge, user.AdditionalInformation, time.Time{}, time.Time{}, "", "", nil, false)
 
-	sqlString := regexp.QuoteMeta(`SELECT id, name, age, additional_information FROM users WHERE id=?`)
+	sqlString := regexp.QuoteMeta(`SELECT id, name, age, additional_information, created_at, updated_at, created_by, updated_by, date_of_birth, date_of_birth_estimated FROM users WHERE id=? AND deleted_at IS NULL`)
 	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
 
 	parentSSQLString := regexp.QuoteMeta(`SELECT name FROM user_parents WHERE user_id=?`)
@@ -147,7 +165,8 @@ func TestGetUser(t *testing.T) {
 	req.Id = ""
 
 	_, err = grpcServer.GetUser(context.Background(), req)
-	c.Equal(service.ErrMissingUserID, err)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
 }
 
 func TestUpdateUser(t *testing.T) {
@@ -174,9 +193,19 @@ func TestUpdateUser(t *testing.T) {
 	intAge, err := strconv.Atoi(user.Age)
 	c.NoError(err)
 
+	storedRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(storedRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs(user.Id).WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs(user.Id).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
 	sqlUpdateString := regexp.QuoteMeta(repository.UpdateUserStatement)
 
-	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, user.AdditionalInformation, user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlUpdateString).WithArgs(user.Name, intAge, sqlmock.AnyArg(), true, user.AdditionalInformation, sqlmock.AnyArg(), sqlmock.AnyArg(), user.Id).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	sqlDeleteString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
 
@@ -187,7 +216,7 @@ func TestUpdateUser(t *testing.T) {
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[0]).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(parentsSQLInsertString).WithArgs(user.Id, user.Parent[1]).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation)
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, user.Name, user.Age, user.AdditionalInformation, time.Time{}, time.Time{}, "", "", nil, false)
 
 	sqlSelectString := regexp.QuoteMeta(repository.UserDataQuery)
 
@@ -198,6 +227,9 @@ func TestUpdateUser(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parent[0]).AddRow(user.Parent[1])
 
 	mock.ExpectQuery(parentSSQLString).WithArgs(user.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := grpcServer.UpdateUser(context.Background(), user)
 
@@ -207,7 +239,8 @@ func TestUpdateUser(t *testing.T) {
 	user.Id = ""
 
 	_, err = grpcServer.UpdateUser(context.Background(), user)
-	c.Equal(service.ErrMissingUserID, err)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
 }
 
 func TestDeleteUser(t *testing.T) {
@@ -227,11 +260,17 @@ func TestDeleteUser(t *testing.T) {
 		Id: "USR123",
 	}
 
-	sqlString := regexp.QuoteMeta(repository.DeleteUserParentsStatement)
-	mock.ExpectExec(sqlString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
 
-	parentSSQLString := regexp.QuoteMeta(repository.DeleteUserStatement)
-	mock.ExpectExec(parentSSQLString).WithArgs("USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	sqlString := regexp.QuoteMeta(repository.DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	result, err := grpcServer.DeleteUser(context.Background(), req)
 
@@ -241,5 +280,416 @@ func TestDeleteUser(t *testing.T) {
 	req.Id = ""
 
 	_, err = grpcServer.DeleteUser(context.Background(), req)
-	c.Equal(service.ErrMissingUserID, err)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+}
+
+func TestRestoreUser(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	req := &pb.RestoreUserRequest{
+		Id: "USR123",
+	}
+
+	user := &pb.RestoreUserResponse{
+		Id:                    "USR123",
+		Name:                  "test",
+		Age:                   "99",
+		AgeYears:              99,
+		AdditionalInformation: "not much",
+		Parent:                []string{"John Doe", "Jane Doe"},
+	}
+
+	intAge, err := strconv.Atoi(user.Age)
+	c.NoError(err)
+
+	mock.ExpectBegin()
+
+	sqlRestoreString := regexp.QuoteMeta(repository.RestoreUserStatement)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.LiveUserNameCountQuery)).WithArgs(req.Id).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(sqlRestoreString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), req.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	row := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow(user.Id, user.Name, intAge, user.AdditionalInformation, time.Time{}, time.Time{}, "", "", nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.UserDataQuery)
+	mock.ExpectQuery(sqlString).WithArgs(req.Id).WillReturnRows(row)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+
+	rows := sqlmock.NewRows([]string{"name"}).AddRow(user.Parent[0]).AddRow(user.Parent[1])
+
+	mock.ExpectQuery(parentSSQLString).WithArgs(req.Id).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := grpcServer.RestoreUser(context.Background(), req)
+
+	c.Equal(user, result)
+	c.NoError(err)
+
+	req.Id = ""
+
+	_, err = grpcServer.RestoreUser(context.Background(), req)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+}
+
+func TestListUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	createdAt := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+	req := &pb.ListUsersRequest{
+		PageSize: 1,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).
+		AddRow("USR123", "test", 99, "not much", createdAt, createdAt, "admin", "admin", nil, false).
+		AddRow("USR456", "other", 42, "", createdAt, createdAt, "admin", "admin", nil, false)
+
+	sqlString := regexp.QuoteMeta(repository.ListUsersQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(2).WillReturnRows(rows)
+
+	parentSSQLString := regexp.QuoteMeta(repository.UserParentsQuery)
+	mock.ExpectQuery(parentSSQLString).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("John Doe"))
+
+	result, err := grpcServer.ListUsers(context.Background(), req)
+	c.NoError(err)
+
+	c.Equal(&pb.ListUsersResponse{
+		Users: []*pb.User{
+			{
+				Id:                    "USR123",
+				Name:                  "test",
+				Age:                   "99",
+				AgeYears:              99,
+				AdditionalInformation: "not much",
+				Parent:                []string{"John Doe"},
+				CreatedAt:             timestamppb.New(createdAt),
+				UpdatedAt:             timestamppb.New(createdAt),
+				CreatedBy:             "admin",
+				UpdatedBy:             "admin",
+			},
+		},
+		NextPageToken: "USR123",
+	}, result)
+}
+
+func TestContextFromMetadata(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(shared.RequestIDMetadataKey, "REQ123"))
+	ctx = shared.ContextWithClientIP(shared.ContextWithActor(ctx, "admin"), "10.0.0.1")
+
+	mock.ExpectBegin()
+
+	beforeRow := sqlmock.NewRows([]string{"id", "name", "age", "additional_information", "created_at", "updated_at", "created_by", "updated_by", "date_of_birth", "date_of_birth_estimated"}).AddRow("USR123", "old name", 98, "", time.Time{}, time.Time{}, "", "", nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserDataQuery)).WithArgs("USR123").WillReturnRows(beforeRow)
+	mock.ExpectQuery(regexp.QuoteMeta(repository.UserParentsQuery)).WithArgs("USR123").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	sqlString := regexp.QuoteMeta(repository.DeleteUserStatement)
+	mock.ExpectExec(sqlString).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "admin", "USR123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertOutboxEventStatement)).WithArgs(shared.EventUserDeleted, "USR123", sqlmock.AnyArg(), "admin", "REQ123", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WithArgs(sqlmock.AnyArg(), "admin", shared.AuditActionDeleteUser, "USR123", sqlmock.AnyArg(), "REQ123", "10.0.0.1", shared.AuditOutcomeSuccess, "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	_, err := grpcServer.DeleteUser(ctx, &pb.DeleteUserRequest{Id: "USR123"})
+	c.NoError(err)
+	c.NoError(mock.ExpectationsWereMet())
+
+	ctx = contextFromMetadata(context.Background(), metadata.Pairs(shared.ActorMetadataKey, "admin", shared.ClientIPMetadataKey, "10.0.0.1"))
+
+	c.Empty(shared.ClientIPFromContext(ctx), "the metadata is only trusted by the TokenAuthenticator")
+	c.Equal(shared.AnonymousActor, shared.ActorFromContext(ctx))
+	c.Empty(shared.RequestIDFromContext(ctx))
+}
+
+func TestListAuditEvents(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target", "changes", "request_id", "client_ip", "outcome", "reason"}).
+		AddRow(1, occurredAt, "admin", shared.AuditActionUpdateUser, "USR123", `{"name":{"before":"old","after":"new"}}`, "REQ123", "10.0.0.1", shared.AuditOutcomeSuccess, "")
+
+	sqlString := regexp.QuoteMeta(repository.ListAuditEventsQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("USR123", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	result, err := grpcServer.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Target: "USR123"})
+	c.NoError(err)
+
+	c.Equal(&pb.ListAuditEventsResponse{
+		Events: []*pb.AuditEvent{
+			{
+				Id:         1,
+				OccurredAt: timestamppb.New(occurredAt),
+				Actor:      "admin",
+				Action:     shared.AuditActionUpdateUser,
+				Target:     "USR123",
+				Changes:    map[string]*pb.AuditChange{"name": {Before: "old", After: "new"}},
+				RequestId:  "REQ123",
+				ClientIp:   "10.0.0.1",
+				Outcome:    shared.AuditOutcomeSuccess,
+			},
+		},
+	}, result)
+
+	_, err = grpcServer.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{})
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+}
+
+type watchUsersStream struct {
+	pb.UserService_WatchUsersServer
+	ctx    context.Context
+	cancel context.CancelFunc
+	events []*pb.UserEvent
+}
+
+func (s *watchUsersStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchUsersStream) Send(event *pb.UserEvent) error {
+	s.events = append(s.events, event)
+	s.cancel()
+
+	return nil
+}
+
+func TestWatchUsers(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	occurredAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "occurred_at", "actor", "request_id", "payload"}).
+		AddRow(8, shared.EventUserUpdated, "USR123", occurredAt, "admin", "REQ123", `{"id":"USR123"}`)
+
+	sqlString := regexp.QuoteMeta(repository.ListEventsQuery + " AND aggregate_id = ? AND event_type IN (?, ?, ?, ?) ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs(int64(7), "USR123", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), repository.DefaultPageSize).WillReturnRows(rows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &watchUsersStream{ctx: ctx, cancel: cancel}
+
+	err := grpcServer.WatchUsers(&pb.WatchUsersRequest{UserId: "USR123", Cursor: "7"}, stream)
+	c.NoError(err)
+
+	c.Equal([]*pb.UserEvent{
+		{
+			Id:         8,
+			Cursor:     "8",
+			Type:       shared.EventUserUpdated,
+			UserId:     "USR123",
+			OccurredAt: timestamppb.New(occurredAt),
+			Actor:      "admin",
+			RequestId:  "REQ123",
+			Payload:    `{"id":"USR123"}`,
+		},
+	}, stream.events)
+
+	stream = &watchUsersStream{ctx: context.Background(), cancel: func() {}}
+
+	err = grpcServer.WatchUsers(&pb.WatchUsersRequest{Cursor: "bad"}, stream)
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertWebhookSubscriptionStatement)).
+		WithArgs(sqlmock.AnyArg(), "https://93.184.216.34/hook", `["user.deleted"]`, "secret", sqlmock.AnyArg(), "admin").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx := shared.ContextWithAdmin(shared.ContextWithActor(context.Background(), "admin"), true)
+
+	result, err := grpcServer.CreateWebhookSubscription(ctx, &pb.CreateWebhookSubscriptionRequest{
+		Url:        "https://93.184.216.34/hook",
+		EventTypes: []string{shared.EventUserDeleted},
+		Secret:     "secret",
+	})
+	c.NoError(err)
+	c.Equal("https://93.184.216.34/hook", result.Url)
+	c.Equal([]string{shared.EventUserDeleted}, result.EventTypes)
+	c.Equal("secret", result.Secret)
+	c.Equal("admin", result.CreatedBy)
+	c.NotNil(result.CreatedAt)
+
+	_, err = grpcServer.CreateWebhookSubscription(ctx, &pb.CreateWebhookSubscriptionRequest{})
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+
+	_, err = grpcServer.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{
+		Url:        "https://93.184.216.34/hook",
+		EventTypes: []string{shared.EventUserDeleted},
+	})
+	c.Equal(codes.PermissionDenied, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrAdminRequired))
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	createdAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "created_at", "created_by"}).
+		AddRow("WHK123", "https://example.com/hook", `["user.created"]`, createdAt, "admin")
+
+	mock.ExpectQuery(regexp.QuoteMeta(repository.ListWebhookSubscriptionsQuery)).WillReturnRows(rows)
+
+	result, err := grpcServer.ListWebhookSubscriptions(shared.ContextWithAdmin(context.Background(), true), &pb.ListWebhookSubscriptionsRequest{})
+	c.NoError(err)
+	c.Equal(&pb.ListWebhookSubscriptionsResponse{
+		Subscriptions: []*pb.WebhookSubscription{
+			{
+				Id:         "WHK123",
+				Url:        "https://example.com/hook",
+				EventTypes: []string{shared.EventUserCreated},
+				CreatedAt:  timestamppb.New(createdAt),
+				CreatedBy:  "admin",
+			},
+		},
+	}, result)
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(repository.DeleteWebhookSubscriptionStatement)).WithArgs(sqlmock.AnyArg(), "WHK123").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(repository.CancelWebhookDeliveriesStatement)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(repository.InsertAuditEventStatement)).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := grpcServer.DeleteWebhookSubscription(shared.ContextWithAdmin(context.Background(), true), &pb.DeleteWebhookSubscriptionRequest{Id: "WHK123"})
+	c.NoError(err)
+	c.Equal("webhook subscription deleted successfully", result.Message)
+
+	_, err = grpcServer.DeleteWebhookSubscription(shared.ContextWithAdmin(context.Background(), true), &pb.DeleteWebhookSubscriptionRequest{})
+	c.Equal(codes.InvalidArgument, status.Code(err))
+	c.True(errors.Is(shared.FromGRPCError(err), shared.ErrInvalidRequest))
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	c := require.New(t)
+
+	db, mock := config.NewDatabaseMock()
+
+	logger := log.NewJSONLogger(os.Stdout)
+
+	svc := service.NewUserService(repository.NewUserRepository(db, logger), logger)
+
+	userEndpoints := endpoints.MakeEndpoints(svc)
+
+	grpcServer := NewGRPCServer(userEndpoints, log.NewJSONLogger(os.Stdout))
+
+	createdAt := time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at"}).
+		AddRow(1, "WHK123", 7, shared.EventUserCreated, shared.WebhookDeliveryPending, 0, createdAt, nil, 0, "", createdAt)
+
+	sqlString := regexp.QuoteMeta(repository.ListWebhookDeliveriesQuery + " ORDER BY id LIMIT ?")
+	mock.ExpectQuery(sqlString).WithArgs("WHK123", repository.DefaultPageSize+1).WillReturnRows(rows)
+
+	result, err := grpcServer.ListWebhookDeliveries(shared.ContextWithAdmin(context.Background(), true), &pb.ListWebhookDeliveriesRequest{SubscriptionId: "WHK123"})
+	c.NoError(err)
+	c.Equal(&pb.ListWebhookDeliveriesResponse{
+		Deliveries: []*pb.WebhookDelivery{
+			{
+				Id:             1,
+				SubscriptionId: "WHK123",
+				EventId:        7,
+				EventType:      shared.EventUserCreated,
+				Status:         shared.WebhookDeliveryPending,
+				NextAttemptAt:  timestamppb.New(createdAt),
+				CreatedAt:      timestamppb.New(createdAt),
+			},
+		},
+	}, result)
 }